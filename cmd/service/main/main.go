@@ -11,6 +11,7 @@ import (
 	"github.com/rs/zerolog"
 	"gorm.io/gorm"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -18,9 +19,12 @@ import (
 	"syscall"
 	"time"
 	"vn/cmd/service/model"
+	chapterService "vn/internal/services/chapter"
 	"vn/internal/transport/handlers/admin"
 	"vn/internal/transport/handlers/chapter"
 	"vn/internal/transport/handlers/character"
+	"vn/internal/transport/handlers/game"
+	"vn/internal/transport/handlers/node"
 	"vn/pkg/atlas"
 	"vn/pkg/metrick"
 )
@@ -78,6 +82,174 @@ func main() {
 		handler.ServeHTTP(w, r)
 	})
 
+	service.Router.HandleFunc("/validate-chapter", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.ValidateChapterHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/get-chapter-bundle", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.GetChapterBundleHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/export-renpy", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.ExportRenpyHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/import-story", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.ImportStoryHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/export-archive", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.ExportArchiveHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/import-archive", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.ImportArchiveHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/export-graph", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.ExportGraphHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/get-chapter-stats", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.GetChapterStatsHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/export-translations", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.ExportTranslationsHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/import-translations", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.ImportTranslationsHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/export-voice-script", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.ExportVoiceScriptHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/import-voice-files", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.ImportVoiceFilesHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/delete-chapter", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.DeleteChapterHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/restore-chapter", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.RestoreChapterHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/get-deleted-chapters", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.GetDeletedChaptersHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/get-chapter-versions", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.GetChapterVersionsHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/get-revisions", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.GetRevisionsHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/diff-revisions", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.DiffRevisionsHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/rollback-revision", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.RollbackRevisionHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/subscribe-chapter", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.SubscribeChapterHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+
+	service.Router.HandleFunc("/create-variable", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.CreateVariableHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/get-variables", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.GetVariablesHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/delete-variable", func(w http.ResponseWriter, r *http.Request) {
+		handler := chapter.DeleteVariableHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+
+	service.Router.HandleFunc("/create-node", func(w http.ResponseWriter, r *http.Request) {
+		handler := node.CreateNodeHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/get-node", func(w http.ResponseWriter, r *http.Request) {
+		handler := node.GetNodeHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/get-node-by-slug", func(w http.ResponseWriter, r *http.Request) {
+		handler := node.GetNodeBySlugHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/get-chapter-nodes", func(w http.ResponseWriter, r *http.Request) {
+		handler := node.GetChapterNodesHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/update-node", func(w http.ResponseWriter, r *http.Request) {
+		handler := node.UpdateNodeHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/get-node-screenplay", func(w http.ResponseWriter, r *http.Request) {
+		handler := node.GetNodeScreenplayHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/update-node-screenplay", func(w http.ResponseWriter, r *http.Request) {
+		handler := node.UpdateNodeScreenplayHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/lock-node", func(w http.ResponseWriter, r *http.Request) {
+		handler := node.LockNodeHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/renew-node-lock", func(w http.ResponseWriter, r *http.Request) {
+		handler := node.RenewNodeLockHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/unlock-node", func(w http.ResponseWriter, r *http.Request) {
+		handler := node.UnlockNodeHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/delete-node", func(w http.ResponseWriter, r *http.Request) {
+		handler := node.DeleteNodeHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/insert-event", func(w http.ResponseWriter, r *http.Request) {
+		handler := node.InsertEventHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/move-event", func(w http.ResponseWriter, r *http.Request) {
+		handler := node.MoveEventHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/duplicate-event", func(w http.ResponseWriter, r *http.Request) {
+		handler := node.DuplicateEventHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/delete-event", func(w http.ResponseWriter, r *http.Request) {
+		handler := node.DeleteEventHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+
+	service.Router.HandleFunc("/start-chapter", func(w http.ResponseWriter, r *http.Request) {
+		handler := game.StartChapterHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/get-current-scene", func(w http.ResponseWriter, r *http.Request) {
+		handler := game.GetCurrentSceneHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+	service.Router.HandleFunc("/make-choice", func(w http.ResponseWriter, r *http.Request) {
+		handler := game.MakeChoiceHandler(service.DB, service.Log)
+		handler.ServeHTTP(w, r)
+	})
+
 	service.Router.HandleFunc("/admin-authorization", func(w http.ResponseWriter, r *http.Request) {
 		handler := admin.AdminAuthorisationHandler(service.DB, service.Log, authConfig)
 		handler.ServeHTTP(w, r)
@@ -104,12 +276,18 @@ func main() {
 		handler.ServeHTTP(w, r)
 	})
 
+	// Контекст запросов отменяется при остановке, чтобы закрыть потоки событий редактора
+	requestsCtx, stopRequests := context.WithCancel(context.Background())
+
 	// Создаем экземпляр сервера
 	server := &http.Server{
-		Addr:    ":8080",
-		Handler: service.Router,
+		Addr:        ":8080",
+		Handler:     service.Router,
+		BaseContext: func(net.Listener) context.Context { return requestsCtx },
 	}
 
+	server.RegisterOnShutdown(stopRequests)
+
 	service.Log.Info().Msg("сервер успешно создан")
 
 	// Регистрируем обработчик сигналов
@@ -126,10 +304,16 @@ func main() {
 		service.Log.Println("Сервер завершил обработку новых подключений")
 	}()
 
+	// Окончательно удаляем главы с истекшим сроком восстановления
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	go chapterService.StartPurgeWorker(purgeCtx, time.Hour, service.DB)
+
 	// Ждем сигнала завершения
 	service.Log.Println("Сервер запущен. Нажмите Ctrl+C для завершения...")
 	<-stop
 
+	stopPurge()
+
 	// Graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
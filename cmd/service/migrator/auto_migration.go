@@ -13,6 +13,12 @@ func AutoMigrate() {
 	MigrateNode()
 	MigrateMedia()
 	MigrateRequest()
+	MigrateVariable()
+	MigrateDeletedChapter()
+	MigrateChapterVersion()
+	MigrateRevision()
+	MigrateNodeLock()
+	MigrateTranslation()
 }
 
 func MigrateAdmin() {
@@ -112,3 +118,87 @@ func MigrateRequest() {
 
 	log.Println("Таблицы успешно созданы")
 }
+
+func MigrateVariable() {
+	// Подключение к базе данных
+	db, err := InitDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	// Создание таблиц
+	// При необходимрсти меняй на другой метод
+	db.AutoMigrate(&models.Variable{}, &models.PlayerVariable{})
+
+	log.Println("Таблицы успешно созданы")
+}
+
+func MigrateDeletedChapter() {
+	// Подключение к базе данных
+	db, err := InitDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	// Создание таблиц
+	// При необходимрсти меняй на другой метод
+	db.AutoMigrate(&models.DeletedChapter{})
+
+	log.Println("Таблицы успешно созданы")
+}
+
+func MigrateChapterVersion() {
+	// Подключение к базе данных
+	db, err := InitDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	// Создание таблиц
+	// При необходимрсти меняй на другой метод
+	db.AutoMigrate(&models.ChapterVersion{}, &models.PlayerChapterVersion{})
+
+	log.Println("Таблицы успешно созданы")
+}
+
+func MigrateRevision() {
+	// Подключение к базе данных
+	db, err := InitDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	// Создание таблиц
+	// При необходимрсти меняй на другой метод
+	db.AutoMigrate(&models.Revision{})
+
+	log.Println("Таблицы успешно созданы")
+}
+
+func MigrateNodeLock() {
+	// Подключение к базе данных
+	db, err := InitDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	// Создание таблиц
+	// При необходимрсти меняй на другой метод
+	db.AutoMigrate(&models.NodeLock{})
+
+	log.Println("Таблицы успешно созданы")
+}
+
+func MigrateTranslation() {
+	// Подключение к базе данных
+	db, err := InitDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	// Создание таблиц
+	// При необходимрсти меняй на другой метод
+	db.AutoMigrate(&models.Translation{})
+
+	log.Println("Таблицы успешно созданы")
+}
@@ -13,4 +13,5 @@ type Chapter struct {
 	Status     int                 // 1 - черновик, 2 - на проверке, 3 - опубликована
 	UpdatedAt  map[time.Time]int64 `gorm:"type:json;column:updated_at"`
 	Author     int64
+	Version    int `gorm:"not null;default:1"` // растет при каждом изменении, для оптимистичной блокировки
 }
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// ChapterVersion - неизменяемый снимок опубликованной главы.
+// Игроки проходят главу по снимку, а не по редактируемым записям
+type ChapterVersion struct {
+	Id          int64          `gorm:"primary_key"`
+	ChapterId   int64          `gorm:"uniqueIndex:idx_chapter_version"`
+	Version     int            `gorm:"uniqueIndex:idx_chapter_version"` // номер публикации, начиная с 1
+	Content     ChapterContent `gorm:"type:jsonb;serializer:json"`
+	PublishedAt time.Time
+}
+
+type ChapterContent struct {
+	Chapter Chapter
+	Nodes   []Node
+}
+
+// Node возвращает узел из снимка главы
+func (content ChapterContent) Node(id int64) (Node, bool) {
+	for _, node := range content.Nodes {
+		if node.Id == id {
+			return node, true
+		}
+	}
+
+	return Node{}, false
+}
+
+// PlayerChapterVersion - версия главы, которую проходит игрок
+type PlayerChapterVersion struct {
+	Id        int64 `gorm:"primary_key"`
+	PlayerId  int64 `gorm:"uniqueIndex:idx_player_chapter_version"`
+	ChapterId int64 `gorm:"uniqueIndex:idx_player_chapter_version"`
+	VersionId int64
+}
@@ -1,9 +1,161 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+const (
+	EventNarration      = 0 // монолог героя или закадровый голос
+	EventCharacterEnter = 1 // персонаж появился
+	EventCharacterExit  = 2 // персонаж ушел
+	EventSpeech         = 3 // персонаж произносит речь
+	EventBackground     = 4 // смена фона
+	EventMusic          = 5 // запуск, остановка или смена музыки
+	EventSoundEffect    = 6 // звуковой эффект
+	EventScreenEffect   = 7 // эффект экрана: затемнение, вспышка, тряска
+	EventWait           = 8 // пауза
+
+	MusicPlay      = "play"
+	MusicStop      = "stop"
+	MusicCrossfade = "crossfade"
+
+	ScreenFadeIn  = "fade_in"
+	ScreenFadeOut = "fade_out"
+	ScreenFlash   = "flash"
+	ScreenShake   = "shake"
+)
+
 type Event struct {
-	Type              int64                     `json:"type"` // 0 - монолог героя или закадровый голос, 1- персонаж появилсяб 2 - перслнаж ушел, 3 - персонаж произносит речь
+	Id                int64                     `json:"id"`   // постоянный id события внутри узла, не зависит от позиции
+	Type              int64                     `json:"type"` // см. константы Event*
 	Character         int64                     `json:"character"`
 	Sound             int64                     `json:"sound"`
 	CharactersInEvent map[int64]map[int64]int64 `json:"characters_in_event" gorm:"type:json"` // персонаж - эмоция + позиция относительно левого края
 	Text              string                    `json:"text"`
+	Effects           []Effect                  `json:"effects,omitempty"`
+
+	// Данные новых типов событий, заполняется только поле своего типа
+	Background  *BackgroundPayload   `json:"background,omitempty"`
+	Music       *MusicPayload        `json:"music,omitempty"`
+	SoundEffect *SoundEffectPayload  `json:"sound_effect,omitempty"`
+	Screen      *ScreenEffectPayload `json:"screen,omitempty"`
+	Wait        *WaitPayload         `json:"wait,omitempty"`
+}
+
+type BackgroundPayload struct {
+	Media      int64  `json:"media"`
+	Transition string `json:"transition,omitempty"` // cut, fade или dissolve, по умолчанию cut
+}
+
+type MusicPayload struct {
+	Action string `json:"action"` // play, stop или crossfade
+	Media  int64  `json:"media,omitempty"`
+	FadeMs int64  `json:"fade_ms,omitempty"`
+	Loop   bool   `json:"loop,omitempty"`
+}
+
+type SoundEffectPayload struct {
+	Media  int64 `json:"media"`
+	Volume int64 `json:"volume"` // 0 - 100
+}
+
+type ScreenEffectPayload struct {
+	Effect     string `json:"effect"` // fade_in, fade_out, flash или shake
+	DurationMs int64  `json:"duration_ms"`
+	Color      string `json:"color,omitempty"`
+}
+
+type WaitPayload struct {
+	DurationMs int64 `json:"duration_ms"`
+	Skippable  bool  `json:"skippable,omitempty"`
+}
+
+// Events - события узла в порядке показа
+type Events []Event
+
+// UnmarshalJSON поддерживает старый формат {"индекс": событие}. События
+// упорядочиваются по индексу, а событиям без id назначается индекс + 1,
+// чтобы id не менялись между чтениями до первого сохранения узла
+func (events *Events) UnmarshalJSON(data []byte) error {
+	var list []Event
+
+	if err := json.Unmarshal(data, &list); err == nil {
+		*events = list
+		return nil
+	}
+
+	var legacy map[string]Event
+
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return fmt.Errorf("failed to unmarshal events: %w", err)
+	}
+
+	indexes := make([]int, 0, len(legacy))
+	byIndex := make(map[int]Event, len(legacy))
+
+	for key, event := range legacy {
+		index, err := strconv.Atoi(key)
+
+		if err != nil {
+			return fmt.Errorf("invalid event index %q: %w", key, err)
+		}
+
+		indexes = append(indexes, index)
+		byIndex[index] = event
+	}
+
+	sort.Ints(indexes)
+
+	list = make([]Event, 0, len(indexes))
+
+	for _, index := range indexes {
+		event := byIndex[index]
+
+		if event.Id == 0 {
+			event.Id = int64(index) + 1
+		}
+
+		list = append(list, event)
+	}
+
+	*events = list
+
+	return nil
+}
+
+// MediaIds возвращает id медиафайлов, на которые ссылается событие
+func (event Event) MediaIds() []int64 {
+	var ids []int64
+
+	if event.Sound != 0 {
+		ids = append(ids, event.Sound)
+	}
+
+	if event.Background != nil && event.Background.Media != 0 {
+		ids = append(ids, event.Background.Media)
+	}
+
+	if event.Music != nil && event.Music.Media != 0 {
+		ids = append(ids, event.Music.Media)
+	}
+
+	if event.SoundEffect != nil && event.SoundEffect.Media != 0 {
+		ids = append(ids, event.SoundEffect.Media)
+	}
+
+	return ids
+}
+
+// Find возвращает позицию события с указанным id или -1
+func (events Events) Find(id int64) int {
+	for i, event := range events {
+		if event.Id == id {
+			return i
+		}
+	}
+
+	return -1
 }
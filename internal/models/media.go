@@ -5,3 +5,10 @@ type Media struct {
 	FileData    []byte `json:"-" gorm:"type:bytea;column:file_data"` // для хранения файла
 	ContentType string `json:"content_type"`
 }
+
+// MediaInfo - описание медиафайла без содержимого
+type MediaInfo struct {
+	Id          int64
+	ContentType string
+	Size        int64
+}
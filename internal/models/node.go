@@ -1,20 +1,100 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
 type Node struct {
-	Id         int64 `gorm:"primary_key"`
-	Slug       string
-	Events     map[int]Event `gorm:"type:json"`
-	ChapterId  int64
+	Id         int64  `gorm:"primary_key"`
+	Slug       string `gorm:"uniqueIndex:idx_node_chapter_slug,priority:2"` // уникален в пределах главы
+	Events     Events `gorm:"type:json"`
+	ChapterId  int64  `gorm:"index;uniqueIndex:idx_node_chapter_slug,priority:1"`
 	Music      int64
 	Background int64
 	Branching  Branching `gorm:"type:json"`
 	End        EndInfo   `gorm:"type:json"`
 	Comment    string
+	Version    int `gorm:"not null;default:1"` // растет при каждом изменении, для оптимистичной блокировки
 }
 
 type Branching struct {
-	Flag      bool
-	Condition map[string]int64 `gorm:"type:json"`
+	Flag    bool     // true - игрок выбирает вариант, false - автоматический переход по первому доступному варианту
+	Choices []Choice `gorm:"type:json"` // варианты в порядке показа игроку
+}
+
+type Choice struct {
+	Id        int64 // уникален в пределах узла, 0 - вариант сохранен до появления id
+	Text      string
+	NextNode  int64
+	Condition string // выражение на языке pkg/condition, пустое - вариант доступен всегда
+}
+
+// UnmarshalJSON поддерживает старый формат условия {"переменная": минимум},
+// который переводится в выражение вида "a >= 1 && b >= 2"
+func (choice *Choice) UnmarshalJSON(data []byte) error {
+	type plainChoice Choice
+
+	var raw struct {
+		plainChoice
+		Condition json.RawMessage
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*choice = Choice(raw.plainChoice)
+
+	if len(raw.Condition) == 0 || string(raw.Condition) == "null" {
+		return nil
+	}
+
+	if err := json.Unmarshal(raw.Condition, &choice.Condition); err == nil {
+		return nil
+	}
+
+	var legacy map[string]int64
+
+	if err := json.Unmarshal(raw.Condition, &legacy); err != nil {
+		return fmt.Errorf("failed to unmarshal choice condition: %w", err)
+	}
+
+	choice.Condition = LegacyCondition(legacy)
+
+	return nil
+}
+
+// LegacyCondition переводит условие старого формата в выражение
+func LegacyCondition(legacy map[string]int64) string {
+	names := make([]string, 0, len(legacy))
+
+	for name := range legacy {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s >= %d", name, legacy[name]))
+	}
+
+	return strings.Join(parts, " && ")
+}
+
+// Targets возвращает id узлов, на которые ведут варианты выбора
+func (branching Branching) Targets() []int64 {
+	targets := make([]int64, 0, len(branching.Choices))
+
+	for _, choice := range branching.Choices {
+		targets = append(targets, choice.NextNode)
+	}
+
+	return targets
 }
 
 type EndInfo struct {
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// NodeLock - исключительное право админа редактировать узел до ExpiresAt.
+// Истекшая блокировка считается снятой
+type NodeLock struct {
+	NodeId    int64 `gorm:"primary_key;autoIncrement:false"`
+	AdminId   int64
+	ExpiresAt time.Time `gorm:"index"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	RevisionChapter = "chapter"
+	RevisionNode    = "node"
+)
+
+// Revision - полное состояние главы или узла после очередного изменения
+type Revision struct {
+	Id         int64           `gorm:"primary_key"`
+	EntityType string          `gorm:"index:idx_revision_entity"` // chapter или node
+	EntityId   int64           `gorm:"index:idx_revision_entity"`
+	ChapterId  int64           `gorm:"index"`
+	Author     int64           // 0 - автор изменения неизвестен
+	Data       json.RawMessage `gorm:"type:jsonb;serializer:json"`
+	CreatedAt  time.Time
+}
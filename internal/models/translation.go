@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Translation - перевод строки, которую видит игрок. Key указывает на строку
+// главы или персонажа, например node.<id>.event.<id> или character.<id>.name
+type Translation struct {
+	Id        int64  `gorm:"primary_key"`
+	Locale    string `gorm:"uniqueIndex:idx_translation_key,priority:1"`
+	ChapterId int64  `gorm:"uniqueIndex:idx_translation_key,priority:2"` // 0 - имена персонажей, общие для всех глав
+	Key       string `gorm:"uniqueIndex:idx_translation_key,priority:3"`
+	Source    string // исходный текст, который переводили. Если он изменился, перевод устарел
+	Text      string
+	UpdatedAt time.Time
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// DeletedChapter - удаленная глава. Хранит снимок главы со всем содержимым,
+// пока не истек срок восстановления
+type DeletedChapter struct {
+	Id        int64           `gorm:"primary_key"` // id удаленной главы
+	Snapshot  ChapterSnapshot `gorm:"type:jsonb;serializer:json"`
+	DeletedAt time.Time       `gorm:"index"`
+}
+
+// ChapterSnapshot - все, что удаляется вместе с главой. Не сохраняются блокировки узлов:
+// они живут минуты, и после восстановления узлы свободны. Прогресс в записях игроков
+// (ChaptersProgress, CompletedChapters) не трогается: без главы он не используется,
+// а после восстановления игроки продолжают с того же места
+type ChapterSnapshot struct {
+	Chapter         Chapter
+	Nodes           []Node
+	Requests        []Request
+	Variables       []Variable // только переменные главы, глобальные не удаляются
+	Admins          []AdminReferences
+	Versions        []ChapterVersion
+	PlayerVersions  []PlayerChapterVersion
+	PlayerVariables []PlayerVariable // значения переменных главы, глобальные не удаляются
+	Revisions       []Revision
+	Translations    []Translation // переводы имен персонажей общие и не удаляются
+}
+
+// AdminReferences - ссылки админа на главу и ее запросы, которые были удалены вместе с главой
+type AdminReferences struct {
+	AdminId          int64
+	CreatedChapter   bool
+	RequestSent      []int64
+	RequestsReceived []int64
+}
@@ -0,0 +1,35 @@
+package models
+
+const (
+	VariableTypeInt  = "int"  // счетчик или очки (например, симпатия персонажа)
+	VariableTypeFlag = "flag" // флаг, хранится как 0 или 1
+
+	EffectSet = "set"
+	EffectAdd = "add"
+	EffectSub = "sub"
+)
+
+// Variable - объявление переменной сюжета
+type Variable struct {
+	Id        int64  `gorm:"primary_key"`
+	ChapterId int64  `gorm:"uniqueIndex:idx_variable_name"` // 0 - глобальная переменная, сохраняется между главами
+	Name      string `gorm:"uniqueIndex:idx_variable_name"`
+	Type      string
+	Default   int64
+}
+
+// PlayerVariable - значение переменной сюжета для конкретного игрока
+type PlayerVariable struct {
+	Id        int64  `gorm:"primary_key"`
+	PlayerId  int64  `gorm:"uniqueIndex:idx_player_variable"`
+	ChapterId int64  `gorm:"uniqueIndex:idx_player_variable"` // 0 - глобальная переменная
+	Name      string `gorm:"uniqueIndex:idx_player_variable"`
+	Value     int64
+}
+
+// Effect - изменение переменной сюжета при показе события
+type Effect struct {
+	Variable  string `json:"variable"`
+	Operation string `json:"operation"` // set, add или sub
+	Value     int64  `json:"value"`
+}
@@ -7,6 +7,8 @@ import (
 	"math/rand"
 	"time"
 	"vn/internal/models"
+	"vn/internal/services/chapter"
+	"vn/internal/services/live"
 	"vn/internal/storage"
 )
 
@@ -15,8 +17,11 @@ const (
 
 	DefaultAdminStatus = -1
 
-	NoChapter                = -1
-	RegisterAdminTypeRequest = 1
+	NoChapter = -1
+
+	// Типы запросов, см. models.Request
+	PublishChapterTypeRequest = 1
+	RegisterAdminTypeRequest  = 2
 )
 
 func Registration(email string, name string, password string, db *gorm.DB) (int64, error) {
@@ -90,6 +95,19 @@ func CreateRequest(requestingAdminId int64, typeRequest int, requestedChapterId
 
 	log.Println(requestingAdminId, typeRequest, requestedChapterId)
 
+	// Перед публикацией проверяем, что граф главы можно пройти
+	if typeRequest == PublishChapterTypeRequest && requestedChapterId > 0 {
+		report, err := chapter.ValidateChapter(requestedChapterId, db)
+
+		if err != nil {
+			return 0, err
+		}
+
+		if !report.Valid() {
+			return 0, &chapter.GraphValidationError{Report: report}
+		}
+	}
+
 	newRequest := models.Request{
 		Id:                 id,
 		Type:               typeRequest,
@@ -133,7 +151,7 @@ func CreateRequest(requestingAdminId int64, typeRequest int, requestedChapterId
 
 	log.Println(typeRequest, "typeRequest")
 
-	if typeRequest == 1 && requestedChapterId != 0 {
+	if typeRequest == PublishChapterTypeRequest && requestedChapterId > 0 {
 
 		chapter, err := storage.SelectChapterWIthId(db, requestedChapterId)
 
@@ -150,6 +168,12 @@ func CreateRequest(requestingAdminId int64, typeRequest int, requestedChapterId
 		}
 
 		log.Println("yовый статус", chapter.Status)
+
+		live.Publish(live.Event{Type: live.ChapterStatusChanged, ChapterId: chapter.Id, Status: chapter.Status})
+	}
+
+	if requestedChapterId > 0 {
+		live.Publish(live.Event{Type: live.RequestSubmitted, ChapterId: requestedChapterId, RequestId: id})
 	}
 
 	return id, nil
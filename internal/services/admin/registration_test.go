@@ -10,6 +10,7 @@ import (
 	"gorm.io/gorm/logger"
 	"regexp"
 	"testing"
+	"vn/internal/services/live"
 )
 
 func TestRegistration(t *testing.T) {
@@ -179,3 +180,56 @@ func TestRegistration(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateRequestRegistrationIsNotPublished(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок базы данных: %v", err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("ошибка при создании подключения к БД: %v", err)
+	}
+
+	adminColumns := []string{"id", "name", "email", "password", "admin_status", "created_chapters_raw", "request_sent_raw", "requests_received_raw"}
+
+	// Запрос на регистрацию не относится к главе: ни проверки графа, ни смены статуса главы
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "requests"`)).
+		WithArgs(RegisterAdminTypeRequest, 0, 5, NoChapter, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`FROM admins`).
+		WillReturnRows(sqlmock.NewRows(adminColumns).AddRow(5, "Новый", "new@example.com", "pass", DefaultAdminStatus, "[]", "[]", "[]"))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "admins"`)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "admins" WHERE admin_status = $1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`FROM admins`).
+		WillReturnRows(sqlmock.NewRows(adminColumns).AddRow(1, "Главный", "root@example.com", "pass", 1, "[]", "[]", "[]"))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "admins"`)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	events, cancel := live.Subscribe(NoChapter)
+	defer cancel()
+
+	_, err = CreateRequest(5, RegisterAdminTypeRequest, NoChapter, gormDB)
+
+	if err != nil {
+		t.Fatalf("CreateRequest() ошибка = %v", err)
+	}
+
+	select {
+	case event := <-events:
+		t.Errorf("CreateRequest() опубликовал событие %q для запроса на регистрацию", event.Type)
+	default:
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("не все ожидания выполнены: %v", err)
+	}
+}
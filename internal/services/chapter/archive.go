@@ -0,0 +1,530 @@
+package chapter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"io"
+	"strconv"
+	"time"
+	"vn/internal/models"
+	"vn/internal/storage"
+)
+
+// ArchiveFormat - версия формата архива главы, растет при несовместимых изменениях
+const ArchiveFormat = 1
+
+// archiveMaxFile ограничивает размер файла внутри архива после распаковки
+const archiveMaxFile = 256 << 20
+
+var ErrInvalidArchive = errors.New("invalid chapter archive")
+
+type archiveManifest struct {
+	Format     int       `json:"format"`
+	ChapterId  int64     `json:"chapter_id"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+type archiveMedia struct {
+	Id          int64  `json:"id"`
+	ContentType string `json:"content_type"`
+}
+
+// ExportArchive упаковывает главу в zip: manifest.json, chapter.json, nodes.json,
+// characters.json, variables.json, media.json и содержимое медиафайлов в media/<id>
+func ExportArchive(chapterId int64, db *gorm.DB) ([]byte, error) {
+	chapter, err := storage.SelectChapterWIthId(db, chapterId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	loaded, err := loadChapterNodes(chapter, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &Bundle{Chapter: chapter, Nodes: make([]models.Node, 0, len(loaded))}
+
+	for _, nodeId := range chapter.Nodes {
+		if node, ok := loaded[nodeId]; ok {
+			bundle.Nodes = append(bundle.Nodes, node)
+		}
+	}
+
+	characters, err := storage.SelectCharactersWithIds(db, chapter.Characters)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, characterId := range chapter.Characters {
+		if character, ok := characters[characterId]; ok {
+			bundle.Characters = append(bundle.Characters, character)
+		}
+	}
+
+	variables, err := storage.SelectVariablesForChapter(db, chapterId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Глобальные переменные принадлежат всем главам и не переносятся
+	chapterVariables := make([]models.Variable, 0, len(variables))
+
+	for _, variable := range variables {
+		if variable.ChapterId == chapterId {
+			chapterVariables = append(chapterVariables, variable)
+		}
+	}
+
+	mediaIds := bundleMediaIds(bundle)
+
+	// Узлы без музыки и фона ссылаются на несуществующие файлы, их пропускаем
+	existing, err := storage.SelectExistingMediaIds(db, mediaIds)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	files := []struct {
+		name  string
+		value interface{}
+	}{
+		{"manifest.json", archiveManifest{Format: ArchiveFormat, ChapterId: chapterId, ExportedAt: time.Now().UTC()}},
+		{"chapter.json", chapter},
+		{"nodes.json", bundle.Nodes},
+		{"characters.json", bundle.Characters},
+		{"variables.json", chapterVariables},
+	}
+
+	for _, file := range files {
+		if err = writeArchiveJSON(archive, file.name, file.value); err != nil {
+			return nil, err
+		}
+	}
+
+	media := make([]archiveMedia, 0, len(existing))
+
+	for _, id := range mediaIds {
+		if !existing[id] {
+			continue
+		}
+
+		blob, err := storage.SelectMediaWIthId(db, id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		media = append(media, archiveMedia{Id: id, ContentType: blob.ContentType})
+
+		w, err := archive.Create(archiveMediaFile(id))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = w.Write(blob.FileData); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = writeArchiveJSON(archive, "media.json", media); err != nil {
+		return nil, err
+	}
+
+	if err = archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ImportArchive создает главу-черновик из архива ExportArchive. Глава, узлы, персонажи,
+// переменные и медиафайлы получают новые id, ссылки между ними переписываются.
+// Персонаж, slug которого уже занят, не создается: глава ссылается на существующего
+// персонажа с этим slug
+func ImportArchive(data []byte, authorId int64, db *gorm.DB) (int64, int64, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	var manifest archiveManifest
+
+	if err = readArchiveJSON(files, "manifest.json", &manifest); err != nil {
+		return 0, 0, err
+	}
+
+	if manifest.Format != ArchiveFormat {
+		return 0, 0, fmt.Errorf("%w: unsupported format %d", ErrInvalidArchive, manifest.Format)
+	}
+
+	var chapter models.Chapter
+	var nodes []models.Node
+	var characters []models.Character
+	var variables []models.Variable
+	var media []archiveMedia
+
+	for name, value := range map[string]interface{}{
+		"chapter.json":    &chapter,
+		"nodes.json":      &nodes,
+		"characters.json": &characters,
+		"variables.json":  &variables,
+		"media.json":      &media,
+	} {
+		if err = readArchiveJSON(files, name, value); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	ids := newArchiveIds()
+
+	for _, m := range media {
+		ids.media[m.Id] = ids.fresh()
+	}
+
+	for _, node := range nodes {
+		ids.nodes[node.Id] = ids.fresh()
+	}
+
+	var newChapter models.Chapter
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		newCharacters, err := ids.mapCharacters(characters, tx)
+
+		if err != nil {
+			return err
+		}
+
+		newChapter, err = ids.chapter(chapter, authorId)
+
+		if err != nil {
+			return err
+		}
+
+		newNodes := make([]models.Node, 0, len(nodes))
+
+		for _, node := range nodes {
+			newNode, err := ids.node(node, newChapter.Id)
+
+			if err != nil {
+				return err
+			}
+
+			newNodes = append(newNodes, newNode)
+		}
+
+		for _, m := range media {
+			blob, err := readArchiveFile(files, archiveMediaFile(m.Id))
+
+			if err != nil {
+				return err
+			}
+
+			_, err = storage.RegisterMedia(tx, models.Media{Id: ids.media[m.Id], FileData: blob, ContentType: m.ContentType})
+
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, character := range newCharacters {
+			if _, err := storage.RegisterCharacter(tx, character); err != nil {
+				return err
+			}
+		}
+
+		for _, node := range newNodes {
+			if err := storage.RestoreNode(tx, node); err != nil {
+				return err
+			}
+		}
+
+		if err := storage.RestoreChapter(tx, newChapter); err != nil {
+			return err
+		}
+
+		for i := range variables {
+			variables[i].Id = ids.fresh()
+			variables[i].ChapterId = newChapter.Id
+		}
+
+		return storage.RestoreVariables(tx, variables)
+	})
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return newChapter.Id, newChapter.StartNode, nil
+}
+
+// archiveIds - соответствие старых id из архива новым
+type archiveIds struct {
+	nodes      map[int64]int64
+	characters map[int64]int64
+	media      map[int64]int64
+	used       map[int64]bool
+}
+
+func newArchiveIds() *archiveIds {
+	return &archiveIds{
+		nodes:      map[int64]int64{},
+		characters: map[int64]int64{},
+		media:      map[int64]int64{},
+		used:       map[int64]bool{},
+	}
+}
+
+// fresh выдает новый id. generateUniqueId может повториться в пределах миллисекунды
+func (ids *archiveIds) fresh() int64 {
+	id := generateUniqueId()
+
+	for ids.used[id] {
+		id = generateUniqueId()
+	}
+
+	ids.used[id] = true
+
+	return id
+}
+
+func (ids *archiveIds) chapter(chapter models.Chapter, authorId int64) (models.Chapter, error) {
+	startNode, ok := ids.nodes[chapter.StartNode]
+
+	if !ok {
+		return models.Chapter{}, fmt.Errorf("%w: start node %d is not in archive", ErrInvalidArchive, chapter.StartNode)
+	}
+
+	newChapter := models.Chapter{
+		Id:         ids.fresh(),
+		Name:       chapter.Name,
+		StartNode:  startNode,
+		Nodes:      make([]int64, 0, len(chapter.Nodes)),
+		Characters: make([]int64, 0, len(chapter.Characters)),
+		Status:     DefaultStatus,
+		UpdatedAt:  map[time.Time]int64{time.Now(): authorId},
+		Author:     authorId,
+		Version:    1,
+	}
+
+	for _, nodeId := range chapter.Nodes {
+		if newId, ok := ids.nodes[nodeId]; ok {
+			newChapter.Nodes = append(newChapter.Nodes, newId)
+		}
+	}
+
+	for _, characterId := range chapter.Characters {
+		if newId, ok := ids.characters[characterId]; ok {
+			newChapter.Characters = append(newChapter.Characters, newId)
+		}
+	}
+
+	return newChapter, nil
+}
+
+func (ids *archiveIds) node(node models.Node, chapterId int64) (models.Node, error) {
+	newNode := node
+	newNode.Id = ids.nodes[node.Id]
+	newNode.ChapterId = chapterId
+	newNode.Music = ids.media[node.Music]
+	newNode.Background = ids.media[node.Background]
+	newNode.Version = 1
+	newNode.Events = make(models.Events, 0, len(node.Events))
+
+	for _, event := range node.Events {
+		newEvent, err := ids.event(event)
+
+		if err != nil {
+			return models.Node{}, fmt.Errorf("node %d: %w", node.Id, err)
+		}
+
+		newNode.Events = append(newNode.Events, newEvent)
+	}
+
+	newNode.Branching.Choices = make([]models.Choice, 0, len(node.Branching.Choices))
+
+	for _, choice := range node.Branching.Choices {
+		target, ok := ids.nodes[choice.NextNode]
+
+		if !ok {
+			return models.Node{}, fmt.Errorf("%w: node %d leads to node %d outside of archive", ErrInvalidArchive, node.Id, choice.NextNode)
+		}
+
+		choice.NextNode = target
+		newNode.Branching.Choices = append(newNode.Branching.Choices, choice)
+	}
+
+	return newNode, nil
+}
+
+// event переписывает id персонажей и медиафайлов события. Медиафайлы, которых
+// нет в архиве, сбрасываются в 0
+func (ids *archiveIds) event(event models.Event) (models.Event, error) {
+	if event.Character != 0 {
+		character, ok := ids.characters[event.Character]
+
+		if !ok {
+			return models.Event{}, fmt.Errorf("%w: character %d is not in archive", ErrInvalidArchive, event.Character)
+		}
+
+		event.Character = character
+	}
+
+	if event.CharactersInEvent != nil {
+		inEvent := make(map[int64]map[int64]int64, len(event.CharactersInEvent))
+
+		for characterId, emotions := range event.CharactersInEvent {
+			character, ok := ids.characters[characterId]
+
+			if !ok {
+				return models.Event{}, fmt.Errorf("%w: character %d is not in archive", ErrInvalidArchive, characterId)
+			}
+
+			inEvent[character] = emotions
+		}
+
+		event.CharactersInEvent = inEvent
+	}
+
+	event.Sound = ids.media[event.Sound]
+
+	if event.Background != nil {
+		payload := *event.Background
+		payload.Media = ids.media[payload.Media]
+		event.Background = &payload
+	}
+
+	if event.Music != nil {
+		payload := *event.Music
+		payload.Media = ids.media[payload.Media]
+		event.Music = &payload
+	}
+
+	if event.SoundEffect != nil {
+		payload := *event.SoundEffect
+		payload.Media = ids.media[payload.Media]
+		event.SoundEffect = &payload
+	}
+
+	return event, nil
+}
+
+// mapCharacters сопоставляет персонажей архива с существующими по slug, slug уникален.
+// Возвращает персонажей, которых нужно создать, уже с новыми id
+func (ids *archiveIds) mapCharacters(characters []models.Character, db *gorm.DB) ([]models.Character, error) {
+	existing, err := storage.SelectCharacters(db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	bySlug := make(map[string]int64, len(existing))
+
+	for _, c := range existing {
+		bySlug[c.Slug] = c.Id
+	}
+
+	newCharacters := make([]models.Character, 0, len(characters))
+
+	for _, character := range characters {
+		if id, ok := bySlug[character.Slug]; ok {
+			ids.characters[character.Id] = id
+			continue
+		}
+
+		ids.characters[character.Id] = ids.fresh()
+		bySlug[character.Slug] = ids.characters[character.Id]
+		newCharacters = append(newCharacters, ids.character(character))
+	}
+
+	return newCharacters, nil
+}
+
+func (ids *archiveIds) character(character models.Character) models.Character {
+	newCharacter := character
+	newCharacter.Id = ids.characters[character.Id]
+	newCharacter.Emotions = make(map[int64]int64, len(character.Emotions))
+
+	for emotion, image := range character.Emotions {
+		newCharacter.Emotions[emotion] = ids.media[image]
+	}
+
+	return newCharacter
+}
+
+func archiveMediaFile(id int64) string {
+	return "media/" + strconv.FormatInt(id, 10)
+}
+
+func writeArchiveJSON(archive *zip.Writer, name string, value interface{}) error {
+	w, err := archive.Create(name)
+
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(value)
+}
+
+func readArchiveFile(files map[string]*zip.File, name string) ([]byte, error) {
+	file, ok := files[name]
+
+	if !ok {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidArchive, name)
+	}
+
+	r, err := file.Open()
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidArchive, name, err)
+	}
+
+	defer r.Close()
+
+	data, err := io.ReadAll(io.LimitReader(r, archiveMaxFile+1))
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidArchive, name, err)
+	}
+
+	if len(data) > archiveMaxFile {
+		return nil, fmt.Errorf("%w: %s is too large", ErrInvalidArchive, name)
+	}
+
+	return data, nil
+}
+
+func readArchiveJSON(files map[string]*zip.File, name string, value interface{}) error {
+	data, err := readArchiveFile(files, name)
+
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidArchive, name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package chapter
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"regexp"
+	"testing"
+	"vn/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func testArchive(t *testing.T, manifest archiveManifest) []byte {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	files := []struct {
+		name  string
+		value interface{}
+	}{
+		{"manifest.json", manifest},
+		{"chapter.json", models.Chapter{Id: 1, Name: "Глава", StartNode: 10, Nodes: []int64{10, 11}, Characters: []int64{7}, Status: 3}},
+		{"nodes.json", []models.Node{
+			{Id: 10, Slug: "start", ChapterId: 1, Background: 100, Music: 1,
+				Events:    models.Events{{Id: 1, Type: models.EventSpeech, Character: 7, Text: "Привет"}},
+				Branching: models.Branching{Flag: true, Choices: []models.Choice{{Text: "Дальше", NextNode: 11}}}},
+			{Id: 11, Slug: "end", ChapterId: 1, End: models.EndInfo{Flag: true}},
+		}},
+		{"characters.json", []models.Character{{Id: 7, Name: "Алиса", Slug: "alice", Emotions: map[int64]int64{1: 100}}}},
+		{"variables.json", []models.Variable{{Id: 5, ChapterId: 1, Name: "trust", Type: models.VariableTypeInt}}},
+		{"media.json", []archiveMedia{{Id: 100, ContentType: "image/png"}}},
+	}
+
+	for _, file := range files {
+		assert.NoError(t, writeArchiveJSON(archive, file.name, file.value))
+	}
+
+	w, err := archive.Create(archiveMediaFile(100))
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("png"))
+	assert.NoError(t, err)
+	assert.NoError(t, archive.Close())
+
+	return buf.Bytes()
+}
+
+func TestImportArchive(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании моковой БД: %v", err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("ошибка при создании подключения к БД: %v", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM characters`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "color", "emotions_raw"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "media"`)).
+		WithArgs([]byte("png"), "image/png", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "characters"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "nodes"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "nodes"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "chapters"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "variables"`)).
+		WithArgs(sqlmock.AnyArg(), "trust", models.VariableTypeInt, 0, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(6))
+	mock.ExpectCommit()
+
+	chapterId, startNode, err := ImportArchive(testArchive(t, archiveManifest{Format: ArchiveFormat, ChapterId: 1}), 2, gormDB)
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, int64(1), chapterId)
+	assert.NotEqual(t, int64(10), startNode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportArchiveExistingCharacter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании моковой БД: %v", err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("ошибка при создании подключения к БД: %v", err)
+	}
+
+	// Алиса со slug "alice" уже есть, повторная вставка нарушила бы уникальность slug
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM characters`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "color", "emotions_raw"}).
+			AddRow(70, "Алиса", "alice", "#ff0000", `{}`))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "media"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	// Реплика и список персонажей главы ссылаются на существующую Алису
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "nodes"`)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			eventsWithCharacter{70}, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "nodes"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "chapters"`)).
+		WithArgs(sqlmock.AnyArg(), []byte(`[70]`), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "variables"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(6))
+	mock.ExpectCommit()
+
+	_, _, err = ImportArchive(testArchive(t, archiveManifest{Format: ArchiveFormat, ChapterId: 1}), 2, gormDB)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// eventsWithCharacter проверяет, что сохраненные события ссылаются на персонажа
+type eventsWithCharacter struct {
+	character int64
+}
+
+func (m eventsWithCharacter) Match(v driver.Value) bool {
+	data, ok := v.([]byte)
+
+	if !ok {
+		return false
+	}
+
+	var events models.Events
+
+	if err := json.Unmarshal(data, &events); err != nil {
+		return false
+	}
+
+	return len(events) > 0 && events[0].Character == m.character
+}
+
+func TestImportArchiveInvalid(t *testing.T) {
+	_, _, err := ImportArchive([]byte("не zip"), 2, nil)
+	assert.ErrorIs(t, err, ErrInvalidArchive)
+
+	_, _, err = ImportArchive(testArchive(t, archiveManifest{Format: ArchiveFormat + 1}), 2, nil)
+	assert.ErrorIs(t, err, ErrInvalidArchive)
+}
+
+func TestArchiveIdsNode(t *testing.T) {
+	ids := newArchiveIds()
+	ids.nodes = map[int64]int64{10: 20, 11: 21}
+	ids.characters = map[int64]int64{7: 17}
+	ids.media = map[int64]int64{100: 200}
+
+	node, err := ids.node(models.Node{
+		Id:         10,
+		ChapterId:  1,
+		Background: 100,
+		Music:      1,
+		Events: models.Events{
+			{Id: 1, Type: models.EventCharacterEnter, CharactersInEvent: map[int64]map[int64]int64{7: {1: 50}}},
+			{Id: 2, Type: models.EventBackground, Background: &models.BackgroundPayload{Media: 100}},
+		},
+		Branching: models.Branching{Choices: []models.Choice{{NextNode: 11}}},
+		Version:   7,
+	}, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), node.Id)
+	assert.Equal(t, 1, node.Version, "импортированный узел начинает с первой версии")
+	assert.Equal(t, int64(2), node.ChapterId)
+	assert.Equal(t, int64(200), node.Background)
+	assert.Equal(t, int64(0), node.Music, "медиафайла нет в архиве")
+	assert.Equal(t, map[int64]map[int64]int64{17: {1: 50}}, node.Events[0].CharactersInEvent)
+	assert.Equal(t, int64(200), node.Events[1].Background.Media)
+	assert.Equal(t, int64(21), node.Branching.Choices[0].NextNode)
+
+	_, err = ids.node(models.Node{Id: 10, Branching: models.Branching{Choices: []models.Choice{{NextNode: 99}}}}, 2)
+	assert.ErrorIs(t, err, ErrInvalidArchive)
+}
+
+func TestArchiveIdsChapter(t *testing.T) {
+	ids := newArchiveIds()
+	ids.nodes = map[int64]int64{10: 20}
+	ids.characters = map[int64]int64{7: 17}
+
+	chapter, err := ids.chapter(models.Chapter{Id: 1, StartNode: 10, Nodes: []int64{10}, Characters: []int64{7}, Version: 5}, 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), chapter.StartNode)
+	assert.Equal(t, []int64{20}, chapter.Nodes)
+	assert.Equal(t, []int64{17}, chapter.Characters)
+	assert.Equal(t, int64(3), chapter.Author)
+	assert.Equal(t, 1, chapter.Version, "импортированная глава начинает с первой версии")
+}
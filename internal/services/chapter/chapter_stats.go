@@ -0,0 +1,290 @@
+package chapter
+
+import (
+	"gorm.io/gorm"
+	"sort"
+	"strings"
+	"vn/internal/models"
+)
+
+// wordsPerMinute - средняя скорость чтения художественного текста с экрана
+const wordsPerMinute = 180
+
+// ChapterStats - объем главы для планирования релизов и бюджета озвучки
+type ChapterStats struct {
+	ChapterId      int64
+	Nodes          int
+	Events         int
+	Words          int // слова реплик, вариантов выбора и текстов концовок
+	ReadingSeconds int // время чтения всего текста главы
+	Choices        int // варианты, которые выбирает игрок, без автоматических переходов
+	Endings        int
+	ShortestPath   *StoryPath // nil - из начального узла не достичь концовки
+	LongestPath    *StoryPath // в главе с циклами - оценка снизу, тогда Approximate = true
+	Characters     []CharacterLines
+}
+
+// StoryPath - прохождение от начального узла до концовки
+type StoryPath struct {
+	Nodes          []int64
+	Words          int
+	ReadingSeconds int
+	Approximate    bool // путь может быть не самым длинным, см. ChapterStats.LongestPath
+}
+
+// CharacterLines - сколько говорит персонаж
+type CharacterLines struct {
+	CharacterId int64
+	Name        string
+	Lines       int
+	Words       int
+}
+
+// GetChapterStats считает статистику текущего черновика главы
+func GetChapterStats(chapterId int64, db *gorm.DB) (*ChapterStats, error) {
+	chapter, nodes, characters, err := loadChapterContents(chapterId, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return CountChapterStats(chapter, nodes, characters), nil
+}
+
+// CountChapterStats считает статистику по загруженным узлам и персонажам главы
+func CountChapterStats(chapter models.Chapter, nodes []models.Node, characters []models.Character) *ChapterStats {
+	stats := &ChapterStats{ChapterId: chapter.Id, Nodes: len(nodes), Characters: []CharacterLines{}}
+
+	listed := map[int64]bool{}
+
+	for _, character := range characters {
+		stats.Characters = append(stats.Characters, CharacterLines{CharacterId: character.Id, Name: character.Name})
+		listed[character.Id] = true
+	}
+
+	// Реплики персонажей, которых убрали из списка главы, тоже считаются
+	var extra []CharacterLines
+
+	for _, node := range nodes {
+		stats.Events += len(node.Events)
+		stats.Words += nodeWords(node)
+
+		if node.Branching.Flag {
+			stats.Choices += len(node.Branching.Choices)
+		}
+
+		if node.End.Flag {
+			stats.Endings++
+		}
+
+		for _, event := range node.Events {
+			if event.Type == models.EventSpeech && event.Character != 0 && !listed[event.Character] {
+				extra = append(extra, CharacterLines{CharacterId: event.Character})
+				listed[event.Character] = true
+			}
+		}
+	}
+
+	sort.Slice(extra, func(a, b int) bool { return extra[a].CharacterId < extra[b].CharacterId })
+
+	stats.Characters = append(stats.Characters, extra...)
+	lines := make(map[int64]*CharacterLines, len(stats.Characters))
+
+	for i := range stats.Characters {
+		lines[stats.Characters[i].CharacterId] = &stats.Characters[i]
+	}
+
+	for _, node := range nodes {
+		for _, event := range node.Events {
+			if event.Type == models.EventSpeech && event.Character != 0 {
+				lines[event.Character].Lines++
+				lines[event.Character].Words += countWords(event.Text)
+			}
+		}
+	}
+
+	stats.ReadingSeconds = readingSeconds(stats.Words)
+
+	byId := make(map[int64]models.Node, len(nodes))
+
+	for _, node := range nodes {
+		byId[node.Id] = node
+	}
+
+	stats.ShortestPath = storyPath(shortestPath(chapter.StartNode, byId), byId)
+
+	longest, approximate := longestPath(chapter.StartNode, byId)
+	stats.LongestPath = storyPath(longest, byId)
+
+	if stats.LongestPath != nil {
+		stats.LongestPath.Approximate = approximate
+	}
+
+	return stats
+}
+
+func countWords(text string) int {
+	return len(strings.Fields(text))
+}
+
+// nodeWords - слова, которые игрок читает в узле
+func nodeWords(node models.Node) int {
+	words := countWords(node.End.EndText)
+
+	for _, event := range node.Events {
+		words += countWords(event.Text)
+	}
+
+	for _, choice := range node.Branching.Choices {
+		words += countWords(choice.Text)
+	}
+
+	return words
+}
+
+func readingSeconds(words int) int {
+	return (words*60 + wordsPerMinute - 1) / wordsPerMinute
+}
+
+func storyPath(path []int64, nodes map[int64]models.Node) *StoryPath {
+	if path == nil {
+		return nil
+	}
+
+	res := &StoryPath{Nodes: path}
+
+	for _, nodeId := range path {
+		res.Words += nodeWords(nodes[nodeId])
+	}
+
+	res.ReadingSeconds = readingSeconds(res.Words)
+
+	return res
+}
+
+// shortestPath ищет путь до ближайшей концовки обходом в ширину
+func shortestPath(start int64, nodes map[int64]models.Node) []int64 {
+	if _, ok := nodes[start]; !ok {
+		return nil
+	}
+
+	parent := map[int64]int64{start: 0}
+	queue := []int64{start}
+
+	for len(queue) > 0 {
+		nodeId := queue[0]
+		queue = queue[1:]
+
+		if nodes[nodeId].End.Flag {
+			var path []int64
+
+			for id := nodeId; id != 0; id = parent[id] {
+				path = append([]int64{id}, path...)
+			}
+
+			return path
+		}
+
+		for _, target := range nodes[nodeId].Branching.Targets() {
+			if _, ok := nodes[target]; !ok {
+				continue
+			}
+
+			if _, seen := parent[target]; !seen {
+				parent[target] = nodeId
+				queue = append(queue, target)
+			}
+		}
+	}
+
+	return nil
+}
+
+// longestPath ищет самый длинный путь до концовки без повторов узлов. Точная задача
+// для графа с циклами NP-трудна, поэтому переходы назад по циклам отбрасываются при
+// обходе в глубину в порядке вариантов, и на оставшемся ациклическом графе длина
+// считается за линейное время. Второе значение true, если переходы отбрасывались:
+// тогда найденный путь - оценка снизу, которая зависит от порядка вариантов
+func longestPath(start int64, nodes map[int64]models.Node) ([]int64, bool) {
+	if _, ok := nodes[start]; !ok {
+		return nil, false
+	}
+
+	const (
+		white = iota
+		gray
+		black
+	)
+
+	color := map[int64]int{}
+	back := map[[2]int64]bool{}
+
+	var classify func(nodeId int64)
+	classify = func(nodeId int64) {
+		color[nodeId] = gray
+
+		for _, target := range nodes[nodeId].Branching.Targets() {
+			if _, ok := nodes[target]; !ok {
+				continue
+			}
+
+			switch color[target] {
+			case white:
+				classify(target)
+			case gray:
+				back[[2]int64{nodeId, target}] = true
+			}
+		}
+
+		color[nodeId] = black
+	}
+
+	classify(start)
+
+	// length - число узлов в самом длинном пути до концовки, 0 - концовка недостижима
+	length := map[int64]int{}
+	next := map[int64]int64{}
+	done := map[int64]bool{}
+
+	var measure func(nodeId int64) int
+	measure = func(nodeId int64) int {
+		if done[nodeId] {
+			return length[nodeId]
+		}
+
+		done[nodeId] = true
+		node := nodes[nodeId]
+
+		// Концовка завершает прохождение, переходы из нее игрок не видит
+		if node.End.Flag {
+			length[nodeId] = 1
+			return 1
+		}
+
+		for _, target := range node.Branching.Targets() {
+			if _, ok := nodes[target]; !ok || back[[2]int64{nodeId, target}] {
+				continue
+			}
+
+			if l := measure(target); l > 0 && l+1 > length[nodeId] {
+				length[nodeId] = l + 1
+				next[nodeId] = target
+			}
+		}
+
+		return length[nodeId]
+	}
+
+	if measure(start) == 0 {
+		return nil, len(back) > 0
+	}
+
+	path := []int64{start}
+
+	for nodeId := start; !nodes[nodeId].End.Flag; {
+		nodeId = next[nodeId]
+		path = append(path, nodeId)
+	}
+
+	return path, len(back) > 0
+}
@@ -0,0 +1,89 @@
+package chapter
+
+import (
+	"testing"
+	"vn/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountChapterStats(t *testing.T) {
+	chapter := models.Chapter{Id: 1, StartNode: 10}
+
+	nodes := []models.Node{
+		{Id: 10, Events: models.Events{
+			{Id: 1, Type: models.EventNarration, Text: "Утро в лесу"},
+			{Id: 2, Type: models.EventSpeech, Character: 5, Text: "Куда пойдем?"},
+		}, Branching: models.Branching{Flag: true, Choices: []models.Choice{
+			{Text: "Налево", NextNode: 11},
+			{Text: "Направо", NextNode: 12},
+		}}},
+		{Id: 11, Events: models.Events{
+			{Id: 1, Type: models.EventSpeech, Character: 7, Text: "Тише"},
+		}, Branching: models.Branching{Choices: []models.Choice{{NextNode: 13}}}},
+		{Id: 12, Events: models.Events{
+			{Id: 1, Type: models.EventWait},
+		}, Branching: models.Branching{Choices: []models.Choice{{NextNode: 10}}}}, // возврат в начало
+		{Id: 13, Branching: models.Branching{Choices: []models.Choice{{NextNode: 14}}}},
+		{Id: 14, End: models.EndInfo{Flag: true, EndText: "Конец"}},
+		{Id: 15, End: models.EndInfo{Flag: true}}, // недостижимая концовка
+	}
+
+	characters := []models.Character{{Id: 5, Name: "Анна"}, {Id: 6, Name: "Борис"}}
+
+	stats := CountChapterStats(chapter, nodes, characters)
+
+	assert.Equal(t, 6, stats.Nodes)
+	assert.Equal(t, 4, stats.Events)
+	assert.Equal(t, 9, stats.Words)
+	assert.Equal(t, 3, stats.ReadingSeconds)
+	assert.Equal(t, 2, stats.Choices)
+	assert.Equal(t, 2, stats.Endings)
+
+	assert.Equal(t, []CharacterLines{
+		{CharacterId: 5, Name: "Анна", Lines: 1, Words: 2},
+		{CharacterId: 6, Name: "Борис"},
+		{CharacterId: 7, Lines: 1, Words: 1},
+	}, stats.Characters)
+
+	assert.Equal(t, &StoryPath{Nodes: []int64{10, 11, 13, 14}, Words: 9, ReadingSeconds: 3}, stats.ShortestPath)
+	assert.Equal(t, []int64{10, 11, 13, 14}, stats.LongestPath.Nodes)
+	// Возврат из 12 в начало - цикл, длинный путь только оценка
+	assert.False(t, stats.ShortestPath.Approximate)
+	assert.True(t, stats.LongestPath.Approximate)
+}
+
+func TestCountChapterStatsNoEnding(t *testing.T) {
+	nodes := []models.Node{
+		{Id: 10, Branching: models.Branching{Choices: []models.Choice{{NextNode: 11}}}},
+		{Id: 11, Branching: models.Branching{Choices: []models.Choice{{NextNode: 10}}}},
+	}
+
+	stats := CountChapterStats(models.Chapter{Id: 1, StartNode: 10}, nodes, nil)
+
+	assert.Nil(t, stats.ShortestPath)
+	assert.Nil(t, stats.LongestPath)
+	assert.Equal(t, []CharacterLines{}, stats.Characters)
+}
+
+func TestLongestPath(t *testing.T) {
+	nodes := map[int64]models.Node{
+		1: {Id: 1, Branching: models.Branching{Choices: []models.Choice{{NextNode: 2}, {NextNode: 4}}}},
+		2: {Id: 2, Branching: models.Branching{Choices: []models.Choice{{NextNode: 3}}}},
+		3: {Id: 3, Branching: models.Branching{Choices: []models.Choice{{NextNode: 1}, {NextNode: 4}}}},
+		4: {Id: 4, End: models.EndInfo{Flag: true}},
+	}
+
+	assert.Equal(t, []int64{1, 4}, shortestPath(1, nodes))
+
+	path, approximate := longestPath(1, nodes)
+	assert.Equal(t, []int64{1, 2, 3, 4}, path)
+	assert.True(t, approximate)
+
+	// Без цикла путь точный
+	nodes[3] = models.Node{Id: 3, Branching: models.Branching{Choices: []models.Choice{{NextNode: 4}}}}
+
+	path, approximate = longestPath(1, nodes)
+	assert.Equal(t, []int64{1, 2, 3, 4}, path)
+	assert.False(t, approximate)
+}
@@ -0,0 +1,88 @@
+package chapter
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"vn/internal/models"
+	"vn/internal/storage"
+)
+
+// ErrVersionRequired возвращается, если клиент не передал версию, которую он видел
+var ErrVersionRequired = errors.New("version is required")
+
+// ChapterConflictError возвращается, когда глава изменилась после того, как клиент ее загрузил.
+// Current - текущее состояние главы
+type ChapterConflictError struct {
+	Current models.Chapter
+}
+
+func (e *ChapterConflictError) Error() string {
+	return fmt.Sprintf("chapter %d was modified, current version %d", e.Current.Id, e.Current.Version)
+}
+
+// NodeConflictError возвращается, когда узел изменился после того, как клиент его загрузил.
+// Current - текущее состояние узла
+type NodeConflictError struct {
+	Current models.Node
+}
+
+func (e *NodeConflictError) Error() string {
+	return fmt.Sprintf("node %d was modified, current version %d", e.Current.Id, e.Current.Version)
+}
+
+// checkChapterVersion сравнивает версию, которую видел клиент, с текущей версией главы
+func checkChapterVersion(chapter models.Chapter, version int) error {
+	if version <= 0 {
+		return ErrVersionRequired
+	}
+
+	if version != chapter.Version {
+		return &ChapterConflictError{Current: chapter}
+	}
+
+	return nil
+}
+
+// checkNodeVersion сравнивает версию, которую видел клиент, с текущей версией узла
+func checkNodeVersion(node models.Node, version int) error {
+	if version <= 0 {
+		return ErrVersionRequired
+	}
+
+	if version != node.Version {
+		return &NodeConflictError{Current: node}
+	}
+
+	return nil
+}
+
+// chapterConflict перечитывает главу, если сохранение проиграло гонку за версию
+func chapterConflict(id int64, err error, db *gorm.DB) error {
+	if !errors.Is(err, storage.ErrVersionConflict) {
+		return err
+	}
+
+	current, selectErr := storage.SelectChapterWIthId(db, id)
+
+	if selectErr != nil {
+		return err
+	}
+
+	return &ChapterConflictError{Current: current}
+}
+
+// nodeConflict перечитывает узел, если сохранение проиграло гонку за версию
+func nodeConflict(id int64, err error, db *gorm.DB) error {
+	if !errors.Is(err, storage.ErrVersionConflict) {
+		return err
+	}
+
+	current, selectErr := GetNode(id, db)
+
+	if selectErr != nil {
+		return err
+	}
+
+	return &NodeConflictError{Current: *current}
+}
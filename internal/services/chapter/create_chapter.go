@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"time"
 	"vn/internal/models"
+	"vn/internal/services/live"
 	"vn/internal/storage"
 )
 
@@ -69,11 +70,17 @@ func CreateNode(chapterId int64, slug string, db *gorm.DB) (int64, error) {
 
 	id := generateUniqueId()
 
+	if slug == "" {
+		slug = defaultSlug(id)
+	} else if err := checkSlug(chapterId, slug, id, db); err != nil {
+		return 0, err
+	}
+
 	newNode := models.Node{
 		Id:        id,
 		Slug:      slug,
 		ChapterId: chapterId,
-		Events:    map[int]models.Event{},
+		Events:    models.Events{},
 		Branching: models.Branching{},
 		End:       models.EndInfo{},
 		Comment:   " ",
@@ -99,5 +106,7 @@ func CreateNode(chapterId int64, slug string, db *gorm.DB) (int64, error) {
 		return 0, err
 	}
 
+	live.Publish(live.Event{Type: live.NodeCreated, ChapterId: chapterId, NodeId: id})
+
 	return id, nil
 }
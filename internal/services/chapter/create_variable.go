@@ -0,0 +1,55 @@
+package chapter
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"regexp"
+	"vn/internal/models"
+	"vn/internal/storage"
+)
+
+// Имя переменной - идентификаторы через точку, например affinity.anna или flag.met_boss
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// CreateVariable объявляет переменную сюжета. chapterId == 0 - глобальная переменная
+func CreateVariable(chapterId int64, name string, variableType string, defaultValue int64, db *gorm.DB) (int64, error) {
+	if !variableNamePattern.MatchString(name) {
+		return 0, fmt.Errorf("invalid variable name %q", name)
+	}
+
+	if variableType != models.VariableTypeInt && variableType != models.VariableTypeFlag {
+		return 0, fmt.Errorf("unknown variable type %q", variableType)
+	}
+
+	if variableType == models.VariableTypeFlag && defaultValue != 0 {
+		defaultValue = 1
+	}
+
+	variables, err := storage.SelectVariablesForChapter(db, chapterId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, variable := range variables {
+		if variable.Name == name {
+			return 0, fmt.Errorf("variable %q is already declared", name)
+		}
+	}
+
+	id := generateUniqueId()
+
+	_, err = storage.RegisterVariable(db, models.Variable{
+		Id:        id,
+		ChapterId: chapterId,
+		Name:      name,
+		Type:      variableType,
+		Default:   defaultValue,
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
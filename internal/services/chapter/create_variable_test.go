@@ -0,0 +1,16 @@
+package chapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVariableNamePattern(t *testing.T) {
+	assert.True(t, variableNamePattern.MatchString("affinity.anna"))
+	assert.True(t, variableNamePattern.MatchString("flag.met_boss"))
+	assert.True(t, variableNamePattern.MatchString("counter"))
+	assert.False(t, variableNamePattern.MatchString("affinity..anna"))
+	assert.False(t, variableNamePattern.MatchString("1st"))
+	assert.False(t, variableNamePattern.MatchString("anna affinity"))
+}
@@ -0,0 +1,414 @@
+package chapter
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"log"
+	"time"
+	"vn/internal/models"
+	"vn/internal/storage"
+)
+
+// RetentionPeriod - сколько удаленная глава доступна для восстановления
+const RetentionPeriod = 30 * 24 * time.Hour
+
+var ErrRestoreExpired = errors.New("chapter restore period has expired")
+
+// DeleteChapter удаляет главу вместе с узлами, запросами, переменными главы,
+// опубликованными версиями, ревизиями, переводами, данными игроков по главе
+// и ссылками админов на нее. Снимок удаленного сохраняется для восстановления
+func DeleteChapter(id int64, db *gorm.DB) error {
+	chapter, err := storage.SelectChapterWIthId(db, id)
+
+	if err != nil {
+		return err
+	}
+
+	nodes, err := storage.SelectNodesByChapterId(db, id)
+
+	if err != nil {
+		return err
+	}
+
+	requests, err := storage.SelectRequestsByChapterId(db, id)
+
+	if err != nil {
+		return err
+	}
+
+	variables, err := storage.SelectVariablesForChapter(db, id)
+
+	if err != nil {
+		return err
+	}
+
+	snapshot := models.ChapterSnapshot{
+		Chapter:  chapter,
+		Nodes:    nodes,
+		Requests: requests,
+	}
+
+	for _, variable := range variables {
+		if variable.ChapterId == id {
+			snapshot.Variables = append(snapshot.Variables, variable)
+		}
+	}
+
+	err = collectChapterData(&snapshot, db)
+
+	if err != nil {
+		return err
+	}
+
+	admins, err := relatedAdmins(chapter, requests, db)
+
+	if err != nil {
+		return err
+	}
+
+	var changedAdmins []models.Admin
+
+	for _, admin := range admins {
+		refs, updated := detachAdmin(admin, id, requests)
+
+		if refs.CreatedChapter || len(refs.RequestSent) > 0 || len(refs.RequestsReceived) > 0 {
+			snapshot.Admins = append(snapshot.Admins, refs)
+			changedAdmins = append(changedAdmins, updated)
+		}
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := storage.SaveDeletedChapter(tx, models.DeletedChapter{
+			Id:        id,
+			Snapshot:  snapshot,
+			DeletedAt: time.Now(),
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if err := storage.DeleteNodesByChapterId(tx, id); err != nil {
+			return err
+		}
+
+		if err := storage.DeleteRequestsByChapterId(tx, id); err != nil {
+			return err
+		}
+
+		if len(snapshot.Variables) > 0 {
+			if err := storage.DeleteVariablesByChapterId(tx, id); err != nil {
+				return err
+			}
+		}
+
+		if err := deleteChapterData(tx, snapshot); err != nil {
+			return err
+		}
+
+		for _, admin := range changedAdmins {
+			if _, err := storage.UpdateAdmin(tx, admin.Id, admin); err != nil {
+				return err
+			}
+		}
+
+		_, err = storage.DeleteChapter(tx, id)
+
+		return err
+	})
+}
+
+// RestoreChapter восстанавливает удаленную главу, если срок хранения не истек
+func RestoreChapter(id int64, db *gorm.DB) error {
+	deleted, err := storage.SelectDeletedChapter(db, id)
+
+	if err != nil {
+		return err
+	}
+
+	if time.Since(deleted.DeletedAt) > RetentionPeriod {
+		return ErrRestoreExpired
+	}
+
+	snapshot := deleted.Snapshot
+
+	var changedAdmins []models.Admin
+
+	for _, refs := range snapshot.Admins {
+		admin, err := storage.SelectAdminWithId(db, refs.AdminId)
+
+		// Админ мог быть удален, восстанавливаем остальное
+		if err != nil {
+			continue
+		}
+
+		changedAdmins = append(changedAdmins, attachAdmin(admin, id, refs))
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := storage.RestoreChapter(tx, snapshot.Chapter); err != nil {
+			return err
+		}
+
+		for _, node := range snapshot.Nodes {
+			if err := storage.RestoreNode(tx, node); err != nil {
+				return err
+			}
+		}
+
+		if err := storage.RestoreRequests(tx, snapshot.Requests); err != nil {
+			return err
+		}
+
+		if err := storage.RestoreVariables(tx, snapshot.Variables); err != nil {
+			return err
+		}
+
+		if err := restoreChapterData(tx, snapshot); err != nil {
+			return err
+		}
+
+		for _, admin := range changedAdmins {
+			if _, err := storage.UpdateAdmin(tx, admin.Id, admin); err != nil {
+				return err
+			}
+		}
+
+		return storage.DeleteDeletedChapter(tx, id)
+	})
+}
+
+// collectChapterData добавляет в снимок версии, данные игроков, ревизии и переводы главы
+func collectChapterData(snapshot *models.ChapterSnapshot, db *gorm.DB) error {
+	id := snapshot.Chapter.Id
+	var err error
+
+	if snapshot.Versions, err = storage.SelectChapterVersions(db, id); err != nil {
+		return err
+	}
+
+	if snapshot.PlayerVersions, err = storage.SelectPlayerVersionsByChapterId(db, id); err != nil {
+		return err
+	}
+
+	if snapshot.PlayerVariables, err = storage.SelectPlayerVariablesByChapterId(db, id); err != nil {
+		return err
+	}
+
+	if snapshot.Revisions, err = storage.SelectRevisionsByChapterId(db, id); err != nil {
+		return err
+	}
+
+	snapshot.Translations, err = storage.SelectTranslationsByChapterId(db, id)
+
+	return err
+}
+
+// deleteChapterData удаляет данные из collectChapterData и блокировки узлов главы.
+// Версии игроков ссылаются на версии главы, поэтому удаляются первыми
+func deleteChapterData(tx *gorm.DB, snapshot models.ChapterSnapshot) error {
+	id := snapshot.Chapter.Id
+	nodeIds := make([]int64, 0, len(snapshot.Nodes))
+
+	for _, node := range snapshot.Nodes {
+		nodeIds = append(nodeIds, node.Id)
+	}
+
+	if err := storage.DeleteNodeLocks(tx, nodeIds); err != nil {
+		return err
+	}
+
+	if err := storage.DeletePlayerVersionsByChapterId(tx, id); err != nil {
+		return err
+	}
+
+	if err := storage.DeleteChapterVersionsByChapterId(tx, id); err != nil {
+		return err
+	}
+
+	if err := storage.DeletePlayerVariablesByChapterId(tx, id); err != nil {
+		return err
+	}
+
+	if err := storage.DeleteRevisionsByChapterId(tx, id); err != nil {
+		return err
+	}
+
+	return storage.DeleteTranslationsByChapterId(tx, id)
+}
+
+// restoreChapterData возвращает данные из collectChapterData с прежними id
+func restoreChapterData(tx *gorm.DB, snapshot models.ChapterSnapshot) error {
+	if err := storage.RestoreChapterVersions(tx, snapshot.Versions); err != nil {
+		return err
+	}
+
+	if err := storage.RestorePlayerVersions(tx, snapshot.PlayerVersions); err != nil {
+		return err
+	}
+
+	if err := storage.RestorePlayerVariables(tx, snapshot.PlayerVariables); err != nil {
+		return err
+	}
+
+	if err := storage.RestoreRevisions(tx, snapshot.Revisions); err != nil {
+		return err
+	}
+
+	return storage.RestoreTranslations(tx, snapshot.Translations)
+}
+
+func GetDeletedChapters(db *gorm.DB) ([]models.DeletedChapter, error) {
+	return storage.SelectDeletedChapters(db)
+}
+
+// PurgeDeletedChapters окончательно удаляет главы с истекшим сроком восстановления
+func PurgeDeletedChapters(now time.Time, db *gorm.DB) (int64, error) {
+	return storage.PurgeDeletedChapters(db, now.Add(-RetentionPeriod))
+}
+
+// StartPurgeWorker раз в interval удаляет главы с истекшим сроком восстановления,
+// пока не отменен ctx
+func StartPurgeWorker(ctx context.Context, interval time.Duration, db *gorm.DB) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			purged, err := PurgeDeletedChapters(now, db)
+
+			if err != nil {
+				log.Println("ошибка очистки удаленных глав:", err)
+				continue
+			}
+
+			if purged > 0 {
+				log.Println("окончательно удалено глав:", purged)
+			}
+		}
+	}
+}
+
+// relatedAdmins возвращает автора главы, авторов запросов и сверхадминов,
+// получивших запросы по главе
+func relatedAdmins(chapter models.Chapter, requests []models.Request, db *gorm.DB) ([]models.Admin, error) {
+	ids := []int64{chapter.Author}
+
+	for _, request := range requests {
+		ids = append(ids, request.RequestingAdmin)
+	}
+
+	seen := map[int64]bool{}
+	var admins []models.Admin
+
+	for _, adminId := range ids {
+		if adminId == 0 || seen[adminId] {
+			continue
+		}
+
+		seen[adminId] = true
+
+		admin, err := storage.SelectAdminWithId(db, adminId)
+
+		// Ссылки на несуществующих админов чистить не нужно
+		if err != nil {
+			continue
+		}
+
+		admins = append(admins, admin)
+	}
+
+	if len(requests) > 0 {
+		superAdmins, err := storage.SelectAllSupeAdmins(db)
+
+		if err == nil {
+			for _, admin := range superAdmins {
+				if !seen[admin.Id] {
+					seen[admin.Id] = true
+					admins = append(admins, admin)
+				}
+			}
+		}
+	}
+
+	return admins, nil
+}
+
+// detachAdmin убирает из админа ссылки на главу и ее запросы
+func detachAdmin(admin models.Admin, chapterId int64, requests []models.Request) (models.AdminReferences, models.Admin) {
+	requestIds := make(map[int64]bool, len(requests))
+
+	for _, request := range requests {
+		requestIds[request.Id] = true
+	}
+
+	refs := models.AdminReferences{AdminId: admin.Id}
+
+	createdChapters := []int64{}
+
+	for _, id := range admin.CreatedChapters {
+		if id == chapterId {
+			refs.CreatedChapter = true
+			continue
+		}
+
+		createdChapters = append(createdChapters, id)
+	}
+
+	admin.CreatedChapters = createdChapters
+	admin.RequestSent, refs.RequestSent = splitIds(admin.RequestSent, requestIds)
+	admin.RequestsReceived, refs.RequestsReceived = splitIds(admin.RequestsReceived, requestIds)
+
+	return refs, admin
+}
+
+// attachAdmin возвращает админу ссылки, убранные при удалении главы
+func attachAdmin(admin models.Admin, chapterId int64, refs models.AdminReferences) models.Admin {
+	if refs.CreatedChapter && !containsId(admin.CreatedChapters, chapterId) {
+		admin.CreatedChapters = append(admin.CreatedChapters, chapterId)
+	}
+
+	for _, id := range refs.RequestSent {
+		if !containsId(admin.RequestSent, id) {
+			admin.RequestSent = append(admin.RequestSent, id)
+		}
+	}
+
+	for _, id := range refs.RequestsReceived {
+		if !containsId(admin.RequestsReceived, id) {
+			admin.RequestsReceived = append(admin.RequestsReceived, id)
+		}
+	}
+
+	return admin
+}
+
+// splitIds делит список на оставшиеся id и id из removed
+func splitIds(ids []int64, removed map[int64]bool) ([]int64, []int64) {
+	kept := []int64{}
+	var dropped []int64
+
+	for _, id := range ids {
+		if removed[id] {
+			dropped = append(dropped, id)
+		} else {
+			kept = append(kept, id)
+		}
+	}
+
+	return kept, dropped
+}
+
+func containsId(ids []int64, id int64) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,138 @@
+package chapter
+
+import (
+	"regexp"
+	"testing"
+	"time"
+	"vn/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestDetachAndAttachAdmin(t *testing.T) {
+	admin := models.Admin{
+		Id:               1,
+		CreatedChapters:  []int64{5, 6},
+		RequestSent:      []int64{10, 11},
+		RequestsReceived: []int64{12},
+	}
+
+	requests := []models.Request{{Id: 10, RequestedChapterId: 5}, {Id: 12, RequestedChapterId: 5}}
+
+	refs, detached := detachAdmin(admin, 5, requests)
+
+	assert.Equal(t, models.AdminReferences{
+		AdminId:          1,
+		CreatedChapter:   true,
+		RequestSent:      []int64{10},
+		RequestsReceived: []int64{12},
+	}, refs)
+	assert.Equal(t, []int64{6}, detached.CreatedChapters)
+	assert.Equal(t, []int64{11}, detached.RequestSent)
+	assert.Equal(t, []int64{}, detached.RequestsReceived)
+
+	restored := attachAdmin(detached, 5, refs)
+
+	assert.ElementsMatch(t, admin.CreatedChapters, restored.CreatedChapters)
+	assert.ElementsMatch(t, admin.RequestSent, restored.RequestSent)
+	assert.ElementsMatch(t, admin.RequestsReceived, restored.RequestsReceived)
+
+	// Повторное восстановление не дублирует ссылки
+	restored = attachAdmin(restored, 5, refs)
+	assert.Len(t, restored.CreatedChapters, 2)
+}
+
+func TestRestoreChapterExpired(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании моковой БД: %v", err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("ошибка при создании подключения к БД: %v", err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "deleted_chapters" WHERE id = $1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "snapshot", "deleted_at"}).
+			AddRow(5, `{"Chapter":{"Id":5}}`, time.Now().Add(-RetentionPeriod-time.Hour)))
+
+	err = RestoreChapter(5, gormDB)
+
+	assert.ErrorIs(t, err, ErrRestoreExpired)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurgeDeletedChapters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании моковой БД: %v", err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("ошибка при создании подключения к БД: %v", err)
+	}
+
+	now := time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "deleted_chapters" WHERE deleted_at < $1`)).
+		WithArgs(now.Add(-RetentionPeriod)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	purged, err := PurgeDeletedChapters(now, gormDB)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreChapterWithHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании моковой БД: %v", err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("ошибка при создании подключения к БД: %v", err)
+	}
+
+	snapshot := `{"Chapter":{"Id":5,"Version":4},"Nodes":[{"Id":10,"ChapterId":5,"Version":2}],
+		"Versions":[{"Id":7,"ChapterId":5,"Version":1}],
+		"PlayerVersions":[{"Id":8,"PlayerId":3,"ChapterId":5,"VersionId":7}],
+		"Revisions":[{"Id":9,"EntityType":"node","EntityId":10,"ChapterId":5,"Author":1,"Data":{}}]}`
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "deleted_chapters" WHERE id = $1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "snapshot", "deleted_at"}).AddRow(5, snapshot, time.Now()))
+
+	mock.ExpectBegin()
+	// Глава и узел восстанавливаются с прежними версиями, иначе клиенты получат ложные конфликты
+	anyArg := sqlmock.AnyArg()
+	mock.ExpectQuery(`INSERT INTO "chapters"`).
+		WithArgs(anyArg, anyArg, 5, anyArg, anyArg, anyArg, anyArg, anyArg, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectQuery(`INSERT INTO "nodes"`).
+		WithArgs(anyArg, anyArg, 5, anyArg, anyArg, anyArg, 10, anyArg, anyArg, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	// Сначала версии главы, на них ссылаются версии игроков
+	mock.ExpectQuery(`INSERT INTO "chapter_versions"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(`INSERT INTO "player_chapter_versions"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+	mock.ExpectQuery(`INSERT INTO "revisions"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "deleted_chapters" WHERE id = $1`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = RestoreChapter(5, gormDB)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,22 @@
+package chapter
+
+import (
+	"gorm.io/gorm"
+)
+
+// DeleteEvent удаляет событие узла и возвращает новую версию узла
+func DeleteEvent(nodeId int64, eventId int64, version int, adminId int64, db *gorm.DB) (int, error) {
+	node, err := GetNode(nodeId, db)
+
+	if err != nil {
+		return 0, err
+	}
+
+	events, _, err := removeEvent(node.Events, eventId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return saveEvents(node, events, version, adminId, db)
+}
@@ -0,0 +1,100 @@
+package chapter
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"vn/internal/services/live"
+	"vn/internal/storage"
+)
+
+// ErrNodeHasIncomingChoices возвращается, если в удаляемый узел ведут варианты выбора других узлов
+var ErrNodeHasIncomingChoices = errors.New("node is a target of choices")
+
+// DeleteNode удаляет узел, если админ держит его блокировку. Узел, в который ведут
+// варианты выбора, не удаляется, чтобы переходы не остались висячими
+func DeleteNode(id int64, adminId int64, db *gorm.DB) error {
+	node, err := GetNode(id, db)
+
+	if err != nil {
+		return err
+	}
+
+	err = checkNodeLock(id, adminId, db)
+
+	if err != nil {
+		return err
+	}
+
+	chapter, err := storage.SelectChapterWIthId(db, node.ChapterId)
+
+	if err != nil {
+		return err
+	}
+
+	// Начальный узел удалять нельзя, иначе глава останется без входа
+	if chapter.StartNode == id {
+		return errors.New("start node can not be deleted")
+	}
+
+	// Узел, его блокировка и список узлов главы меняются вместе: при конфликте версии главы
+	// узел остается на месте
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := checkIncomingChoices(id, chapter.Id, tx); err != nil {
+			return err
+		}
+
+		if _, err := storage.DeleteNode(tx, id); err != nil {
+			return err
+		}
+
+		if _, err := storage.DeleteNodeLock(tx, id, adminId); err != nil {
+			return err
+		}
+
+		nodes := make([]int64, 0, len(chapter.Nodes))
+
+		for _, nodeId := range chapter.Nodes {
+			if nodeId != id {
+				nodes = append(nodes, nodeId)
+			}
+		}
+
+		chapter.Nodes = nodes
+
+		_, err := storage.UpdateChapter(tx, chapter.Id, chapter)
+
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	live.Publish(live.Event{Type: live.NodeDeleted, ChapterId: chapter.Id, NodeId: id})
+
+	return nil
+}
+
+// checkIncomingChoices проверяет, что в узел не ведет ни один вариант выбора других узлов главы
+func checkIncomingChoices(id int64, chapterId int64, db *gorm.DB) error {
+	nodes, err := storage.SelectNodesByChapterId(db, chapterId)
+
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		if node.Id == id {
+			continue
+		}
+
+		for _, choice := range node.Branching.Choices {
+			if choice.NextNode == id {
+				return fmt.Errorf("%w: choice %d of node %d", ErrNodeHasIncomingChoices, choice.Id, node.Id)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,12 @@
+package chapter
+
+import (
+	"gorm.io/gorm"
+	"vn/internal/storage"
+)
+
+func DeleteVariable(id int64, db *gorm.DB) error {
+	_, err := storage.DeleteVariable(db, id)
+
+	return err
+}
@@ -0,0 +1,29 @@
+package chapter
+
+import (
+	"gorm.io/gorm"
+)
+
+// DuplicateEvent копирует событие и вставляет копию сразу после него.
+// Возвращает id копии и новую версию узла
+func DuplicateEvent(nodeId int64, eventId int64, version int, adminId int64, db *gorm.DB) (int64, int, error) {
+	node, err := GetNode(nodeId, db)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	events, newId, err := duplicateEvent(node.Events, eventId)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	newVersion, err := saveEvents(node, events, version, adminId, db)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return newId, newVersion, nil
+}
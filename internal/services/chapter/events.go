@@ -0,0 +1,198 @@
+package chapter
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"vn/internal/models"
+	"vn/internal/storage"
+)
+
+var ErrEventNotFound = errors.New("event not found")
+
+// assignEventIds назначает id событиям, у которых его еще нет, и проверяет,
+// что id внутри узла не повторяются
+func assignEventIds(events models.Events) (models.Events, error) {
+	used := make(map[int64]bool, len(events))
+
+	for _, event := range events {
+		if event.Id == 0 {
+			continue
+		}
+
+		if used[event.Id] {
+			return nil, errors.New("duplicate event id")
+		}
+
+		used[event.Id] = true
+	}
+
+	res := make(models.Events, len(events))
+
+	for i, event := range events {
+		if event.Id == 0 {
+			event.Id = newEventId(used)
+		}
+
+		res[i] = event
+	}
+
+	return res, nil
+}
+
+// assignChoiceIds назначает id вариантам выбора так же, как assignEventIds событиям.
+// По id вариант находит свой перевод, даже если варианты переставили
+func assignChoiceIds(choices []models.Choice) ([]models.Choice, error) {
+	used := make(map[int64]bool, len(choices))
+
+	for _, choice := range choices {
+		if choice.Id == 0 {
+			continue
+		}
+
+		if used[choice.Id] {
+			return nil, errors.New("duplicate choice id")
+		}
+
+		used[choice.Id] = true
+	}
+
+	res := make([]models.Choice, len(choices))
+
+	for i, choice := range choices {
+		if choice.Id == 0 {
+			choice.Id = newEventId(used)
+		}
+
+		res[i] = choice
+	}
+
+	return res, nil
+}
+
+func newEventId(used map[int64]bool) int64 {
+	id := generateUniqueId()
+
+	for used[id] {
+		id = generateUniqueId()
+	}
+
+	used[id] = true
+
+	return id
+}
+
+// insertEvent вставляет событие на позицию. Позиция вне списка означает вставку в конец
+func insertEvent(events models.Events, position int, event models.Event) models.Events {
+	if position < 0 || position > len(events) {
+		position = len(events)
+	}
+
+	res := make(models.Events, 0, len(events)+1)
+	res = append(res, events[:position]...)
+	res = append(res, event)
+	res = append(res, events[position:]...)
+
+	return res
+}
+
+// removeEvent удаляет событие из списка и возвращает его
+func removeEvent(events models.Events, eventId int64) (models.Events, models.Event, error) {
+	index := events.Find(eventId)
+
+	if index < 0 {
+		return nil, models.Event{}, ErrEventNotFound
+	}
+
+	removed := events[index]
+
+	res := make(models.Events, 0, len(events)-1)
+	res = append(res, events[:index]...)
+	res = append(res, events[index+1:]...)
+
+	return res, removed, nil
+}
+
+// moveEvent переставляет событие на позицию, считая позицию в списке после перемещения
+func moveEvent(events models.Events, eventId int64, position int) (models.Events, error) {
+	rest, event, err := removeEvent(events, eventId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return insertEvent(rest, position, event), nil
+}
+
+// duplicateEvent вставляет копию события сразу после оригинала
+func duplicateEvent(events models.Events, eventId int64) (models.Events, int64, error) {
+	index := events.Find(eventId)
+
+	if index < 0 {
+		return nil, 0, ErrEventNotFound
+	}
+
+	used := make(map[int64]bool, len(events))
+
+	for _, event := range events {
+		used[event.Id] = true
+	}
+
+	event := copyEvent(events[index])
+	event.Id = newEventId(used)
+
+	return insertEvent(events, index+1, event), event.Id, nil
+}
+
+func copyEvent(event models.Event) models.Event {
+	if event.CharactersInEvent != nil {
+		charactersInEvent := make(map[int64]map[int64]int64, len(event.CharactersInEvent))
+
+		for characterId, emotions := range event.CharactersInEvent {
+			positions := make(map[int64]int64, len(emotions))
+
+			for emotion, position := range emotions {
+				positions[emotion] = position
+			}
+
+			charactersInEvent[characterId] = positions
+		}
+
+		event.CharactersInEvent = charactersInEvent
+	}
+
+	if event.Effects != nil {
+		event.Effects = append([]models.Effect(nil), event.Effects...)
+	}
+
+	return event
+}
+
+// saveEvents сохраняет новый список событий узла, если админ держит блокировку узла
+// и видел его текущую версию. Возвращает новую версию узла
+func saveEvents(node *models.Node, events models.Events, version int, adminId int64, db *gorm.DB) (int, error) {
+	err := checkNodeLock(node.Id, adminId, db)
+
+	if err != nil {
+		return 0, err
+	}
+
+	err = checkNodeVersion(*node, version)
+
+	if err != nil {
+		return 0, err
+	}
+
+	newNode := *node
+	newNode.Events = events
+
+	_, err = storage.UpdateNode(db, node.Id, newNode, adminId)
+
+	if err != nil {
+		return 0, nodeConflict(node.Id, err, db)
+	}
+
+	newNode.Version++
+	publishNodeUpdated(newNode)
+
+	return newNode.Version, nil
+}
@@ -0,0 +1,103 @@
+package chapter
+
+import (
+	"encoding/json"
+	"testing"
+	"vn/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func eventIds(events models.Events) []int64 {
+	ids := make([]int64, 0, len(events))
+
+	for _, event := range events {
+		ids = append(ids, event.Id)
+	}
+
+	return ids
+}
+
+func TestEventOperations(t *testing.T) {
+	events := models.Events{{Id: 1}, {Id: 2}, {Id: 3}}
+
+	inserted := insertEvent(events, 1, models.Event{Id: 4})
+	assert.Equal(t, []int64{1, 4, 2, 3}, eventIds(inserted))
+	assert.Equal(t, []int64{1, 2, 3}, eventIds(events))
+
+	appended := insertEvent(events, -1, models.Event{Id: 5})
+	assert.Equal(t, []int64{1, 2, 3, 5}, eventIds(appended))
+
+	moved, err := moveEvent(events, 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{2, 3, 1}, eventIds(moved))
+
+	moved, err = moveEvent(events, 3, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 1, 2}, eventIds(moved))
+
+	_, err = moveEvent(events, 9, 0)
+	assert.ErrorIs(t, err, ErrEventNotFound)
+
+	removed, _, err := removeEvent(events, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 3}, eventIds(removed))
+}
+
+func TestDuplicateEvent(t *testing.T) {
+	events := models.Events{
+		{Id: 1, Text: "Привет", CharactersInEvent: map[int64]map[int64]int64{42: {1: 30}}},
+		{Id: 2},
+	}
+
+	duplicated, newId, err := duplicateEvent(events, 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, duplicated, 3)
+	assert.Equal(t, newId, duplicated[1].Id)
+	assert.NotEqual(t, int64(1), newId)
+	assert.Equal(t, "Привет", duplicated[1].Text)
+
+	// Копия не должна делить вложенные карты с оригиналом
+	duplicated[1].CharactersInEvent[42][1] = 50
+	assert.Equal(t, int64(30), events[0].CharactersInEvent[42][1])
+}
+
+func TestAssignEventIds(t *testing.T) {
+	events, err := assignEventIds(models.Events{{Id: 7}, {}, {}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), events[0].Id)
+	assert.NotZero(t, events[1].Id)
+	assert.NotEqual(t, events[1].Id, events[2].Id)
+
+	_, err = assignEventIds(models.Events{{Id: 7}, {Id: 7}})
+	assert.Error(t, err)
+}
+
+func TestAssignChoiceIds(t *testing.T) {
+	choices, err := assignChoiceIds([]models.Choice{{Id: 7, Text: "Налево"}, {Text: "Направо"}, {Text: "Назад"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), choices[0].Id)
+	assert.NotZero(t, choices[1].Id)
+	assert.NotEqual(t, choices[1].Id, choices[2].Id)
+
+	_, err = assignChoiceIds([]models.Choice{{Id: 7}, {Id: 7}})
+	assert.Error(t, err)
+}
+
+func TestLegacyEventsUnmarshal(t *testing.T) {
+	var events models.Events
+
+	err := json.Unmarshal([]byte(`{"10": {"text": "третье"}, "2": {"text": "второе"}, "0": {"id": 99, "text": "первое"}}`), &events)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{99, 3, 11}, eventIds(events))
+	assert.Equal(t, "второе", events[1].Text)
+
+	err = json.Unmarshal([]byte(`[{"id": 5, "text": "a"}, {"id": 6, "text": "b"}]`), &events)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{5, 6}, eventIds(events))
+}
@@ -0,0 +1,245 @@
+package chapter
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"sort"
+	"strings"
+	"vn/internal/models"
+	"vn/internal/storage"
+)
+
+const (
+	GraphDOT     = "dot"
+	GraphMermaid = "mermaid"
+)
+
+// graphLabelLength - сколько символов первой реплики узла попадает в подпись
+const graphLabelLength = 40
+
+var ErrUnknownGraphFormat = errors.New("unknown graph format")
+
+// Цвета концовок, назначаются по алфавиту EndResult и повторяются по кругу
+var graphEndColors = []string{"#8dd3c7", "#fb8072", "#80b1d3", "#fdb462", "#b3de69", "#fccde5", "#bebada", "#ffffb3"}
+
+// ExportGraph рисует граф главы в формате dot (Graphviz) или mermaid
+func ExportGraph(chapterId int64, format string, db *gorm.DB) (string, error) {
+	if format != GraphDOT && format != GraphMermaid {
+		return "", ErrUnknownGraphFormat
+	}
+
+	chapter, err := storage.SelectChapterWIthId(db, chapterId)
+
+	if err != nil {
+		return "", err
+	}
+
+	nodes, err := loadChapterNodes(chapter, db)
+
+	if err != nil {
+		return "", err
+	}
+
+	if format == GraphMermaid {
+		return RenderMermaid(chapter, nodes), nil
+	}
+
+	return RenderDOT(chapter, nodes), nil
+}
+
+// graphNodes возвращает узлы в порядке списка главы
+func graphNodes(chapter models.Chapter, nodes map[int64]models.Node) []models.Node {
+	res := make([]models.Node, 0, len(nodes))
+
+	for _, nodeId := range chapter.Nodes {
+		if node, ok := nodes[nodeId]; ok {
+			res = append(res, node)
+		}
+	}
+
+	return res
+}
+
+// graphEndClasses назначает каждому EndResult номер цвета
+func graphEndClasses(nodes []models.Node) map[string]int {
+	var results []string
+	seen := map[string]bool{}
+
+	for _, node := range nodes {
+		if node.End.Flag && !seen[node.End.EndResult] {
+			seen[node.End.EndResult] = true
+			results = append(results, node.End.EndResult)
+		}
+	}
+
+	sort.Strings(results)
+
+	classes := make(map[string]int, len(results))
+
+	for i, result := range results {
+		classes[result] = i
+	}
+
+	return classes
+}
+
+// graphNodeLabel - slug, первая строка текста и результат концовки
+func graphNodeLabel(node models.Node) []string {
+	lines := []string{node.Slug}
+
+	for _, event := range node.Events {
+		text := strings.TrimSpace(event.Text)
+
+		if text == "" {
+			continue
+		}
+
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			text = strings.TrimSpace(text[:i])
+		}
+
+		if runes := []rune(text); len(runes) > graphLabelLength {
+			text = string(runes[:graphLabelLength]) + "…"
+		}
+
+		lines = append(lines, text)
+		break
+	}
+
+	if node.End.Flag && node.End.EndResult != "" {
+		lines = append(lines, "["+node.End.EndResult+"]")
+	}
+
+	return lines
+}
+
+// graphEdgeLabel - текст варианта, а если его нет - условие перехода
+func graphEdgeLabel(choice models.Choice) string {
+	if choice.Text != "" {
+		return choice.Text
+	}
+
+	return choice.Condition
+}
+
+func graphNodeId(id int64) string {
+	return fmt.Sprintf("n%d", id)
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// RenderDOT рисует граф главы для Graphviz. Начальный узел выделен двойной рамкой,
+// автоматические переходы пунктиром, концовки закрашены по EndResult
+func RenderDOT(chapter models.Chapter, nodes map[int64]models.Node) string {
+	ordered := graphNodes(chapter, nodes)
+	classes := graphEndClasses(ordered)
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "digraph \"chapter_%d\" {\n", chapter.Id)
+	fmt.Fprintf(&b, "    label=\"%s\";\n", dotEscaper.Replace(chapter.Name))
+	b.WriteString("    node [shape=box, style=rounded];\n")
+
+	for _, node := range ordered {
+		lines := graphNodeLabel(node)
+
+		for i := range lines {
+			lines[i] = dotEscaper.Replace(lines[i])
+		}
+
+		attrs := []string{fmt.Sprintf(`label="%s"`, strings.Join(lines, `\n`))}
+
+		if node.Id == chapter.StartNode {
+			attrs = append(attrs, "peripheries=2")
+		}
+
+		if node.End.Flag {
+			color := graphEndColors[classes[node.End.EndResult]%len(graphEndColors)]
+			attrs = append(attrs, `style="rounded,filled"`, fmt.Sprintf(`fillcolor="%s"`, color))
+		}
+
+		fmt.Fprintf(&b, "    %s [%s];\n", graphNodeId(node.Id), strings.Join(attrs, ", "))
+	}
+
+	for _, node := range ordered {
+		for _, choice := range node.Branching.Choices {
+			var attrs []string
+
+			if label := graphEdgeLabel(choice); label != "" {
+				attrs = append(attrs, fmt.Sprintf(`label="%s"`, dotEscaper.Replace(label)))
+			}
+
+			if !node.Branching.Flag {
+				attrs = append(attrs, "style=dashed")
+			}
+
+			if len(attrs) > 0 {
+				fmt.Fprintf(&b, "    %s -> %s [%s];\n", graphNodeId(node.Id), graphNodeId(choice.NextNode), strings.Join(attrs, ", "))
+			} else {
+				fmt.Fprintf(&b, "    %s -> %s;\n", graphNodeId(node.Id), graphNodeId(choice.NextNode))
+			}
+		}
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+var mermaidEscaper = strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;", "&", "#amp;", "\n", " ")
+
+// RenderMermaid рисует граф главы как flowchart Mermaid. Оформление то же, что в RenderDOT
+func RenderMermaid(chapter models.Chapter, nodes map[int64]models.Node) string {
+	ordered := graphNodes(chapter, nodes)
+	classes := graphEndClasses(ordered)
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%%%% %s\n", strings.ReplaceAll(chapter.Name, "\n", " "))
+	b.WriteString("flowchart TD\n")
+
+	for _, node := range ordered {
+		lines := graphNodeLabel(node)
+
+		for i := range lines {
+			lines[i] = mermaidEscaper.Replace(lines[i])
+		}
+
+		label := strings.Join(lines, "<br/>")
+
+		if node.Id == chapter.StartNode {
+			fmt.Fprintf(&b, "    %s([\"%s\"])\n", graphNodeId(node.Id), label)
+		} else {
+			fmt.Fprintf(&b, "    %s[\"%s\"]\n", graphNodeId(node.Id), label)
+		}
+	}
+
+	for _, node := range ordered {
+		arrow := "-->"
+
+		if !node.Branching.Flag {
+			arrow = "-.->"
+		}
+
+		for _, choice := range node.Branching.Choices {
+			if label := graphEdgeLabel(choice); label != "" {
+				fmt.Fprintf(&b, "    %s %s|\"%s\"| %s\n", graphNodeId(node.Id), arrow, mermaidEscaper.Replace(label), graphNodeId(choice.NextNode))
+			} else {
+				fmt.Fprintf(&b, "    %s %s %s\n", graphNodeId(node.Id), arrow, graphNodeId(choice.NextNode))
+			}
+		}
+	}
+
+	for class := 0; class < len(classes); class++ {
+		fmt.Fprintf(&b, "    classDef end%d fill:%s\n", class, graphEndColors[class%len(graphEndColors)])
+	}
+
+	for _, node := range ordered {
+		if node.End.Flag {
+			fmt.Fprintf(&b, "    class %s end%d\n", graphNodeId(node.Id), classes[node.End.EndResult])
+		}
+	}
+
+	return b.String()
+}
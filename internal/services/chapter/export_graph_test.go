@@ -0,0 +1,70 @@
+package chapter
+
+import (
+	"testing"
+	"vn/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func graphChapter() (models.Chapter, map[int64]models.Node) {
+	chapter := models.Chapter{Id: 1, Name: "Пролог", StartNode: 10, Nodes: []int64{10, 11, 12}}
+
+	nodes := map[int64]models.Node{
+		10: {
+			Id:     10,
+			Slug:   "start",
+			Events: models.Events{{Id: 1, Type: models.EventCharacterEnter}, {Id: 2, Type: models.EventSpeech, Text: "Кто \"там\"?\nВторая строка"}},
+			Branching: models.Branching{Flag: true, Choices: []models.Choice{
+				{Text: "Открыть", NextNode: 11},
+				{NextNode: 12, Condition: "trust >= 2"},
+			}},
+		},
+		11: {Id: 11, Slug: "good", End: models.EndInfo{Flag: true, EndResult: "good"}},
+		12: {Id: 12, Slug: "bad", End: models.EndInfo{Flag: true, EndResult: "bad"}},
+	}
+
+	return chapter, nodes
+}
+
+func TestRenderDOT(t *testing.T) {
+	chapter, nodes := graphChapter()
+
+	expected := `digraph "chapter_1" {
+    label="Пролог";
+    node [shape=box, style=rounded];
+    n10 [label="start\nКто \"там\"?", peripheries=2];
+    n11 [label="good\n[good]", style="rounded,filled", fillcolor="#fb8072"];
+    n12 [label="bad\n[bad]", style="rounded,filled", fillcolor="#8dd3c7"];
+    n10 -> n11 [label="Открыть"];
+    n10 -> n12 [label="trust >= 2"];
+}
+`
+
+	assert.Equal(t, expected, RenderDOT(chapter, nodes))
+}
+
+func TestRenderMermaid(t *testing.T) {
+	chapter, nodes := graphChapter()
+
+	expected := `%% Пролог
+flowchart TD
+    n10(["start<br/>Кто #quot;там#quot;?"])
+    n11["good<br/>[good]"]
+    n12["bad<br/>[bad]"]
+    n10 -->|"Открыть"| n11
+    n10 -->|"trust #gt;= 2"| n12
+    classDef end0 fill:#8dd3c7
+    classDef end1 fill:#fb8072
+    class n11 end1
+    class n12 end0
+`
+
+	assert.Equal(t, expected, RenderMermaid(chapter, nodes))
+}
+
+func TestExportGraphUnknownFormat(t *testing.T) {
+	_, err := ExportGraph(1, "svg", nil)
+
+	assert.ErrorIs(t, err, ErrUnknownGraphFormat)
+}
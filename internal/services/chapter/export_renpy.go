@@ -0,0 +1,503 @@
+package chapter
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"vn/internal/models"
+	"vn/internal/storage"
+)
+
+const renpyIndent = "    "
+
+// ExportRenpy собирает главу в скрипт Ren'Py (.rpy). Медиафайлы не выгружаются,
+// в скрипте на них ссылаются имена media_<id>
+func ExportRenpy(chapterId int64, db *gorm.DB) (string, error) {
+	chapter, err := storage.SelectChapterWIthId(db, chapterId)
+
+	if err != nil {
+		return "", err
+	}
+
+	nodes, err := loadChapterNodes(chapter, db)
+
+	if err != nil {
+		return "", err
+	}
+
+	characters, err := storage.SelectCharactersWithIds(db, chapter.Characters)
+
+	if err != nil {
+		return "", err
+	}
+
+	variables, err := storage.SelectVariablesForChapter(db, chapterId)
+
+	if err != nil {
+		return "", err
+	}
+
+	return RenderRenpy(chapter, nodes, characters, variables), nil
+}
+
+// RenderRenpy переводит главу в скрипт Ren'Py: узлы становятся метками, события -
+// репликами и командами show/hide/scene/play, варианты выбора - меню, концовки - return
+func RenderRenpy(chapter models.Chapter, nodes map[int64]models.Node, characters map[int64]models.Character, variables []models.Variable) string {
+	r := renpyWriter{
+		labels:     renpyLabels(chapter, nodes),
+		characters: renpyCharacterNames(chapter, characters),
+	}
+
+	r.line(0, "# Глава %q, экспорт в Ren'Py", chapter.Name)
+	r.blank()
+
+	for _, characterId := range chapter.Characters {
+		character, ok := characters[characterId]
+
+		if !ok {
+			continue
+		}
+
+		if character.Color != "" {
+			r.line(0, "define %s = Character(%s, color=%s)", r.characters[characterId], renpyString(character.Name), renpyString(character.Color))
+		} else {
+			r.line(0, "define %s = Character(%s)", r.characters[characterId], renpyString(character.Name))
+		}
+	}
+
+	sort.Slice(variables, func(a, b int) bool { return variables[a].Name < variables[b].Name })
+
+	for _, variable := range variables {
+		r.line(0, "default %s = %s", variable.Name, renpyVariableValue(variable.Type, variable.Default))
+	}
+
+	r.blank()
+	r.line(0, "label start:")
+
+	if label, ok := r.labels[chapter.StartNode]; ok {
+		r.line(1, "jump %s", label)
+	} else {
+		r.line(1, "return")
+	}
+
+	for _, nodeId := range chapter.Nodes {
+		node, ok := nodes[nodeId]
+
+		if !ok {
+			continue
+		}
+
+		r.blank()
+		r.node(node)
+	}
+
+	return r.String()
+}
+
+type renpyWriter struct {
+	strings.Builder
+	labels     map[int64]string // id узла - метка
+	characters map[int64]string // id персонажа - имя в скрипте
+}
+
+func (r *renpyWriter) line(depth int, format string, args ...interface{}) {
+	r.WriteString(strings.Repeat(renpyIndent, depth))
+	fmt.Fprintf(r, format, args...)
+	r.WriteString("\n")
+}
+
+func (r *renpyWriter) blank() {
+	r.WriteString("\n")
+}
+
+func (r *renpyWriter) node(node models.Node) {
+	r.line(0, "label %s:", r.labels[node.Id])
+
+	if node.Comment != "" {
+		r.line(1, "# %s", strings.ReplaceAll(node.Comment, "\n", " "))
+	}
+
+	if node.Background != 0 {
+		r.line(1, "scene %s", renpyMedia(node.Background))
+	}
+
+	if node.Music != 0 {
+		r.line(1, "play music %s", renpyString(renpyMedia(node.Music)))
+	}
+
+	for _, event := range node.Events {
+		r.event(event)
+	}
+
+	r.transition(node)
+}
+
+func (r *renpyWriter) event(event models.Event) {
+	if event.Sound != 0 {
+		if event.Type == models.EventSpeech || event.Type == models.EventNarration {
+			r.line(1, "voice %s", renpyString(renpyMedia(event.Sound)))
+		} else {
+			r.line(1, "play sound %s", renpyString(renpyMedia(event.Sound)))
+		}
+	}
+
+	switch event.Type {
+	case models.EventNarration:
+		r.line(1, "%s", renpyString(event.Text))
+	case models.EventSpeech:
+		if name, ok := r.characters[event.Character]; ok {
+			r.line(1, "%s %s", name, renpyString(event.Text))
+		} else {
+			r.line(1, "%s", renpyString(event.Text))
+		}
+	case models.EventCharacterEnter:
+		r.show(event)
+	case models.EventCharacterExit:
+		for _, characterId := range eventCharacters(event) {
+			if name, ok := r.characters[characterId]; ok {
+				r.line(1, "hide %s", name)
+			}
+		}
+	case models.EventBackground:
+		if event.Background != nil {
+			r.line(1, "scene %s%s", renpyMedia(event.Background.Media), renpyWith(event.Background.Transition))
+		}
+	case models.EventMusic:
+		r.music(event.Music)
+	case models.EventSoundEffect:
+		if event.SoundEffect != nil {
+			r.line(1, "play sound %s volume %s", renpyString(renpyMedia(event.SoundEffect.Media)), renpyVolume(event.SoundEffect.Volume))
+		}
+	case models.EventScreenEffect:
+		r.screen(event.Screen)
+	case models.EventWait:
+		if event.Wait != nil {
+			if event.Wait.Skippable {
+				r.line(1, "pause %s", renpySeconds(event.Wait.DurationMs))
+			} else {
+				r.line(1, "$ renpy.pause(%s, hard=True)", renpySeconds(event.Wait.DurationMs))
+			}
+		}
+	}
+
+	for _, effect := range event.Effects {
+		switch effect.Operation {
+		case models.EffectSet:
+			r.line(1, "$ %s = %d", effect.Variable, effect.Value)
+		case models.EffectAdd:
+			r.line(1, "$ %s += %d", effect.Variable, effect.Value)
+		case models.EffectSub:
+			r.line(1, "$ %s -= %d", effect.Variable, effect.Value)
+		}
+	}
+}
+
+// show выводит персонажей события с эмоцией и позицией. Если у персонажа несколько
+// эмоций, берется эмоция с наименьшим индексом
+func (r *renpyWriter) show(event models.Event) {
+	for _, characterId := range eventCharacters(event) {
+		name, ok := r.characters[characterId]
+
+		if !ok {
+			continue
+		}
+
+		emotions := event.CharactersInEvent[characterId]
+
+		if len(emotions) == 0 {
+			r.line(1, "show %s", name)
+			continue
+		}
+
+		indexes := make([]int64, 0, len(emotions))
+
+		for emotion := range emotions {
+			indexes = append(indexes, emotion)
+		}
+
+		sort.Slice(indexes, func(a, b int) bool { return indexes[a] < indexes[b] })
+
+		r.line(1, "show %s emotion_%d at Position(xpos=%d)", name, indexes[0], emotions[indexes[0]])
+	}
+}
+
+func (r *renpyWriter) music(music *models.MusicPayload) {
+	if music == nil {
+		return
+	}
+
+	fade := ""
+
+	if music.FadeMs > 0 {
+		fade = renpySeconds(music.FadeMs)
+	}
+
+	switch music.Action {
+	case models.MusicStop:
+		if fade != "" {
+			r.line(1, "stop music fadeout %s", fade)
+		} else {
+			r.line(1, "stop music")
+		}
+	case models.MusicPlay, models.MusicCrossfade:
+		command := "play music " + renpyString(renpyMedia(music.Media))
+
+		if fade != "" {
+			if music.Action == models.MusicCrossfade {
+				command += " fadeout " + fade
+			}
+
+			command += " fadein " + fade
+		}
+
+		if music.Loop {
+			command += " loop"
+		} else {
+			command += " noloop"
+		}
+
+		r.line(1, "%s", command)
+	}
+}
+
+func (r *renpyWriter) screen(screen *models.ScreenEffectPayload) {
+	if screen == nil {
+		return
+	}
+
+	duration := renpySeconds(screen.DurationMs)
+
+	switch screen.Effect {
+	case models.ScreenFadeIn, models.ScreenFadeOut:
+		r.line(1, "with Fade(%s, 0.0, %s)", duration, duration)
+	case models.ScreenFlash:
+		color := screen.Color
+
+		if color == "" {
+			color = "#fff"
+		}
+
+		r.line(1, "with Fade(0.1, 0.0, %s, color=%s)", duration, renpyString(color))
+	case models.ScreenShake:
+		r.line(1, "with hpunch")
+	}
+}
+
+// transition выводит переход из узла: меню для выбора игрока, цепочку if для
+// автоматического перехода и return для концовки
+func (r *renpyWriter) transition(node models.Node) {
+	if node.End.Flag {
+		if node.End.EndText != "" {
+			r.line(1, "%s", renpyString(node.End.EndText))
+		}
+
+		if node.End.EndResult != "" {
+			r.line(1, "# концовка: %s", node.End.EndResult)
+		}
+
+		r.line(1, "return")
+		return
+	}
+
+	if len(node.Branching.Choices) == 0 {
+		r.line(1, "return")
+		return
+	}
+
+	if node.Branching.Flag {
+		r.line(1, "menu:")
+
+		for _, choice := range node.Branching.Choices {
+			if choice.Condition != "" {
+				r.line(2, "%s if %s:", renpyString(choice.Text), renpyCondition(choice.Condition))
+			} else {
+				r.line(2, "%s:", renpyString(choice.Text))
+			}
+
+			r.jump(3, choice.NextNode)
+		}
+
+		return
+	}
+
+	for _, choice := range node.Branching.Choices {
+		if choice.Condition == "" {
+			r.jump(1, choice.NextNode)
+			return
+		}
+
+		r.line(1, "if %s:", renpyCondition(choice.Condition))
+		r.jump(2, choice.NextNode)
+	}
+
+	// Ни одно условие не выполнилось
+	r.line(1, "return")
+}
+
+func (r *renpyWriter) jump(depth int, nodeId int64) {
+	if label, ok := r.labels[nodeId]; ok {
+		r.line(depth, "jump %s", label)
+		return
+	}
+
+	r.line(depth, "return # узел %d не входит в главу", nodeId)
+}
+
+// eventCharacters возвращает персонажей события по возрастанию id, а если их нет - Character
+func eventCharacters(event models.Event) []int64 {
+	if len(event.CharactersInEvent) == 0 {
+		if event.Character == 0 {
+			return nil
+		}
+
+		return []int64{event.Character}
+	}
+
+	ids := make([]int64, 0, len(event.CharactersInEvent))
+
+	for id := range event.CharactersInEvent {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(a, b int) bool { return ids[a] < ids[b] })
+
+	return ids
+}
+
+var renpyNameInvalid = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// renpyName приводит slug к имени Ren'Py: латиница в нижнем регистре, цифры и _
+func renpyName(slug string, fallback string) string {
+	name := strings.Trim(renpyNameInvalid.ReplaceAllString(strings.ToLower(slug), "_"), "_")
+
+	if name == "" {
+		return fallback
+	}
+
+	if name[0] >= '0' && name[0] <= '9' {
+		return "_" + name
+	}
+
+	return name
+}
+
+// renpyLabels назначает узлам уникальные метки. Метка start занята точкой входа
+func renpyLabels(chapter models.Chapter, nodes map[int64]models.Node) map[int64]string {
+	labels := make(map[int64]string, len(nodes))
+	used := map[string]bool{"start": true}
+
+	for _, nodeId := range chapter.Nodes {
+		node, ok := nodes[nodeId]
+
+		if !ok {
+			continue
+		}
+
+		fallback := "node_" + strconv.FormatInt(node.Id, 10)
+		label := renpyName(node.Slug, fallback)
+
+		if used[label] {
+			label += "_" + strconv.FormatInt(node.Id, 10)
+		}
+
+		used[label] = true
+		labels[node.Id] = label
+	}
+
+	return labels
+}
+
+func renpyCharacterNames(chapter models.Chapter, characters map[int64]models.Character) map[int64]string {
+	names := make(map[int64]string, len(characters))
+	used := map[string]bool{}
+
+	for _, characterId := range chapter.Characters {
+		character, ok := characters[characterId]
+
+		if !ok {
+			continue
+		}
+
+		fallback := "character_" + strconv.FormatInt(character.Id, 10)
+		name := renpyName(character.Slug, fallback)
+
+		if used[name] {
+			name += "_" + strconv.FormatInt(character.Id, 10)
+		}
+
+		used[name] = true
+		names[character.Id] = name
+	}
+
+	return names
+}
+
+func renpyMedia(id int64) string {
+	return "media_" + strconv.FormatInt(id, 10)
+}
+
+var renpyEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+	"[", "[[",
+	"{", "{{",
+)
+
+// renpyString экранирует строку для Ren'Py, включая подстановки [..] и теги {..}
+func renpyString(s string) string {
+	return `"` + renpyEscaper.Replace(s) + `"`
+}
+
+func renpyWith(transition string) string {
+	switch transition {
+	case "fade":
+		return " with fade"
+	case "dissolve":
+		return " with dissolve"
+	default:
+		return ""
+	}
+}
+
+// renpySeconds переводит миллисекунды в секунды Ren'Py
+func renpySeconds(ms int64) string {
+	return strconv.FormatFloat(float64(ms)/1000, 'f', -1, 64)
+}
+
+// renpyVolume переводит громкость 0 - 100 в долю от 0 до 1
+func renpyVolume(volume int64) string {
+	return strconv.FormatFloat(float64(volume)/100, 'f', -1, 64)
+}
+
+func renpyVariableValue(variableType string, value int64) string {
+	if variableType == models.VariableTypeFlag {
+		if value != 0 {
+			return "True"
+		}
+
+		return "False"
+	}
+
+	return strconv.FormatInt(value, 10)
+}
+
+var (
+	renpyOperators = strings.NewReplacer("&&", " and ", "||", " or ", "!=", "!=", "!", " not ")
+	renpyBool      = regexp.MustCompile(`\b(true|false)\b`)
+	renpySpaces    = regexp.MustCompile(`\s+`)
+)
+
+// renpyCondition переводит выражение pkg/condition в выражение Python
+func renpyCondition(condition string) string {
+	expr := renpyOperators.Replace(condition)
+
+	expr = renpyBool.ReplaceAllStringFunc(expr, func(literal string) string {
+		return strings.ToUpper(literal[:1]) + literal[1:]
+	})
+
+	return strings.TrimSpace(renpySpaces.ReplaceAllString(expr, " "))
+}
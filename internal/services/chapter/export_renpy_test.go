@@ -0,0 +1,94 @@
+package chapter
+
+import (
+	"testing"
+	"vn/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderRenpy(t *testing.T) {
+	chapter := models.Chapter{Id: 1, Name: "Пролог", StartNode: 10, Nodes: []int64{10, 11, 12}, Characters: []int64{7}}
+
+	characters := map[int64]models.Character{
+		7: {Id: 7, Name: "Алиса", Slug: "alice", Color: "#ff0000"},
+	}
+
+	nodes := map[int64]models.Node{
+		10: {
+			Id:         10,
+			Slug:       "start",
+			Background: 3,
+			Events: models.Events{
+				{Id: 1, Type: models.EventCharacterEnter, Character: 7, CharactersInEvent: map[int64]map[int64]int64{7: {2: 100}}},
+				{Id: 2, Type: models.EventSpeech, Character: 7, Text: `Привет, "гость" [1]`,
+					Effects: []models.Effect{{Variable: "trust", Operation: models.EffectAdd, Value: 1}}},
+				{Id: 3, Type: models.EventWait, Wait: &models.WaitPayload{DurationMs: 1500, Skippable: true}},
+			},
+			Branching: models.Branching{Flag: true, Choices: []models.Choice{
+				{Text: "Остаться", NextNode: 11, Condition: "trust >= 1 && !angry"},
+				{Text: "Уйти", NextNode: 12},
+			}},
+		},
+		11: {
+			Id:   11,
+			Slug: "stay-here",
+			Events: models.Events{
+				{Id: 1, Type: models.EventCharacterExit, Character: 7},
+				{Id: 2, Type: models.EventMusic, Music: &models.MusicPayload{Action: models.MusicStop, FadeMs: 500}},
+			},
+			End: models.EndInfo{Flag: true, EndResult: "good", EndText: "Конец"},
+		},
+		12: {
+			Id:     12,
+			Slug:   "leave",
+			Events: models.Events{{Id: 1, Type: models.EventNarration, Text: "Ты уходишь."}},
+			Branching: models.Branching{Choices: []models.Choice{
+				{NextNode: 11, Condition: "trust > 5"},
+				{NextNode: 99},
+			}},
+		},
+	}
+
+	variables := []models.Variable{
+		{Name: "trust", Type: models.VariableTypeInt, Default: 0},
+		{Name: "angry", Type: models.VariableTypeFlag, Default: 1},
+	}
+
+	expected := `# Глава "Пролог", экспорт в Ren'Py
+
+define alice = Character("Алиса", color="#ff0000")
+default angry = True
+default trust = 0
+
+label start:
+    jump start_10
+
+label start_10:
+    scene media_3
+    show alice emotion_2 at Position(xpos=100)
+    alice "Привет, \"гость\" [[1]"
+    $ trust += 1
+    pause 1.5
+    menu:
+        "Остаться" if trust >= 1 and not angry:
+            jump stay_here
+        "Уйти":
+            jump leave
+
+label stay_here:
+    hide alice
+    stop music fadeout 0.5
+    "Конец"
+    # концовка: good
+    return
+
+label leave:
+    "Ты уходишь."
+    if trust > 5:
+        jump stay_here
+    return # узел 99 не входит в главу
+`
+
+	assert.Equal(t, expected, RenderRenpy(chapter, nodes, characters, variables))
+}
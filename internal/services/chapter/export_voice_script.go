@@ -0,0 +1,266 @@
+package chapter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"sort"
+	"strconv"
+	"strings"
+	"vn/internal/models"
+)
+
+const (
+	VoiceFountain = "fountain"
+	VoiceCSV      = "csv"
+)
+
+var (
+	ErrUnknownVoiceFormat    = errors.New("unknown voice script format")
+	ErrCharacterNotInChapter = errors.New("character is not in chapter")
+	ErrInvalidLineId         = errors.New("invalid line id")
+)
+
+// VoiceLine - строка для озвучки: реплика персонажа или текст рассказчика
+type VoiceLine struct {
+	LineId    string // <id узла>_<id события>, по нему файлы записи сопоставляются с событиями
+	NodeId    int64
+	NodeSlug  string
+	EventId   int64
+	Speaker   string // slug персонажа, пусто - рассказчик
+	Name      string // имя персонажа
+	Emotion   int64  // эмоция говорящего, -1 - не указана
+	Text      string
+	Sound     int64 // уже прикрепленный файл озвучки
+	Character int64
+}
+
+// VoiceLineId - id строки озвучки для события узла
+func VoiceLineId(nodeId int64, eventId int64) string {
+	return fmt.Sprintf("%d_%d", nodeId, eventId)
+}
+
+// ParseVoiceLineId разбирает id строки озвучки, в том числе имя файла без расширения
+func ParseVoiceLineId(lineId string) (int64, int64, error) {
+	nodePart, eventPart, ok := strings.Cut(lineId, "_")
+
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: %q", ErrInvalidLineId, lineId)
+	}
+
+	nodeId, err := strconv.ParseInt(nodePart, 10, 64)
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %q", ErrInvalidLineId, lineId)
+	}
+
+	eventId, err := strconv.ParseInt(eventPart, 10, 64)
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %q", ErrInvalidLineId, lineId)
+	}
+
+	return nodeId, eventId, nil
+}
+
+// ExportVoiceScript выгружает строки главы для записи озвучки в Fountain или CSV.
+// characterId != 0 оставляет только реплики этого персонажа
+func ExportVoiceScript(chapterId int64, characterId int64, format string, db *gorm.DB) ([]byte, error) {
+	if format != VoiceFountain && format != VoiceCSV {
+		return nil, ErrUnknownVoiceFormat
+	}
+
+	chapter, nodes, characters, err := loadChapterContents(chapterId, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if characterId != 0 {
+		found := false
+
+		for _, character := range characters {
+			found = found || character.Id == characterId
+		}
+
+		if !found {
+			return nil, fmt.Errorf("%w: character %d, chapter %d", ErrCharacterNotInChapter, characterId, chapterId)
+		}
+	}
+
+	lines := VoiceLines(nodes, characters, characterId)
+
+	if format == VoiceCSV {
+		return RenderVoiceCSV(lines)
+	}
+
+	return []byte(RenderFountain(chapter.Name, lines)), nil
+}
+
+// VoiceLines собирает реплики и текст рассказчика в порядке узлов главы.
+// characterId != 0 оставляет только реплики этого персонажа
+func VoiceLines(nodes []models.Node, characters []models.Character, characterId int64) []VoiceLine {
+	byId := make(map[int64]models.Character, len(characters))
+
+	for _, character := range characters {
+		byId[character.Id] = character
+	}
+
+	var lines []VoiceLine
+
+	for _, node := range nodes {
+		for _, event := range node.Events {
+			if strings.TrimSpace(event.Text) == "" {
+				continue
+			}
+
+			speech := event.Type == models.EventSpeech
+
+			if !speech && event.Type != models.EventNarration {
+				continue
+			}
+
+			if characterId != 0 && (!speech || event.Character != characterId) {
+				continue
+			}
+
+			line := VoiceLine{
+				LineId:   VoiceLineId(node.Id, event.Id),
+				NodeId:   node.Id,
+				NodeSlug: node.Slug,
+				EventId:  event.Id,
+				Emotion:  -1,
+				Text:     event.Text,
+				Sound:    event.Sound,
+			}
+
+			if speech {
+				character := byId[event.Character]
+				line.Character = event.Character
+				line.Speaker = character.Slug
+				line.Name = character.Name
+				line.Emotion = speakerEmotion(event)
+
+				if line.Name == "" {
+					line.Name = fmt.Sprintf("CHARACTER %d", event.Character)
+				}
+			}
+
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// speakerEmotion - эмоция говорящего с наименьшим индексом, как в экспорте Ren'Py
+func speakerEmotion(event models.Event) int64 {
+	emotions := event.CharactersInEvent[event.Character]
+
+	if len(emotions) == 0 {
+		return -1
+	}
+
+	indexes := make([]int64, 0, len(emotions))
+
+	for emotion := range emotions {
+		indexes = append(indexes, emotion)
+	}
+
+	sort.Slice(indexes, func(a, b int) bool { return indexes[a] < indexes[b] })
+
+	return indexes[0]
+}
+
+// RenderVoiceCSV пишет таблицу строк озвучки: id строки, узел, говорящий, эмоция, текст, файл
+func RenderVoiceCSV(lines []VoiceLine) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	rows := [][]string{{"line_id", "node", "speaker", "emotion", "text", "sound"}}
+
+	for _, line := range lines {
+		emotion, sound := "", ""
+
+		if line.Emotion >= 0 {
+			emotion = strconv.FormatInt(line.Emotion, 10)
+		}
+
+		if line.Sound != 0 {
+			sound = strconv.FormatInt(line.Sound, 10)
+		}
+
+		rows = append(rows, []string{line.LineId, line.NodeSlug, line.Speaker, emotion, line.Text, sound})
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RenderFountain пишет сценарий Fountain: каждый узел - сцена, реплики - диалоги,
+// текст рассказчика - действие. Id строки записывается заметкой [[...]]
+func RenderFountain(title string, lines []VoiceLine) string {
+	var b strings.Builder
+
+	if title != "" {
+		fmt.Fprintf(&b, "Title: %s\n\n", fountainText(title))
+	}
+
+	var node int64
+
+	for _, line := range lines {
+		if line.NodeId != node {
+			node = line.NodeId
+			heading := line.NodeSlug
+
+			if heading == "" {
+				heading = fmt.Sprintf("node %d", line.NodeId)
+			}
+
+			fmt.Fprintf(&b, ".%s\n\n", strings.ToUpper(fountainText(heading)))
+		}
+
+		text := fountainParagraph(line.Text)
+		note := fmt.Sprintf(" [[%s]]", line.LineId)
+
+		if line.Character == 0 {
+			// ! делает строку действием, даже если она похожа на реплику или заголовок
+			fmt.Fprintf(&b, "!%s%s\n\n", text, note)
+			continue
+		}
+
+		b.WriteString(strings.ToUpper(fountainText(line.Name)) + "\n")
+
+		if line.Emotion >= 0 {
+			fmt.Fprintf(&b, "(emotion %d)\n", line.Emotion)
+		}
+
+		fmt.Fprintf(&b, "%s%s\n\n", text, note)
+	}
+
+	return b.String()
+}
+
+// fountainText убирает переводы строк из однострочных элементов
+func fountainText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// fountainParagraph сохраняет переводы строк внутри реплики. Пустая строка
+// в Fountain закончила бы реплику, поэтому в ней пишутся два пробела
+func fountainParagraph(s string) string {
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(s, "\r", "")), "\n")
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			lines[i] = "  "
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,88 @@
+package chapter
+
+import (
+	"errors"
+	"testing"
+	"vn/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testVoiceNodes() ([]models.Node, []models.Character) {
+	nodes := []models.Node{
+		{Id: 10, Slug: "start", Events: models.Events{
+			{Id: 1, Type: models.EventNarration, Text: "Утро."},
+			{Id: 2, Type: models.EventSpeech, Character: 5, Text: "Привет!\n\nКак ты?", CharactersInEvent: map[int64]map[int64]int64{5: {3: 10, 2: 30}}, Sound: 77},
+			{Id: 3, Type: models.EventWait, Wait: &models.WaitPayload{DurationMs: 500}},
+		}},
+		{Id: 11, Slug: "forest", Events: models.Events{
+			{Id: 1, Type: models.EventSpeech, Character: 6, Text: "Тише"},
+			{Id: 2, Type: models.EventSpeech, Character: 5, Text: ""},
+		}},
+	}
+
+	characters := []models.Character{{Id: 5, Name: "Анна", Slug: "anna"}, {Id: 6, Name: "Борис", Slug: "boris"}}
+
+	return nodes, characters
+}
+
+func TestVoiceLines(t *testing.T) {
+	nodes, characters := testVoiceNodes()
+
+	lines := VoiceLines(nodes, characters, 0)
+
+	assert.Len(t, lines, 3)
+	assert.Equal(t, VoiceLine{LineId: "10_1", NodeId: 10, NodeSlug: "start", EventId: 1, Emotion: -1, Text: "Утро."}, lines[0])
+	assert.Equal(t, "anna", lines[1].Speaker)
+	assert.Equal(t, int64(2), lines[1].Emotion)
+	assert.Equal(t, "11_1", lines[2].LineId)
+
+	lines = VoiceLines(nodes, characters, 6)
+
+	assert.Len(t, lines, 1)
+	assert.Equal(t, "boris", lines[0].Speaker)
+}
+
+func TestRenderVoiceCSV(t *testing.T) {
+	nodes, characters := testVoiceNodes()
+
+	data, err := RenderVoiceCSV(VoiceLines(nodes, characters, 0))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "line_id,node,speaker,emotion,text,sound\n"+
+		"10_1,start,,,Утро.,\n"+
+		"10_2,start,anna,2,\"Привет!\n\nКак ты?\",77\n"+
+		"11_1,forest,boris,,Тише,\n", string(data))
+}
+
+func TestRenderFountain(t *testing.T) {
+	nodes, characters := testVoiceNodes()
+
+	script := RenderFountain("Глава 1", VoiceLines(nodes, characters, 0))
+
+	assert.Equal(t, "Title: Глава 1\n\n"+
+		".START\n\n"+
+		"!Утро. [[10_1]]\n\n"+
+		"АННА\n(emotion 2)\nПривет!\n  \nКак ты? [[10_2]]\n\n"+
+		".FOREST\n\n"+
+		"БОРИС\nТише [[11_1]]\n\n", script)
+}
+
+func TestParseVoiceLineId(t *testing.T) {
+	nodeId, eventId, err := ParseVoiceLineId("1712345_3")
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1712345), nodeId)
+	assert.Equal(t, int64(3), eventId)
+
+	for _, lineId := range []string{"", "1712345", "a_3", "1_b", "take2"} {
+		_, _, err = ParseVoiceLineId(lineId)
+		assert.True(t, errors.Is(err, ErrInvalidLineId), lineId)
+	}
+}
+
+func TestImportVoiceFilesInvalidArchive(t *testing.T) {
+	_, err := ImportVoiceFiles(1, []byte("not a zip"), 1, nil)
+
+	assert.True(t, errors.Is(err, ErrInvalidVoiceArchive))
+}
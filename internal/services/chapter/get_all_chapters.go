@@ -7,7 +7,9 @@ import (
 	"vn/internal/storage"
 )
 
-func GetChaptersByUserId(db *gorm.DB, id int64) ([]models.Chapter, error) {
+// GetChaptersByUserId возвращает главы, доступные пользователю. Игроку названия
+// опубликованных глав переводятся на locale
+func GetChaptersByUserId(db *gorm.DB, id int64, locale string) ([]models.Chapter, error) {
 
 	log.Println(id)
 	_, err := storage.SelectAdminWithId(db, id)
@@ -39,5 +41,29 @@ func GetChaptersByUserId(db *gorm.DB, id int64) ([]models.Chapter, error) {
 		return nil, err
 	}
 
+	chapters, err = publishedChapters(chapters, db)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(chapters))
+
+	for _, chapter := range chapters {
+		ids = append(ids, chapter.Id)
+	}
+
+	catalog, err := LoadCatalog(locale, ids, db)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	for i := range chapters {
+		chapters[i] = catalog.Chapter(chapters[i])
+	}
+
 	return chapters, nil
 }
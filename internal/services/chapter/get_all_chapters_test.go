@@ -130,11 +130,11 @@ func TestGetChaptersByUserId(t *testing.T) {
 				charactersJSON, _ := json.Marshal(testChapter.Characters)
 				updatedAtJSON, _ := json.Marshal(testChapter.UpdatedAt)
 
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, start_node, CAST(COALESCE(nodes, '[]'::json) AS TEXT) as nodes_raw, CAST(COALESCE(characters, '[]'::json) AS TEXT) as characters_raw, status, CAST(updated_at AS TEXT) as updated_at_raw, author FROM chapters`)).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author"}).
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, start_node, CAST(COALESCE(nodes, '[]'::json) AS TEXT) as nodes_raw, CAST(COALESCE(characters, '[]'::json) AS TEXT) as characters_raw, status, CAST(updated_at AS TEXT) as updated_at_raw, author, version FROM chapters`)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}).
 						AddRow(testChapter.Id, testChapter.Name, testChapter.StartNode,
 							string(nodesJSON), string(charactersJSON),
-							testChapter.Status, string(updatedAtJSON), testChapter.Author))
+							testChapter.Status, string(updatedAtJSON), testChapter.Author, 1))
 			} else if tt.isPlayer {
 				// Ожидаем успешный поиск игрока с JSON полями
 				completedChaptersJSON, _ := json.Marshal(testPlayer.CompletedChapters)
@@ -155,10 +155,14 @@ func TestGetChaptersByUserId(t *testing.T) {
 						AddRow(testChapter.Id, testChapter.Name, testChapter.StartNode,
 							string(nodesJSON), string(charactersJSON),
 							testChapter.Status, string(updatedAtJSON), testChapter.Author))
+
+				// Ожидаем получение последних версий опубликованных глав
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT DISTINCT ON (chapter_id) * FROM chapter_versions WHERE chapter_id IN ($1)`)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "chapter_id", "version", "content", "published_at"}))
 			}
 
 			// Выполняем тестируемую функцию
-			chapters, err := GetChaptersByUserId(gormDB, tt.userId)
+			chapters, err := GetChaptersByUserId(gormDB, tt.userId, "")
 
 			// Проверяем результаты
 			if (err != nil) != tt.wantErr {
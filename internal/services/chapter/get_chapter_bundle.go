@@ -0,0 +1,127 @@
+package chapter
+
+import (
+	"gorm.io/gorm"
+	"sort"
+	"vn/internal/models"
+	"vn/internal/storage"
+)
+
+// Bundle - опубликованная глава со всем, что нужно клиенту для игры без сети
+type Bundle struct {
+	Chapter    models.Chapter
+	Nodes      []models.Node
+	Characters []models.Character
+	Media      []models.MediaInfo
+}
+
+// GetChapterBundle собирает последнюю опубликованную версию главы. Правки черновика
+// после публикации игрокам не видны. Текст переводится на locale,
+// строки без перевода остаются на исходном языке
+func GetChapterBundle(id int64, locale string, db *gorm.DB) (*Bundle, error) {
+	catalog, err := LoadCatalog(locale, []int64{id}, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	chapter, err := storage.SelectChapterWIthId(db, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if chapter.Status != PublishedStatus {
+		return nil, ErrChapterNotPublished
+	}
+
+	version, err := storage.SelectLatestChapterVersion(db, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Глава опубликована до появления версий - снимок создается при первом запросе
+	if version == nil {
+		version, err = PublishVersion(id, db)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	bundle := &Bundle{Chapter: version.Content.Chapter, Nodes: version.Content.Nodes}
+	bundle.Chapter.Status = chapter.Status
+
+	if bundle.Nodes == nil {
+		bundle.Nodes = []models.Node{}
+	}
+
+	characters, err := storage.SelectCharactersWithIds(db, bundle.Chapter.Characters)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, characterId := range bundle.Chapter.Characters {
+		if character, ok := characters[characterId]; ok {
+			bundle.Characters = append(bundle.Characters, character)
+		}
+	}
+
+	bundle.Media, err = storage.SelectMediaInfo(db, bundleMediaIds(bundle))
+
+	if err != nil {
+		return nil, err
+	}
+
+	bundle.Chapter = catalog.Chapter(bundle.Chapter)
+
+	for i := range bundle.Nodes {
+		bundle.Nodes[i] = catalog.Node(bundle.Nodes[i])
+	}
+
+	for i := range bundle.Characters {
+		bundle.Characters[i] = catalog.Character(bundle.Characters[i])
+	}
+
+	return bundle, nil
+}
+
+// bundleMediaIds собирает id медиафайлов, на которые ссылаются узлы и персонажи главы
+func bundleMediaIds(bundle *Bundle) []int64 {
+	seen := map[int64]bool{}
+
+	add := func(id int64) {
+		if id != 0 {
+			seen[id] = true
+		}
+	}
+
+	for _, node := range bundle.Nodes {
+		add(node.Music)
+		add(node.Background)
+
+		for _, event := range node.Events {
+			for _, id := range event.MediaIds() {
+				add(id)
+			}
+		}
+	}
+
+	for _, character := range bundle.Characters {
+		for _, image := range character.Emotions {
+			add(image)
+		}
+	}
+
+	ids := make([]int64, 0, len(seen))
+
+	for id := range seen {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(a, b int) bool { return ids[a] < ids[b] })
+
+	return ids
+}
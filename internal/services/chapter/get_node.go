@@ -0,0 +1,42 @@
+package chapter
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"vn/internal/models"
+	"vn/internal/storage"
+)
+
+func GetNode(id int64, db *gorm.DB) (*models.Node, error) {
+	node, err := storage.SelectNodeWIthId(db, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if node == nil {
+		return nil, errors.New("node data not found")
+	}
+
+	return node, nil
+}
+
+// GetNodeBySlug возвращает узел главы по его slug
+func GetNodeBySlug(chapterId int64, slug string, db *gorm.DB) (*models.Node, error) {
+	node, err := storage.SelectNodeWithSlug(db, chapterId, slug)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if node == nil {
+		return nil, errors.New("node data not found")
+	}
+
+	return node, nil
+}
+
+// GetChapterNodes возвращает все узлы главы
+func GetChapterNodes(chapterId int64, db *gorm.DB) ([]models.Node, error) {
+	return storage.SelectNodesByChapterId(db, chapterId)
+}
@@ -0,0 +1,12 @@
+package chapter
+
+import (
+	"gorm.io/gorm"
+	"vn/internal/models"
+	"vn/internal/storage"
+)
+
+// GetVariables возвращает переменные, доступные в главе, включая глобальные
+func GetVariables(chapterId int64, db *gorm.DB) ([]models.Variable, error) {
+	return storage.SelectVariablesForChapter(db, chapterId)
+}
@@ -0,0 +1,319 @@
+package chapter
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"vn/internal/models"
+	"vn/internal/services/character"
+	"vn/internal/storage"
+	"vn/pkg/story"
+)
+
+const (
+	ImportTwee = "twee"
+	ImportInk  = "ink"
+)
+
+var (
+	ErrUnknownImportFormat = errors.New("unknown import format")
+	ErrBrokenLink          = errors.New("passage links to missing passage")
+)
+
+// ImportStory создает главу-черновик из файла Twee 3 или скомпилированного Ink.
+// Фрагменты становятся узлами, строки "Имя: реплика" - речью персонажа. Говорящие,
+// которых еще нет, создаются и возвращаются для проверки. Строки, начинающиеся
+// со слов из narration ("Поздно ночью: ..."), остаются текстом рассказчика
+func ImportStory(format string, data []byte, narration []string, authorId int64, db *gorm.DB) (int64, int64, []string, error) {
+	var parsed *story.Story
+	var err error
+
+	switch format {
+	case ImportTwee:
+		parsed, err = story.ParseTwee(data)
+	case ImportInk:
+		parsed, err = story.ParseInk(data)
+	default:
+		return 0, 0, nil, ErrUnknownImportFormat
+	}
+
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	if err = checkStoryLinks(parsed); err != nil {
+		return 0, 0, nil, err
+	}
+
+	chapterId := generateUniqueId()
+
+	nodeIds := make(map[string]int64, len(parsed.Passages))
+	used := map[int64]bool{chapterId: true}
+
+	for _, passage := range parsed.Passages {
+		// Id зависит от времени, узлы одной главы создаются в одну миллисекунду
+		id := generateUniqueId()
+
+		for used[id] {
+			id = generateUniqueId()
+		}
+
+		used[id] = true
+		nodeIds[passage.Name] = id
+	}
+
+	newChapter := models.Chapter{
+		Id:         chapterId,
+		Name:       parsed.Title,
+		StartNode:  nodeIds[parsed.Start],
+		Nodes:      make([]int64, 0, len(parsed.Passages)),
+		Characters: []int64{},
+		Status:     DefaultStatus,
+		UpdatedAt:  map[time.Time]int64{time.Now(): authorId},
+		Author:     authorId,
+		Version:    1,
+	}
+
+	if newChapter.Name == "" {
+		newChapter.Name = "Импортированная глава"
+	}
+
+	var created []string
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		known, err := knownCharacters(tx)
+
+		if err != nil {
+			return err
+		}
+
+		excluded := make(map[string]bool, len(narration))
+
+		for _, prefix := range narration {
+			excluded[strings.ToLower(strings.TrimSpace(prefix))] = true
+		}
+
+		parsed.ResolveSpeakers(func(speaker string) bool {
+			return excluded[strings.ToLower(speaker)]
+		})
+
+		names := parsed.Speakers()
+		var speakerIds map[string]int64
+		speakerIds, created, err = importSpeakers(names, known, tx)
+
+		if err != nil {
+			return err
+		}
+
+		for _, speaker := range names {
+			newChapter.Characters = append(newChapter.Characters, speakerIds[speaker])
+		}
+
+		slugs := map[string]bool{}
+
+		for _, passage := range parsed.Passages {
+			node, err := importNode(passage, chapterId, nodeIds, speakerIds, slugs)
+
+			if err != nil {
+				return err
+			}
+
+			if err = storage.RestoreNode(tx, node); err != nil {
+				return err
+			}
+
+			newChapter.Nodes = append(newChapter.Nodes, node.Id)
+		}
+
+		return storage.RestoreChapter(tx, newChapter)
+	})
+
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	return chapterId, newChapter.StartNode, created, nil
+}
+
+// checkStoryLinks проверяет, что все переходы ведут в существующие фрагменты
+func checkStoryLinks(parsed *story.Story) error {
+	if _, ok := parsed.Passage(parsed.Start); !ok {
+		return fmt.Errorf("%w: start passage %q", ErrBrokenLink, parsed.Start)
+	}
+
+	for _, passage := range parsed.Passages {
+		targets := make([]string, 0, len(passage.Choices)+1)
+
+		for _, choice := range passage.Choices {
+			targets = append(targets, choice.Target)
+		}
+
+		if passage.Next != "" {
+			targets = append(targets, passage.Next)
+		}
+
+		for _, target := range targets {
+			if _, ok := parsed.Passage(target); !ok {
+				return fmt.Errorf("%w: %q -> %q", ErrBrokenLink, passage.Name, target)
+			}
+		}
+	}
+
+	return nil
+}
+
+// knownCharacters возвращает id персонажей по имени и slug в нижнем регистре
+func knownCharacters(db *gorm.DB) (map[string]int64, error) {
+	existing, err := storage.SelectCharacters(db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]int64, len(existing)*2)
+
+	for _, c := range existing {
+		known[strings.ToLower(c.Slug)] = c.Id
+		known[strings.ToLower(c.Name)] = c.Id
+	}
+
+	return known, nil
+}
+
+// lookupCharacter ищет персонажа по имени говорящего или по slug, который получился бы из имени.
+// Так "Dr. Who" находит персонажа со slug "dr-who"
+func lookupCharacter(known map[string]int64, speaker string) (int64, bool) {
+	if id, ok := known[strings.ToLower(speaker)]; ok {
+		return id, true
+	}
+
+	slug := importSlug(speaker)
+
+	if slug == "" {
+		return 0, false
+	}
+
+	id, ok := known[slug]
+
+	return id, ok
+}
+
+// importSpeakers сопоставляет говорящих с персонажами по имени или slug без учета регистра
+// и создает недостающих. Возвращает имя говорящего - id персонажа и имена созданных персонажей
+func importSpeakers(speakers []string, known map[string]int64, db *gorm.DB) (map[string]int64, []string, error) {
+	ids := make(map[string]int64, len(speakers))
+	var created []string
+
+	for _, speaker := range speakers {
+		if id, ok := lookupCharacter(known, speaker); ok {
+			ids[speaker] = id
+			continue
+		}
+
+		slug := importSlug(speaker)
+
+		if slug == "" {
+			return nil, nil, fmt.Errorf("%w: speaker %q has no letters for slug", story.ErrInvalid, speaker)
+		}
+
+		id, err := character.CreateCharacter(speaker, slug, db)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Следующие говорящие с тем же slug ("Dr Who" и "Dr. Who") станут этим же персонажем
+		known[strings.ToLower(speaker)] = id
+		known[slug] = id
+		ids[speaker] = id
+		created = append(created, speaker)
+	}
+
+	return ids, created, nil
+}
+
+func importNode(passage story.Passage, chapterId int64, nodeIds map[string]int64, speakers map[string]int64, slugs map[string]bool) (models.Node, error) {
+	node := models.Node{
+		Id:        nodeIds[passage.Name],
+		Slug:      uniqueImportSlug(passage.Name, nodeIds[passage.Name], slugs),
+		ChapterId: chapterId,
+		Events:    make(models.Events, 0, len(passage.Lines)),
+		Comment:   passage.Name,
+		Version:   1,
+	}
+
+	for _, line := range passage.Lines {
+		event := models.Event{Type: models.EventNarration, Text: line.Text}
+
+		if line.Speaker != "" {
+			event.Type = models.EventSpeech
+			event.Character = speakers[line.Speaker]
+		}
+
+		node.Events = append(node.Events, event)
+	}
+
+	events, err := assignEventIds(node.Events)
+
+	if err != nil {
+		return models.Node{}, err
+	}
+
+	node.Events = events
+
+	switch {
+	case len(passage.Choices) > 0:
+		node.Branching.Flag = true
+
+		for _, choice := range passage.Choices {
+			node.Branching.Choices = append(node.Branching.Choices, models.Choice{Text: choice.Text, NextNode: nodeIds[choice.Target]})
+		}
+	case passage.Next != "":
+		node.Branching.Choices = []models.Choice{{NextNode: nodeIds[passage.Next]}}
+	default:
+		node.End = models.EndInfo{Flag: true}
+	}
+
+	choices, err := assignChoiceIds(node.Branching.Choices)
+
+	if err != nil {
+		return models.Node{}, err
+	}
+
+	node.Branching.Choices = choices
+
+	return node, nil
+}
+
+var importSlugInvalid = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// importSlug переводит имя фрагмента или персонажа в slug
+func importSlug(name string) string {
+	return strings.Trim(importSlugInvalid.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// uniqueImportSlug возвращает slug, не занятый другими узлами импортируемой главы
+func uniqueImportSlug(name string, nodeId int64, used map[string]bool) string {
+	slug := importSlug(name)
+
+	if utf8.RuneCountInString(slug) > 64 {
+		slug = strings.TrimRight(string([]rune(slug)[:64]), "-")
+	}
+
+	if slug == "" {
+		slug = defaultSlug(nodeId)
+	}
+
+	if used[slug] {
+		slug += "-" + strconv.FormatInt(nodeId, 36)
+	}
+
+	used[slug] = true
+
+	return slug
+}
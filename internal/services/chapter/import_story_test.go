@@ -0,0 +1,151 @@
+package chapter
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"vn/pkg/story"
+)
+
+func TestImportStory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании моковой БД: %v", err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("ошибка при создании подключения к БД: %v", err)
+	}
+
+	data := []byte(`:: Start
+Алиса: Привет!
+Боб: Здравствуй.
+[[Дальше->Конец]]
+
+:: Конец
+Конец.
+`)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, slug, color`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "color", "emotions_raw"}).
+			AddRow(7, "Алиса", "alice", "#ff0000", `{}`))
+	// Боба нет среди персонажей, он создается
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "characters"`)).
+		WithArgs("Боб", "боб", "#00693E", `{}`, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "nodes"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "nodes"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "chapters"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectCommit()
+
+	chapterId, startNode, created, err := ImportStory(ImportTwee, data, nil, 1, gormDB)
+
+	assert.NoError(t, err)
+	assert.NotZero(t, chapterId)
+	assert.NotZero(t, startNode)
+	assert.Equal(t, []string{"Боб"}, created)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportStorySpeakers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании моковой БД: %v", err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("ошибка при создании подключения к БД: %v", err)
+	}
+
+	// "Dr. Who" находится по slug существующего персонажа, а не создается повторно.
+	// "Поздно ночью" исключено и остается текстом рассказчика
+	data := []byte(`:: Start
+Dr. Who: Allons-y!
+Поздно ночью: все спали.
+`)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, slug, color`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "color", "emotions_raw"}).
+			AddRow(7, "Доктор", "dr-who", "#ff0000", `{}`))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "nodes"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "chapters"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectCommit()
+
+	_, _, created, err := ImportStory(ImportTwee, data, []string{"поздно ночью"}, 1, gormDB)
+
+	assert.NoError(t, err)
+	assert.Empty(t, created)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportSpeakersSameSlug(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании моковой БД: %v", err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("ошибка при создании подключения к БД: %v", err)
+	}
+
+	// Второй говорящий с тем же slug не нарушает уникальность characters.slug
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "characters"`)).
+		WithArgs("Dr Who", "dr-who", "#00693E", `{}`, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+	mock.ExpectCommit()
+
+	ids, created, err := importSpeakers([]string{"Dr Who", "Dr. Who"}, map[string]int64{}, gormDB)
+
+	assert.NoError(t, err)
+	assert.Equal(t, ids["Dr Who"], ids["Dr. Who"])
+	assert.Equal(t, []string{"Dr Who"}, created)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportStoryErrors(t *testing.T) {
+	_, _, _, err := ImportStory("docx", nil, nil, 1, nil)
+	assert.ErrorIs(t, err, ErrUnknownImportFormat)
+
+	_, _, _, err = ImportStory(ImportTwee, []byte(":: Start\n[[Нет такого]]\n"), nil, 1, nil)
+	assert.ErrorIs(t, err, ErrBrokenLink)
+
+	_, _, _, err = ImportStory(ImportInk, []byte("не json"), nil, 1, nil)
+	assert.ErrorIs(t, err, story.ErrInvalid)
+}
+
+func TestImportNode(t *testing.T) {
+	nodeIds := map[string]int64{"A": 10, "B": 11}
+
+	node, err := importNode(story.Passage{
+		Name:  "A",
+		Lines: []story.Line{{Text: "Тишина."}, {Speaker: "Алиса", Text: "Ау!"}},
+		Next:  "B",
+	}, 1, nodeIds, map[string]int64{"Алиса": 7}, map[string]bool{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "a", node.Slug)
+	assert.Equal(t, 1, node.Version)
+	assert.Len(t, node.Events, 2)
+	assert.Equal(t, int64(7), node.Events[1].Character)
+	assert.False(t, node.Branching.Flag)
+	assert.Equal(t, int64(11), node.Branching.Choices[0].NextNode)
+	assert.False(t, node.End.Flag)
+}
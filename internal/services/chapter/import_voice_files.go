@@ -0,0 +1,222 @@
+package chapter
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"io"
+	"mime"
+	"path"
+	"sort"
+	"strings"
+	"vn/internal/models"
+	"vn/internal/storage"
+)
+
+var ErrInvalidVoiceArchive = errors.New("invalid voice archive")
+
+// Типы файлов, которые присылают студии озвучки. Остальные определяются по расширению
+var voiceContentTypes = map[string]string{
+	".ogg":  "audio/ogg",
+	".opus": "audio/ogg",
+	".mp3":  "audio/mpeg",
+	".wav":  "audio/wav",
+	".flac": "audio/flac",
+	".m4a":  "audio/mp4",
+}
+
+// VoiceReport - итог загрузки файлов озвучки
+type VoiceReport struct {
+	Attached int      // файлов прикреплено к событиям
+	Unknown  []string // файлы, для которых не нашлось строки в главе
+}
+
+type voiceFile struct {
+	name        string
+	nodeId      int64
+	eventId     int64
+	contentType string
+	data        []byte
+}
+
+// ImportVoiceFiles прикрепляет файлы озвучки из zip-архива к событиям главы. Имя файла
+// без расширения - id строки из ExportVoiceScript, например 1712345_3.ogg.
+// Админ должен держать блокировки всех затронутых узлов, иначе загрузка целиком отклоняется
+func ImportVoiceFiles(chapterId int64, data []byte, adminId int64, db *gorm.DB) (*VoiceReport, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidVoiceArchive, err)
+	}
+
+	_, nodes, _, err := loadChapterContents(chapterId, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	byId := make(map[int64]*models.Node, len(nodes))
+
+	for i := range nodes {
+		byId[nodes[i].Id] = &nodes[i]
+	}
+
+	report := &VoiceReport{Unknown: []string{}}
+	seen := map[string]string{}
+	var files []voiceFile
+
+	for _, file := range archive.File {
+		name := path.Base(file.Name)
+
+		// Каталоги и служебные файлы архиваторов
+		if file.FileInfo().IsDir() || strings.HasPrefix(name, ".") || strings.HasPrefix(file.Name, "__MACOSX/") {
+			continue
+		}
+
+		ext := strings.ToLower(path.Ext(name))
+		lineId := strings.TrimSuffix(name, path.Ext(name))
+
+		nodeId, eventId, err := ParseVoiceLineId(lineId)
+
+		if err != nil {
+			report.Unknown = append(report.Unknown, file.Name)
+			continue
+		}
+
+		node, ok := byId[nodeId]
+
+		if !ok || node.Events.Find(eventId) < 0 {
+			report.Unknown = append(report.Unknown, file.Name)
+			continue
+		}
+
+		if other, ok := seen[lineId]; ok {
+			return nil, fmt.Errorf("%w: %s and %s are for the same line", ErrInvalidVoiceArchive, other, file.Name)
+		}
+
+		seen[lineId] = file.Name
+
+		contentType := voiceContentTypes[ext]
+
+		if contentType == "" {
+			contentType = mime.TypeByExtension(ext)
+		}
+
+		if !strings.HasPrefix(contentType, "audio/") {
+			return nil, fmt.Errorf("%w: %s is not an audio file", ErrInvalidVoiceArchive, file.Name)
+		}
+
+		blob, err := readVoiceFile(file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, voiceFile{name: file.Name, nodeId: nodeId, eventId: eventId, contentType: contentType, data: blob})
+	}
+
+	if len(files) == 0 {
+		return report, nil
+	}
+
+	// Узлы обновляются в порядке id, чтобы результат не зависел от порядка файлов в архиве
+	sort.Slice(files, func(a, b int) bool {
+		if files[a].nodeId != files[b].nodeId {
+			return files[a].nodeId < files[b].nodeId
+		}
+
+		return files[a].eventId < files[b].eventId
+	})
+
+	changed := map[int64]bool{}
+
+	for _, file := range files {
+		if changed[file.nodeId] {
+			continue
+		}
+
+		changed[file.nodeId] = true
+
+		if err := checkNodeLock(file.nodeId, adminId, db); err != nil {
+			return nil, err
+		}
+	}
+
+	used := map[int64]bool{}
+	var updated []models.Node
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, file := range files {
+			mediaId := generateUniqueId()
+
+			for used[mediaId] {
+				mediaId = generateUniqueId()
+			}
+
+			used[mediaId] = true
+
+			_, err := storage.RegisterMedia(tx, models.Media{Id: mediaId, FileData: file.data, ContentType: file.contentType})
+
+			if err != nil {
+				return err
+			}
+
+			node := byId[file.nodeId]
+			events := make(models.Events, len(node.Events))
+			copy(events, node.Events)
+			events[events.Find(file.eventId)].Sound = mediaId
+			node.Events = events
+		}
+
+		for _, node := range nodes {
+			if !changed[node.Id] {
+				continue
+			}
+
+			if _, err := storage.UpdateNode(tx, node.Id, node, adminId); err != nil {
+				return nodeConflict(node.Id, err, db)
+			}
+
+			node.Version++
+			updated = append(updated, node)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, node := range updated {
+		publishNodeUpdated(node)
+	}
+
+	report.Attached = len(files)
+
+	return report, nil
+}
+
+func readVoiceFile(file *zip.File) ([]byte, error) {
+	r, err := file.Open()
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidVoiceArchive, file.Name, err)
+	}
+
+	defer r.Close()
+
+	data, err := io.ReadAll(io.LimitReader(r, archiveMaxFile+1))
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidVoiceArchive, file.Name, err)
+	}
+
+	if len(data) > archiveMaxFile {
+		return nil, fmt.Errorf("%w: %s is too large", ErrInvalidVoiceArchive, file.Name)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,42 @@
+package chapter
+
+import (
+	"gorm.io/gorm"
+	"vn/internal/models"
+)
+
+// InsertEvent добавляет событие в узел на указанную позицию и возвращает id события.
+// Позиция меньше нуля или больше числа событий означает добавление в конец.
+// Вторым значением возвращается новая версия узла
+func InsertEvent(nodeId int64, position int, event models.Event, version int, adminId int64, db *gorm.DB) (int64, int, error) {
+	node, err := GetNode(nodeId, db)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	used := make(map[int64]bool, len(node.Events))
+
+	for _, existing := range node.Events {
+		used[existing.Id] = true
+	}
+
+	event.Id = newEventId(used)
+
+	newNode := *node
+	newNode.Events = insertEvent(node.Events, position, event)
+
+	err = checkEvents(newNode, db)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	newVersion, err := saveEvents(node, newNode.Events, version, adminId, db)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return event.Id, newVersion, nil
+}
@@ -0,0 +1,22 @@
+package chapter
+
+import (
+	"gorm.io/gorm"
+)
+
+// MoveEvent переставляет событие узла на новую позицию и возвращает новую версию узла
+func MoveEvent(nodeId int64, eventId int64, position int, version int, adminId int64, db *gorm.DB) (int, error) {
+	node, err := GetNode(nodeId, db)
+
+	if err != nil {
+		return 0, err
+	}
+
+	events, err := moveEvent(node.Events, eventId, position)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return saveEvents(node, events, version, adminId, db)
+}
@@ -0,0 +1,148 @@
+package chapter
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+	"vn/internal/models"
+	"vn/internal/storage"
+)
+
+// LockLease - на сколько ставится и продлевается блокировка узла.
+// Редактор продлевает ее раз в несколько десятков секунд, пока узел открыт
+const LockLease = 2 * time.Minute
+
+const superAdminStatus = 1
+
+var (
+	ErrLockNotHeld   = errors.New("node lock is not held")
+	ErrNotSuperAdmin = errors.New("only superadmin can override node lock")
+	ErrInvalidAdmin  = errors.New("admin id is required")
+)
+
+// NodeLockedError возвращается, когда узел заблокирован другим админом
+type NodeLockedError struct {
+	Lock models.NodeLock
+}
+
+func (e *NodeLockedError) Error() string {
+	return fmt.Sprintf("node %d is locked by admin %d until %s", e.Lock.NodeId, e.Lock.AdminId, e.Lock.ExpiresAt.Format(time.RFC3339))
+}
+
+// LockNode ставит блокировку узла на LockLease. Повторный вызов владельцем продлевает ее.
+// force перехватывает чужую блокировку и доступен только сверхадмину
+func LockNode(nodeId int64, adminId int64, force bool, db *gorm.DB) (*models.NodeLock, error) {
+	if adminId <= 0 {
+		return nil, ErrInvalidAdmin
+	}
+
+	if _, err := GetNode(nodeId, db); err != nil {
+		return nil, err
+	}
+
+	if force {
+		admin, err := storage.SelectAdminWithId(db, adminId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if admin.AdminStatus != superAdminStatus {
+			return nil, ErrNotSuperAdmin
+		}
+	}
+
+	now := time.Now()
+	lock := models.NodeLock{NodeId: nodeId, AdminId: adminId, ExpiresAt: now.Add(LockLease)}
+
+	acquired, err := storage.AcquireNodeLock(db, lock, now, force)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !acquired {
+		return nil, lockedError(nodeId, now, db)
+	}
+
+	return &lock, nil
+}
+
+// RenewNodeLock продлевает блокировку владельца, вызывается редактором как heartbeat
+func RenewNodeLock(nodeId int64, adminId int64, db *gorm.DB) (*models.NodeLock, error) {
+	if adminId <= 0 {
+		return nil, ErrInvalidAdmin
+	}
+
+	now := time.Now()
+	lock := models.NodeLock{NodeId: nodeId, AdminId: adminId, ExpiresAt: now.Add(LockLease)}
+
+	renewed, err := storage.RenewNodeLock(db, nodeId, adminId, now, lock.ExpiresAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !renewed {
+		return nil, ErrLockNotHeld
+	}
+
+	return &lock, nil
+}
+
+// UnlockNode снимает блокировку владельца
+func UnlockNode(nodeId int64, adminId int64, db *gorm.DB) error {
+	if adminId <= 0 {
+		return ErrInvalidAdmin
+	}
+
+	deleted, err := storage.DeleteNodeLock(db, nodeId, adminId)
+
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+// checkNodeLock разрешает запись в узел только админу с действующей блокировкой
+func checkNodeLock(nodeId int64, adminId int64, db *gorm.DB) error {
+	now := time.Now()
+
+	lock, err := storage.SelectNodeLock(db, nodeId, now)
+
+	if err != nil {
+		return err
+	}
+
+	if lock == nil {
+		return ErrLockNotHeld
+	}
+
+	if lock.AdminId != adminId {
+		return &NodeLockedError{Lock: *lock}
+	}
+
+	return nil
+}
+
+// lockedError возвращает текущего владельца блокировки
+func lockedError(nodeId int64, now time.Time, db *gorm.DB) error {
+	lock, err := storage.SelectNodeLock(db, nodeId, now)
+
+	if err != nil {
+		return err
+	}
+
+	if lock == nil {
+		// Блокировка истекла между запросами, клиент может повторить попытку
+		return ErrLockNotHeld
+	}
+
+	return &NodeLockedError{Lock: *lock}
+}
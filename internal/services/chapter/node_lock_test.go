@@ -0,0 +1,177 @@
+package chapter
+
+import (
+	"regexp"
+	"testing"
+	"time"
+	"vn/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestCheckNodeLock(t *testing.T) {
+	tests := []struct {
+		label   string
+		rows    *sqlmock.Rows
+		wantErr error
+		locked  bool
+	}{
+		{
+			label:   "нет блокировки",
+			rows:    sqlmock.NewRows([]string{"node_id", "admin_id", "expires_at"}),
+			wantErr: ErrLockNotHeld,
+		},
+		{
+			label: "блокировка у этого админа",
+			rows: sqlmock.NewRows([]string{"node_id", "admin_id", "expires_at"}).
+				AddRow(5, 1, time.Now().Add(LockLease)),
+		},
+		{
+			label: "блокировка у другого админа",
+			rows: sqlmock.NewRows([]string{"node_id", "admin_id", "expires_at"}).
+				AddRow(5, 2, time.Now().Add(LockLease)),
+			locked: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("ошибка при создании моковой БД: %v", err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatalf("ошибка при создании подключения к БД: %v", err)
+			}
+
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "node_locks" WHERE node_id = $1 AND expires_at >= $2`)).
+				WithArgs(5, sqlmock.AnyArg(), 1).
+				WillReturnRows(tt.rows)
+
+			err = checkNodeLock(5, 1, gormDB)
+
+			if tt.locked {
+				var lockedErr *NodeLockedError
+				assert.ErrorAs(t, err, &lockedErr)
+				assert.Equal(t, int64(2), lockedErr.Lock.AdminId)
+			} else if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUnlockNodeNotHeld(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании моковой БД: %v", err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("ошибка при создании подключения к БД: %v", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "node_locks" WHERE node_id = $1 AND admin_id = $2`)).
+		WithArgs(5, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err = UnlockNode(5, 1, gormDB)
+
+	assert.ErrorIs(t, err, ErrLockNotHeld)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNodeLockInvalidAdmin(t *testing.T) {
+	// Проверка идет до обращения к базе
+	_, err := LockNode(5, 0, false, nil)
+	assert.ErrorIs(t, err, ErrInvalidAdmin)
+
+	_, err = RenewNodeLock(5, -1, nil)
+	assert.ErrorIs(t, err, ErrInvalidAdmin)
+
+	err = UnlockNode(5, 0, nil)
+	assert.ErrorIs(t, err, ErrInvalidAdmin)
+}
+
+func TestWritesRequireNodeLock(t *testing.T) {
+	lockColumns := []string{"node_id", "admin_id", "expires_at"}
+	nodeColumns := []string{"id", "slug", "chapter_id", "music", "background", "events_raw", "branching_raw", "end_raw", "comment", "version"}
+
+	tests := []struct {
+		label  string
+		locks  *sqlmock.Rows
+		write  func(db *gorm.DB) error
+		locked bool
+	}{
+		{
+			label: "изменение событий без блокировки",
+			locks: sqlmock.NewRows(lockColumns),
+			write: func(db *gorm.DB) error {
+				_, err := saveEvents(&models.Node{Id: 5, Version: 1}, models.Events{}, 1, 1, db)
+				return err
+			},
+		},
+		{
+			label: "изменение событий под чужой блокировкой",
+			locks: sqlmock.NewRows(lockColumns).AddRow(5, 2, time.Now().Add(LockLease)),
+			write: func(db *gorm.DB) error {
+				_, err := saveEvents(&models.Node{Id: 5, Version: 1}, models.Events{}, 1, 1, db)
+				return err
+			},
+			locked: true,
+		},
+		{
+			label: "удаление узла под чужой блокировкой",
+			locks: sqlmock.NewRows(lockColumns).AddRow(5, 2, time.Now().Add(LockLease)),
+			write: func(db *gorm.DB) error {
+				return DeleteNode(5, 1, db)
+			},
+			locked: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("ошибка при создании моковой БД: %v", err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatalf("ошибка при создании подключения к БД: %v", err)
+			}
+
+			mock.MatchExpectationsInOrder(false)
+			mock.ExpectQuery(`FROM nodes`).
+				WillReturnRows(sqlmock.NewRows(nodeColumns).AddRow(5, "start", 3, 1, 1, "[]", "{}", "{}", "", 1))
+			mock.ExpectQuery(`FROM "node_locks"`).WillReturnRows(tt.locks)
+
+			// Запись в базу не ожидается: sqlmock вернет ошибку на любой UPDATE или DELETE
+			err = tt.write(gormDB)
+
+			var lockedErr *NodeLockedError
+
+			if tt.locked {
+				assert.ErrorAs(t, err, &lockedErr)
+				assert.Equal(t, int64(2), lockedErr.Lock.AdminId)
+			} else {
+				assert.ErrorIs(t, err, ErrLockNotHeld)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package chapter
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"time"
+	"vn/internal/models"
+	"vn/internal/storage"
+)
+
+// PublishedStatus - статус опубликованной главы
+const PublishedStatus = 3
+
+var ErrChapterNotPublished = errors.New("chapter is not published")
+
+// PublishVersion сохраняет неизменяемый снимок главы и всех ее узлов.
+// Игроки, начавшие главу раньше, продолжают проходить свою версию
+func PublishVersion(chapterId int64, db *gorm.DB) (*models.ChapterVersion, error) {
+	chapter, err := storage.SelectChapterWIthId(db, chapterId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := loadChapterNodes(chapter, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := storage.SelectLatestChapterVersion(db, chapterId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	version := &models.ChapterVersion{
+		ChapterId:   chapterId,
+		Version:     1,
+		Content:     snapshotContent(chapter, nodes),
+		PublishedAt: time.Now(),
+	}
+
+	if latest != nil {
+		version.Version = latest.Version + 1
+	}
+
+	err = storage.SaveChapterVersion(db, version)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return version, nil
+}
+
+// snapshotContent собирает узлы главы в порядке списка Nodes
+func snapshotContent(chapter models.Chapter, nodes map[int64]models.Node) models.ChapterContent {
+	content := models.ChapterContent{Chapter: chapter, Nodes: make([]models.Node, 0, len(nodes))}
+
+	for _, nodeId := range chapter.Nodes {
+		if node, ok := nodes[nodeId]; ok {
+			content.Nodes = append(content.Nodes, node)
+		}
+	}
+
+	return content
+}
+
+// GetChapterVersions возвращает все опубликованные версии главы
+func GetChapterVersions(chapterId int64, db *gorm.DB) ([]models.ChapterVersion, error) {
+	_, err := storage.SelectChapterWIthId(db, chapterId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.SelectChapterVersions(db, chapterId)
+}
+
+// publishedChapters подменяет опубликованные главы их последними версиями.
+// Главы, опубликованные до появления версий, возвращаются как есть
+func publishedChapters(chapters []models.Chapter, db *gorm.DB) ([]models.Chapter, error) {
+	ids := make([]int64, 0, len(chapters))
+
+	for _, chapter := range chapters {
+		ids = append(ids, chapter.Id)
+	}
+
+	versions, err := storage.SelectLatestChapterVersions(db, ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i, chapter := range chapters {
+		if version, ok := versions[chapter.Id]; ok {
+			chapters[i] = version.Content.Chapter
+			chapters[i].Status = chapter.Status
+		}
+	}
+
+	return chapters, nil
+}
@@ -0,0 +1,67 @@
+package chapter
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+	"vn/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestSnapshotContent(t *testing.T) {
+	chapter := models.Chapter{Id: 1, StartNode: 20, Nodes: []int64{20, 10, 30}}
+	nodes := map[int64]models.Node{
+		10: {Id: 10, ChapterId: 1},
+		20: {Id: 20, ChapterId: 1},
+	}
+
+	content := snapshotContent(chapter, nodes)
+
+	assert.Equal(t, chapter, content.Chapter)
+	assert.Len(t, content.Nodes, 2)
+	assert.Equal(t, int64(20), content.Nodes[0].Id)
+	assert.Equal(t, int64(10), content.Nodes[1].Id)
+
+	node, ok := content.Node(10)
+	assert.True(t, ok)
+	assert.Equal(t, int64(10), node.Id)
+
+	_, ok = content.Node(30)
+	assert.False(t, ok)
+}
+
+func TestPublishedChaptersUseLatestVersion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании моковой БД: %v", err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("ошибка при создании подключения к БД: %v", err)
+	}
+
+	content, _ := json.Marshal(models.ChapterContent{
+		Chapter: models.Chapter{Id: 1, Name: "Опубликованное название", Status: 3},
+	})
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT DISTINCT ON (chapter_id) * FROM chapter_versions WHERE chapter_id IN ($1,$2)`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chapter_id", "version", "content", "published_at"}).
+			AddRow(5, 1, 2, string(content), time.Now()))
+
+	chapters, err := publishedChapters([]models.Chapter{
+		{Id: 1, Name: "Черновое название", Status: 3},
+		{Id: 2, Name: "Без версий", Status: 3},
+	}, gormDB)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Опубликованное название", chapters[0].Name)
+	assert.Equal(t, "Без версий", chapters[1].Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
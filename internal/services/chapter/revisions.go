@@ -0,0 +1,194 @@
+package chapter
+
+import (
+	"encoding/json"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+	"vn/internal/models"
+	"vn/internal/storage"
+	"vn/pkg/jsondiff"
+)
+
+var (
+	ErrUnknownRevisionType = errors.New("unknown revision entity type")
+	ErrRevisionMismatch    = errors.New("revisions belong to different entities")
+)
+
+// GetRevisions возвращает историю изменений главы или узла, новые ревизии первыми
+func GetRevisions(entityType string, entityId int64, db *gorm.DB) ([]models.Revision, error) {
+	if entityType != models.RevisionChapter && entityType != models.RevisionNode {
+		return nil, ErrUnknownRevisionType
+	}
+
+	return storage.SelectRevisions(db, entityType, entityId)
+}
+
+// DiffRevisions сравнивает две ревизии одной сущности
+func DiffRevisions(fromId int64, toId int64, db *gorm.DB) ([]jsondiff.Change, error) {
+	from, err := storage.SelectRevision(db, fromId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := storage.SelectRevision(db, toId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if from.EntityType != to.EntityType || from.EntityId != to.EntityId {
+		return nil, ErrRevisionMismatch
+	}
+
+	return jsondiff.Diff(from.Data, to.Data)
+}
+
+// RollbackRevision возвращает главу или узел к состоянию ревизии.
+// Откат сохраняется как новая ревизия, поэтому его тоже можно отменить
+func RollbackRevision(id int64, authorId int64, db *gorm.DB) error {
+	revision, err := storage.SelectRevision(db, id)
+
+	if err != nil {
+		return err
+	}
+
+	switch revision.EntityType {
+	case models.RevisionChapter:
+		return rollbackChapter(revision, authorId, db)
+	case models.RevisionNode:
+		return rollbackNode(revision, authorId, db)
+	default:
+		return ErrUnknownRevisionType
+	}
+}
+
+// rollbackChapter восстанавливает содержимое главы. Статус публикации не откатывается,
+// узлы, удаленные после ревизии, в список не возвращаются
+func rollbackChapter(revision *models.Revision, authorId int64, db *gorm.DB) error {
+	var past models.Chapter
+
+	if err := json.Unmarshal(revision.Data, &past); err != nil {
+		return err
+	}
+
+	chapter, err := storage.SelectChapterWIthId(db, revision.EntityId)
+
+	if err != nil {
+		return err
+	}
+
+	existing, err := storage.SelectNodesByChapterId(db, chapter.Id)
+
+	if err != nil {
+		return err
+	}
+
+	exists := make(map[int64]bool, len(existing))
+
+	for _, node := range existing {
+		exists[node.Id] = true
+	}
+
+	newChapter := chapter
+	newChapter.Name = past.Name
+	newChapter.Characters = past.Characters
+	newChapter.Nodes = make([]int64, 0, len(past.Nodes))
+
+	for _, nodeId := range past.Nodes {
+		if exists[nodeId] {
+			newChapter.Nodes = append(newChapter.Nodes, nodeId)
+		}
+	}
+
+	if exists[past.StartNode] {
+		newChapter.StartNode = past.StartNode
+	}
+
+	if newChapter.UpdatedAt == nil {
+		newChapter.UpdatedAt = make(map[time.Time]int64)
+	}
+
+	newChapter.UpdatedAt[time.Now()] = authorId
+
+	_, err = storage.UpdateChapter(db, chapter.Id, newChapter)
+
+	return err
+}
+
+// rollbackNode восстанавливает узел. Как и при обычном изменении, автор должен держать блокировку узла
+func rollbackNode(revision *models.Revision, authorId int64, db *gorm.DB) error {
+	var past models.Node
+
+	if err := json.Unmarshal(revision.Data, &past); err != nil {
+		return err
+	}
+
+	node, err := GetNode(revision.EntityId, db)
+
+	if err != nil {
+		return err
+	}
+
+	err = checkNodeLock(node.Id, authorId, db)
+
+	if err != nil {
+		return err
+	}
+
+	newNode := past
+	newNode.Id = node.Id
+	newNode.ChapterId = node.ChapterId
+	newNode.Version = node.Version
+
+	if newNode.Slug != node.Slug {
+		err = checkSlug(node.ChapterId, newNode.Slug, node.Id, db)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	// Персонажи, медиафайлы и переменные из ревизии могли быть удалены с тех пор,
+	// поэтому узел проверяется так же, как в UpdateNode
+	newNode.Events, err = assignEventIds(newNode.Events)
+
+	if err != nil {
+		return err
+	}
+
+	err = checkEvents(newNode, db)
+
+	if err != nil {
+		return err
+	}
+
+	err = checkBranching(node.ChapterId, newNode.Branching, db)
+
+	if err != nil {
+		return err
+	}
+
+	newNode.Branching.Choices, err = assignChoiceIds(newNode.Branching.Choices)
+
+	if err != nil {
+		return err
+	}
+
+	err = checkConditions(newNode, db)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = storage.UpdateNode(db, node.Id, newNode, authorId)
+
+	if err != nil {
+		return err
+	}
+
+	publishNodeUpdated(newNode)
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package chapter
+
+import (
+	"regexp"
+	"testing"
+	"time"
+	"vn/internal/models"
+	"vn/pkg/jsondiff"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func expectRevision(mock sqlmock.Sqlmock, id int64, entityType string, entityId int64, data string) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "revisions" WHERE id = $1`)).
+		WithArgs(id, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id", "chapter_id", "author", "data", "created_at"}).
+			AddRow(id, entityType, entityId, 1, 2, data, time.Now()))
+}
+
+func TestDiffRevisions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании моковой БД: %v", err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("ошибка при создании подключения к БД: %v", err)
+	}
+
+	expectRevision(mock, 10, models.RevisionNode, 5, `{"Slug":"start","Comment":""}`)
+	expectRevision(mock, 11, models.RevisionNode, 5, `{"Slug":"intro","Comment":""}`)
+
+	changes, err := DiffRevisions(10, 11, gormDB)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []jsondiff.Change{{Path: "Slug", Op: jsondiff.OpChanged, Old: "start", New: "intro"}}, changes)
+
+	expectRevision(mock, 10, models.RevisionNode, 5, `{}`)
+	expectRevision(mock, 12, models.RevisionChapter, 1, `{}`)
+
+	_, err = DiffRevisions(10, 12, gormDB)
+
+	assert.ErrorIs(t, err, ErrRevisionMismatch)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetRevisionsUnknownType(t *testing.T) {
+	_, err := GetRevisions("character", 1, nil)
+
+	assert.ErrorIs(t, err, ErrUnknownRevisionType)
+}
@@ -0,0 +1,137 @@
+package chapter
+
+import (
+	"gorm.io/gorm"
+	"vn/internal/models"
+	"vn/internal/services/screenplay"
+)
+
+// NodeScreenplay возвращает узел в виде текста сценария
+func NodeScreenplay(id int64, db *gorm.DB) (string, error) {
+	node, err := GetNode(id, db)
+
+	if err != nil {
+		return "", err
+	}
+
+	dict, err := screenplayDictionary(node.ChapterId, db)
+
+	if err != nil {
+		return "", err
+	}
+
+	return screenplay.Format(*node, dict), nil
+}
+
+// UpdateNodeScreenplay заменяет события, переходы, концовку, музыку, фон и комментарий
+// узла разобранным текстом: строка @scene или #, убранная из сценария, очищает поле.
+// Проверки те же, что у UpdateNode: блокировка, версия, ссылки событий и переходов.
+// Возвращает новую версию узла
+func UpdateNodeScreenplay(id int64, text string, version int, adminId int64, db *gorm.DB) (int, error) {
+	node, err := GetNode(id, db)
+
+	if err != nil {
+		return 0, err
+	}
+
+	dict, err := screenplayDictionary(node.ChapterId, db)
+
+	if err != nil {
+		return 0, err
+	}
+
+	script, err := screenplay.Parse(text, dict)
+
+	if err != nil {
+		return 0, err
+	}
+
+	events := keepEventIds(node.Events, script.Events)
+	branching := script.Branching
+	branching.Choices = keepChoiceIds(node.Branching.Choices, script.Branching.Choices)
+
+	settings := nodeSettings{Music: script.Music, Background: script.Background, Comment: script.Comment, Replace: true}
+
+	return updateNode(id, "", events, settings, &branching, &script.End, version, adminId, db)
+}
+
+func screenplayDictionary(chapterId int64, db *gorm.DB) (*screenplay.Dictionary, error) {
+	_, nodes, characters, err := loadChapterContents(chapterId, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return screenplay.NewDictionary(characters, nodes), nil
+}
+
+// keepEventIds переносит id старых событий на разобранные, чтобы не терять переводы
+// и ссылки на события. Сначала совпадают события с тем же типом и текстом, затем
+// оставшиеся события того же типа по порядку, так исправленная реплика сохраняет id.
+// Остальные получат новые id
+func keepEventIds(old models.Events, parsed models.Events) models.Events {
+	used := make(map[int]bool, len(old))
+	events := make(models.Events, len(parsed))
+	copy(events, parsed)
+
+	for i := range events {
+		for j, event := range old {
+			if !used[j] && event.Type == events[i].Type && event.Text == events[i].Text {
+				events[i].Id = event.Id
+				used[j] = true
+				break
+			}
+		}
+	}
+
+	for i := range events {
+		if events[i].Id != 0 {
+			continue
+		}
+
+		for j, event := range old {
+			if !used[j] && event.Type == events[i].Type {
+				events[i].Id = event.Id
+				used[j] = true
+				break
+			}
+		}
+	}
+
+	return events
+}
+
+// keepChoiceIds переносит id старых вариантов выбора на разобранные, как keepEventIds.
+// Сначала совпадают варианты с тем же переходом и текстом, затем оставшиеся с тем же
+// переходом по порядку
+func keepChoiceIds(old []models.Choice, parsed []models.Choice) []models.Choice {
+	used := make(map[int]bool, len(old))
+	choices := make([]models.Choice, len(parsed))
+	copy(choices, parsed)
+
+	for i := range choices {
+		for j, choice := range old {
+			if !used[j] && choice.Id != 0 && choice.NextNode == choices[i].NextNode && choice.Text == choices[i].Text {
+				choices[i].Id = choice.Id
+				used[j] = true
+				break
+			}
+		}
+	}
+
+	for i := range choices {
+		if choices[i].Id != 0 {
+			continue
+		}
+
+		for j, choice := range old {
+			if !used[j] && choice.Id != 0 && choice.NextNode == choices[i].NextNode {
+				choices[i].Id = choice.Id
+				used[j] = true
+				break
+			}
+		}
+	}
+
+	return choices
+}
@@ -0,0 +1,46 @@
+package chapter
+
+import (
+	"testing"
+	"vn/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeepEventIds(t *testing.T) {
+	old := models.Events{
+		{Id: 11, Type: models.EventNarration, Text: "Утро"},
+		{Id: 12, Type: models.EventSpeech, Character: 5, Text: "Привет"},
+		{Id: 13, Type: models.EventWait},
+	}
+
+	parsed := models.Events{
+		{Type: models.EventSpeech, Character: 5, Text: "Привет"}, // переехала в начало
+		{Type: models.EventNarration, Text: "Утро, дождь"},       // текст поправлен
+		{Type: models.EventWait},
+		{Type: models.EventWait}, // новое событие
+	}
+
+	events := keepEventIds(old, parsed)
+
+	assert.Equal(t, []int64{12, 11, 13, 0}, []int64{events[0].Id, events[1].Id, events[2].Id, events[3].Id})
+	assert.Equal(t, int64(0), parsed[0].Id)
+}
+
+func TestKeepChoiceIds(t *testing.T) {
+	old := []models.Choice{
+		{Id: 21, Text: "Налево", NextNode: 1},
+		{Id: 22, Text: "Направо", NextNode: 2},
+	}
+
+	parsed := []models.Choice{
+		{Text: "Направо", NextNode: 2},       // переставлен
+		{Text: "Налево, в лес", NextNode: 1}, // текст поправлен
+		{Text: "Назад", NextNode: 3},         // новый вариант
+	}
+
+	choices := keepChoiceIds(old, parsed)
+
+	assert.Equal(t, []int64{22, 21, 0}, []int64{choices[0].Id, choices[1].Id, choices[2].Id})
+	assert.Equal(t, int64(0), parsed[0].Id)
+}
@@ -0,0 +1,31 @@
+package chapter
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"strconv"
+	"vn/internal/storage"
+)
+
+var ErrSlugTaken = errors.New("slug is already used in chapter")
+
+// defaultSlug - slug для узла, созданного без slug. Зависит от id узла,
+// поэтому не совпадает с другими узлами главы
+func defaultSlug(nodeId int64) string {
+	return "node-" + strconv.FormatInt(nodeId, 36)
+}
+
+// checkSlug проверяет, что slug не занят другим узлом главы
+func checkSlug(chapterId int64, slug string, nodeId int64, db *gorm.DB) error {
+	node, err := storage.SelectNodeWithSlug(db, chapterId, slug)
+
+	if err != nil {
+		return err
+	}
+
+	if node != nil && node.Id != nodeId {
+		return ErrSlugTaken
+	}
+
+	return nil
+}
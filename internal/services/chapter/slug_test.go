@@ -0,0 +1,46 @@
+package chapter
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestDefaultSlug(t *testing.T) {
+	assert.Equal(t, "node-z", defaultSlug(35))
+	assert.NotEqual(t, defaultSlug(1), defaultSlug(2))
+}
+
+func TestCheckSlug(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании моковой БД: %v", err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("ошибка при создании подключения к БД: %v", err)
+	}
+
+	columns := []string{"id", "slug", "chapter_id", "music", "background", "events_raw", "branching_raw", "end_raw", "comment", "version"}
+	query := regexp.QuoteMeta(`WHERE chapter_id = $1 AND slug = $2 LIMIT 1`)
+
+	mock.ExpectQuery(query).WithArgs(int64(1), "intro").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(10, "intro", 1, 1, 1, "[]", "{}", "{}", "", 1))
+	assert.ErrorIs(t, checkSlug(1, "intro", 11, gormDB), ErrSlugTaken)
+
+	mock.ExpectQuery(query).WithArgs(int64(1), "intro").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(10, "intro", 1, 1, 1, "[]", "{}", "{}", "", 1))
+	assert.NoError(t, checkSlug(1, "intro", 10, gormDB))
+
+	mock.ExpectQuery(query).WithArgs(int64(1), "outro").
+		WillReturnRows(sqlmock.NewRows(columns))
+	assert.NoError(t, checkSlug(1, "outro", 11, gormDB))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
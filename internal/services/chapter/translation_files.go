@@ -0,0 +1,234 @@
+package chapter
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+	"vn/internal/models"
+	"vn/internal/storage"
+	"vn/pkg/l10n"
+)
+
+const (
+	TranslationPO    = "po"
+	TranslationXLIFF = "xliff"
+)
+
+var (
+	ErrUnknownTranslationFormat = errors.New("unknown translation format")
+	ErrLocaleMismatch           = errors.New("translation file is for another locale")
+)
+
+// TranslationReport - итог загрузки файла перевода
+type TranslationReport struct {
+	Saved   int      // сохранено переводов
+	Skipped int      // строки без перевода или помеченные как непроверенные
+	Unknown []string // ключи, которых нет в главе
+}
+
+// ExportTranslations выгружает строки главы с текущими переводами на locale в PO или XLIFF.
+// Переводы, сделанные для прежнего исходного текста, помечаются как устаревшие
+func ExportTranslations(chapterId int64, locale string, format string, db *gorm.DB) ([]byte, error) {
+	if format != TranslationPO && format != TranslationXLIFF {
+		return nil, ErrUnknownTranslationFormat
+	}
+
+	locale, err := translationLocale(locale)
+
+	if err != nil {
+		return nil, err
+	}
+
+	strs, err := chapterSourceStrings(chapterId, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	translations, err := storage.SelectTranslations(db, []string{locale}, []int64{0, chapterId})
+
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[int64]map[string]models.Translation)
+
+	for _, translation := range translations {
+		if existing[translation.ChapterId] == nil {
+			existing[translation.ChapterId] = map[string]models.Translation{}
+		}
+
+		existing[translation.ChapterId][translation.Key] = translation
+	}
+
+	file := l10n.File{Locale: locale, Units: make([]l10n.Unit, 0, len(strs))}
+
+	for _, str := range strs {
+		unit := l10n.Unit{Key: str.key, Source: str.text, Note: str.note}
+
+		if translation, ok := existing[str.chapterId][str.key]; ok {
+			unit.Target = translation.Text
+			unit.Fuzzy = translation.Source != str.text
+		}
+
+		file.Units = append(file.Units, unit)
+	}
+
+	if format == TranslationPO {
+		return l10n.WritePO(file), nil
+	}
+
+	return l10n.WriteXLIFF(file, SourceLocale, fmt.Sprintf("chapter_%d", chapterId))
+}
+
+// ImportTranslations сохраняет переводы из файла PO или XLIFF. Непереведенные
+// и непроверенные (fuzzy) строки пропускаются
+func ImportTranslations(chapterId int64, locale string, format string, data []byte, db *gorm.DB) (*TranslationReport, error) {
+	locale, err := translationLocale(locale)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var file *l10n.File
+
+	switch format {
+	case TranslationPO:
+		file, err = l10n.ReadPO(data)
+	case TranslationXLIFF:
+		file, err = l10n.ReadXLIFF(data)
+	default:
+		return nil, ErrUnknownTranslationFormat
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if file.Locale != "" {
+		fileLocale, err := normalizeLocale(file.Locale)
+
+		if err != nil || fileLocale != locale {
+			return nil, fmt.Errorf("%w: %q", ErrLocaleMismatch, file.Locale)
+		}
+	}
+
+	strs, err := chapterSourceStrings(chapterId, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]sourceString, len(strs))
+
+	for _, str := range strs {
+		known[str.key] = str
+	}
+
+	report := &TranslationReport{Unknown: []string{}}
+	translations := make([]models.Translation, 0, len(file.Units))
+	now := time.Now()
+
+	for _, unit := range file.Units {
+		str, ok := known[unit.Key]
+
+		if !ok {
+			report.Unknown = append(report.Unknown, unit.Key)
+			continue
+		}
+
+		if unit.Target == "" || unit.Fuzzy {
+			report.Skipped++
+			continue
+		}
+
+		// Исходный текст из файла - тот, что видел переводчик
+		source := unit.Source
+
+		if source == "" {
+			source = str.text
+		}
+
+		translations = append(translations, models.Translation{
+			Locale:    locale,
+			ChapterId: str.chapterId,
+			Key:       unit.Key,
+			Source:    source,
+			Text:      unit.Target,
+			UpdatedAt: now,
+		})
+	}
+
+	if err = storage.SaveTranslations(db, translations); err != nil {
+		return nil, err
+	}
+
+	report.Saved = len(translations)
+
+	return report, nil
+}
+
+// translationLocale проверяет язык перевода, исходный язык переводить нельзя
+func translationLocale(locale string) (string, error) {
+	locale, err := normalizeLocale(locale)
+
+	if err != nil {
+		return "", err
+	}
+
+	if isSourceLocale(locale) {
+		return "", fmt.Errorf("%w: %q is the source locale", ErrInvalidLocale, locale)
+	}
+
+	return locale, nil
+}
+
+func chapterSourceStrings(chapterId int64, db *gorm.DB) ([]sourceString, error) {
+	chapter, nodes, characters, err := loadChapterContents(chapterId, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return sourceStrings(chapter, nodes, characters), nil
+}
+
+// loadChapterContents загружает главу, ее узлы и персонажей в порядке списков главы
+func loadChapterContents(chapterId int64, db *gorm.DB) (models.Chapter, []models.Node, []models.Character, error) {
+	chapter, err := storage.SelectChapterWIthId(db, chapterId)
+
+	if err != nil {
+		return models.Chapter{}, nil, nil, err
+	}
+
+	loaded, err := loadChapterNodes(chapter, db)
+
+	if err != nil {
+		return models.Chapter{}, nil, nil, err
+	}
+
+	nodes := make([]models.Node, 0, len(loaded))
+
+	for _, nodeId := range chapter.Nodes {
+		if node, ok := loaded[nodeId]; ok {
+			nodes = append(nodes, node)
+		}
+	}
+
+	characters, err := storage.SelectCharactersWithIds(db, chapter.Characters)
+
+	if err != nil {
+		return models.Chapter{}, nil, nil, err
+	}
+
+	ordered := make([]models.Character, 0, len(characters))
+
+	for _, characterId := range chapter.Characters {
+		if character, ok := characters[characterId]; ok {
+			ordered = append(ordered, character)
+		}
+	}
+
+	return chapter, nodes, ordered, nil
+}
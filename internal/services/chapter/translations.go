@@ -0,0 +1,245 @@
+package chapter
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"regexp"
+	"strings"
+	"vn/internal/models"
+	"vn/internal/storage"
+)
+
+// SourceLocale - язык, на котором пишутся главы. Для него переводы не загружаются
+const SourceLocale = "ru"
+
+var ErrInvalidLocale = errors.New("invalid locale")
+
+var localePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}([_-][a-zA-Z0-9]{2,8})*$`)
+
+// Ключи переводимых строк
+func chapterNameKey() string {
+	return "chapter.name"
+}
+
+func eventTextKey(nodeId int64, eventId int64) string {
+	return fmt.Sprintf("node.%d.event.%d", nodeId, eventId)
+}
+
+// choiceTextKey - ключ варианта по его id. Варианты, сохраненные до появления id,
+// различаются только позицией в узле, пока узел не сохранят заново
+func choiceTextKey(nodeId int64, index int, choice models.Choice) string {
+	if choice.Id == 0 {
+		return fmt.Sprintf("node.%d.choice-at.%d", nodeId, index)
+	}
+
+	return fmt.Sprintf("node.%d.choice.%d", nodeId, choice.Id)
+}
+
+func endTextKey(nodeId int64) string {
+	return fmt.Sprintf("node.%d.end", nodeId)
+}
+
+func characterNameKey(characterId int64) string {
+	return fmt.Sprintf("character.%d.name", characterId)
+}
+
+// normalizeLocale приводит код языка к виду "pt-BR". Пустой код - исходный язык
+func normalizeLocale(locale string) (string, error) {
+	if locale == "" {
+		return "", nil
+	}
+
+	if !localePattern.MatchString(locale) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidLocale, locale)
+	}
+
+	parts := strings.FieldsFunc(locale, func(r rune) bool { return r == '-' || r == '_' })
+	parts[0] = strings.ToLower(parts[0])
+
+	for i := 1; i < len(parts); i++ {
+		if len(parts[i]) == 2 {
+			parts[i] = strings.ToUpper(parts[i])
+		}
+	}
+
+	return strings.Join(parts, "-"), nil
+}
+
+// isSourceLocale - перевод не нужен
+func isSourceLocale(locale string) bool {
+	return locale == "" || locale == SourceLocale || strings.HasPrefix(locale, SourceLocale+"-")
+}
+
+// Catalog - переводы для показа игроку. nil - показывать исходный текст
+type Catalog struct {
+	texts map[int64]map[string][]catalogEntry // id главы - ключ - переводы от регионального языка к общему
+}
+
+// catalogEntry - перевод и исходный текст, который переводили
+type catalogEntry struct {
+	source string
+	text   string
+}
+
+// LoadCatalog загружает переводы глав и имен персонажей. Для "pt-BR" недостающие
+// и устаревшие строки берутся из "pt", а затем из исходного текста
+func LoadCatalog(locale string, chapterIds []int64, db *gorm.DB) (*Catalog, error) {
+	locale, err := normalizeLocale(locale)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if isSourceLocale(locale) {
+		return nil, nil
+	}
+
+	locales := []string{locale}
+
+	if base, _, ok := strings.Cut(locale, "-"); ok {
+		locales = append(locales, base)
+	}
+
+	translations, err := storage.SelectTranslations(db, locales, append([]int64{0}, chapterIds...))
+
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := &Catalog{texts: map[int64]map[string][]catalogEntry{}}
+
+	// Сначала региональный язык, затем общий
+	for _, locale := range locales {
+		for _, translation := range translations {
+			if translation.Locale != locale || translation.Text == "" {
+				continue
+			}
+
+			if catalog.texts[translation.ChapterId] == nil {
+				catalog.texts[translation.ChapterId] = map[string][]catalogEntry{}
+			}
+
+			entries := catalog.texts[translation.ChapterId]
+			entries[translation.Key] = append(entries[translation.Key], catalogEntry{source: translation.Source, text: translation.Text})
+		}
+	}
+
+	return catalog, nil
+}
+
+// text возвращает перевод строки. Перевод устарел, если исходный текст изменился
+// после перевода, тогда берется перевод общего языка или исходный текст
+func (c *Catalog) text(chapterId int64, key string, source string) string {
+	if c == nil {
+		return source
+	}
+
+	for _, entry := range c.texts[chapterId][key] {
+		if entry.source == source {
+			return entry.text
+		}
+	}
+
+	return source
+}
+
+// Chapter возвращает главу с переведенным названием
+func (c *Catalog) Chapter(chapter models.Chapter) models.Chapter {
+	chapter.Name = c.text(chapter.Id, chapterNameKey(), chapter.Name)
+	return chapter
+}
+
+// Node возвращает копию узла с переведенными репликами, вариантами и текстом концовки
+func (c *Catalog) Node(node models.Node) models.Node {
+	if c == nil {
+		return node
+	}
+
+	events := make(models.Events, len(node.Events))
+
+	for i, event := range node.Events {
+		if event.Text != "" {
+			event.Text = c.text(node.ChapterId, eventTextKey(node.Id, event.Id), event.Text)
+		}
+
+		events[i] = event
+	}
+
+	node.Events = events
+
+	choices := make([]models.Choice, len(node.Branching.Choices))
+
+	for i, choice := range node.Branching.Choices {
+		if choice.Text != "" {
+			choice.Text = c.text(node.ChapterId, choiceTextKey(node.Id, i, choice), choice.Text)
+		}
+
+		choices[i] = choice
+	}
+
+	node.Branching.Choices = choices
+
+	if node.End.EndText != "" {
+		node.End.EndText = c.text(node.ChapterId, endTextKey(node.Id), node.End.EndText)
+	}
+
+	return node
+}
+
+// Character возвращает персонажа с переведенным именем
+func (c *Catalog) Character(character models.Character) models.Character {
+	character.Name = c.text(0, characterNameKey(character.Id), character.Name)
+	return character
+}
+
+// sourceString - переводимая строка главы
+type sourceString struct {
+	chapterId int64 // 0 - имя персонажа
+	key       string
+	text      string
+	note      string // подсказка переводчику: slug узла или говорящий
+}
+
+// sourceStrings собирает все строки главы, которые видит игрок
+func sourceStrings(chapter models.Chapter, nodes []models.Node, characters []models.Character) []sourceString {
+	names := make(map[int64]string, len(characters))
+
+	for _, character := range characters {
+		names[character.Id] = character.Name
+	}
+
+	strs := []sourceString{{chapterId: chapter.Id, key: chapterNameKey(), text: chapter.Name, note: "название главы"}}
+
+	for _, character := range characters {
+		strs = append(strs, sourceString{key: characterNameKey(character.Id), text: character.Name, note: "имя персонажа " + character.Slug})
+	}
+
+	for _, node := range nodes {
+		for _, event := range node.Events {
+			if event.Text == "" {
+				continue
+			}
+
+			note := node.Slug
+
+			if name, ok := names[event.Character]; ok && event.Type == models.EventSpeech {
+				note += ", говорит " + name
+			}
+
+			strs = append(strs, sourceString{chapterId: chapter.Id, key: eventTextKey(node.Id, event.Id), text: event.Text, note: note})
+		}
+
+		for i, choice := range node.Branching.Choices {
+			if choice.Text != "" {
+				strs = append(strs, sourceString{chapterId: chapter.Id, key: choiceTextKey(node.Id, i, choice), text: choice.Text, note: node.Slug + ", вариант выбора"})
+			}
+		}
+
+		if node.End.EndText != "" {
+			strs = append(strs, sourceString{chapterId: chapter.Id, key: endTextKey(node.Id), text: node.End.EndText, note: node.Slug + ", концовка"})
+		}
+	}
+
+	return strs
+}
@@ -0,0 +1,170 @@
+package chapter
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"vn/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	tests := []struct {
+		name    string
+		locale  string
+		want    string
+		wantErr bool
+	}{
+		{name: "Пустой код", locale: "", want: ""},
+		{name: "Язык", locale: "EN", want: "en"},
+		{name: "Язык и регион", locale: "pt_br", want: "pt-BR"},
+		{name: "Письменность", locale: "zh-Hant", want: "zh-Hant"},
+		{name: "Недопустимые символы", locale: "en;drop", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeLocale(tt.locale)
+
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, ErrInvalidLocale))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCatalogNode(t *testing.T) {
+	node := models.Node{
+		Id:        10,
+		ChapterId: 1,
+		Events:    models.Events{{Id: 1, Text: "Привет"}, {Id: 2, Text: "Пока"}},
+		Branching: models.Branching{Flag: true, Choices: []models.Choice{{Id: 31, Text: "Налево"}, {Id: 32, Text: "Направо"}}},
+		End:       models.EndInfo{EndText: "Конец"},
+	}
+
+	catalog := &Catalog{texts: map[int64]map[string][]catalogEntry{
+		1: {
+			"node.10.event.1":   {{source: "Привет", text: "Hello"}},
+			"node.10.event.2":   {{source: "До встречи", text: "See you"}}, // реплику изменили после перевода
+			"node.10.choice.32": {{source: "Направо", text: "Right"}},
+			"node.10.end":       {{source: "Конец", text: "The end"}},
+		},
+	}}
+
+	got := catalog.Node(node)
+
+	assert.Equal(t, "Hello", got.Events[0].Text)
+	assert.Equal(t, "Пока", got.Events[1].Text)
+	assert.Equal(t, "Налево", got.Branching.Choices[0].Text)
+	assert.Equal(t, "Right", got.Branching.Choices[1].Text)
+	assert.Equal(t, "The end", got.End.EndText)
+
+	// Перевод варианта следует за его id, а не за позицией
+	node.Branching.Choices[0], node.Branching.Choices[1] = node.Branching.Choices[1], node.Branching.Choices[0]
+	got = catalog.Node(node)
+
+	assert.Equal(t, "Right", got.Branching.Choices[0].Text)
+	assert.Equal(t, "Налево", got.Branching.Choices[1].Text)
+	node.Branching.Choices[0], node.Branching.Choices[1] = node.Branching.Choices[1], node.Branching.Choices[0]
+
+	// Исходный узел не меняется
+	assert.Equal(t, "Привет", node.Events[0].Text)
+	assert.Equal(t, "Направо", node.Branching.Choices[1].Text)
+
+	var empty *Catalog
+	assert.Equal(t, node, empty.Node(node))
+}
+
+func TestLoadCatalogFallback(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании моковой БД: %v", err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("ошибка при создании подключения к БД: %v", err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "translations" WHERE locale IN ($1,$2) AND chapter_id IN ($3,$4)`)).
+		WithArgs("pt-BR", "pt", 0, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "locale", "chapter_id", "key", "source", "text"}).
+			AddRow(1, "pt-BR", 1, "chapter.name", "Глава", "Capítulo BR").
+			AddRow(2, "pt", 1, "chapter.name", "Глава", "Capítulo").
+			AddRow(3, "pt", 0, "character.5.name", "Анна", "Ana").
+			// Региональный перевод устарел, общий сделан с текущего имени
+			AddRow(4, "pt-BR", 0, "character.7.name", "Вера", "Vera BR").
+			AddRow(5, "pt", 0, "character.7.name", "Вероника", "Verônica"))
+
+	catalog, err := LoadCatalog("pt_BR", []int64{1}, gormDB)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Capítulo BR", catalog.Chapter(models.Chapter{Id: 1, Name: "Глава"}).Name)
+	assert.Equal(t, "Ana", catalog.Character(models.Character{Id: 5, Name: "Анна"}).Name)
+	assert.Equal(t, "Verônica", catalog.Character(models.Character{Id: 7, Name: "Вероника"}).Name)
+	// Имя изменили после перевода, показывается исходное
+	assert.Equal(t, "Анна-Мария", catalog.Character(models.Character{Id: 5, Name: "Анна-Мария"}).Name)
+	assert.Equal(t, "Борис", catalog.Character(models.Character{Id: 6, Name: "Борис"}).Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoadCatalogSourceLocale(t *testing.T) {
+	// Для исходного языка база не запрашивается
+	catalog, err := LoadCatalog("ru", []int64{1}, nil)
+
+	assert.NoError(t, err)
+	assert.Nil(t, catalog)
+}
+
+func TestSourceStrings(t *testing.T) {
+	chapter := models.Chapter{Id: 1, Name: "Глава"}
+	nodes := []models.Node{{
+		Id:        10,
+		Slug:      "start",
+		Events:    models.Events{{Id: 1, Type: models.EventSpeech, Character: 5, Text: "Привет"}, {Id: 2}},
+		Branching: models.Branching{Choices: []models.Choice{{Id: 31, NextNode: 11}, {Id: 32, Text: "Уйти", NextNode: 12}, {Text: "Старый", NextNode: 12}}},
+	}}
+	characters := []models.Character{{Id: 5, Name: "Анна", Slug: "anna"}}
+
+	strs := sourceStrings(chapter, nodes, characters)
+
+	var keys []string
+	for _, str := range strs {
+		keys = append(keys, str.key)
+	}
+
+	assert.Equal(t, []string{"chapter.name", "character.5.name", "node.10.event.1", "node.10.choice.32", "node.10.choice-at.2"}, keys)
+	assert.Equal(t, int64(0), strs[1].chapterId)
+	assert.Equal(t, "start, говорит Анна", strs[2].note)
+}
+
+func TestImportTranslationsErrors(t *testing.T) {
+	po := []byte("msgid \"\"\nmsgstr \"\"\n\"Language: de\\n\"\n\nmsgctxt \"chapter.name\"\nmsgid \"Глава\"\nmsgstr \"Kapitel\"\n")
+
+	tests := []struct {
+		name    string
+		locale  string
+		format  string
+		wantErr error
+	}{
+		{name: "Исходный язык", locale: "ru", format: TranslationPO, wantErr: ErrInvalidLocale},
+		{name: "Неизвестный формат", locale: "de", format: "csv", wantErr: ErrUnknownTranslationFormat},
+		{name: "Файл для другого языка", locale: "fr", format: TranslationPO, wantErr: ErrLocaleMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ImportTranslations(1, tt.locale, tt.format, po, nil)
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+		})
+	}
+}
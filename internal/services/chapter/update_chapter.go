@@ -3,6 +3,7 @@ package chapter
 import (
 	"gorm.io/gorm"
 	"time"
+	"vn/internal/services/live"
 	"vn/internal/storage"
 )
 
@@ -14,12 +15,19 @@ func UpdateChapter(
 	updateAuthorId int64,
 	startNode int64,
 	status int,
+	version int, // версия, которую видел клиент
 	db *gorm.DB,
-) error {
+) (int, error) {
 	chapter, err := storage.SelectChapterWIthId(db, id)
 
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	err = checkChapterVersion(chapter, version)
+
+	if err != nil {
+		return 0, err
 	}
 
 	newChapter := chapter
@@ -50,7 +58,43 @@ func UpdateChapter(
 		newChapter.Status = status
 	}
 
+	// При публикации глава сохраняется вместе со снимком версии
+	if chapter.Status != PublishedStatus && newChapter.Status == PublishedStatus {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			_, err := storage.UpdateChapter(tx, id, newChapter)
+
+			if err != nil {
+				return err
+			}
+
+			_, err = PublishVersion(id, tx)
+
+			return err
+		})
+
+		if err != nil {
+			return 0, chapterConflict(id, err, db)
+		}
+
+		publishStatusChanged(id, newChapter.Status)
+
+		return chapter.Version + 1, nil
+	}
+
 	_, err = storage.UpdateChapter(db, id, newChapter)
 
-	return err
+	if err != nil {
+		return 0, chapterConflict(id, err, db)
+	}
+
+	if newChapter.Status != chapter.Status {
+		publishStatusChanged(id, newChapter.Status)
+	}
+
+	return chapter.Version + 1, nil
+}
+
+// publishStatusChanged сообщает редакторам главы о смене статуса
+func publishStatusChanged(chapterId int64, status int) {
+	live.Publish(live.Event{Type: live.ChapterStatusChanged, ChapterId: chapterId, Status: status})
 }
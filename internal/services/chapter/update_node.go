@@ -0,0 +1,177 @@
+package chapter
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"vn/internal/models"
+	"vn/internal/services/live"
+	"vn/internal/storage"
+)
+
+// nodeSettings - музыка, фон и комментарий узла. Пустые значения оставляют текущие,
+// если не задан Replace
+type nodeSettings struct {
+	Music      int64
+	Background int64
+	Comment    string
+	Replace    bool // пустые значения очищают поля, как при замене узла сценарием
+}
+
+// UpdateNode сохраняет изменения узла и возвращает его новую версию
+func UpdateNode(
+	id int64,
+	slug string,
+	events models.Events,
+	music int64,
+	background int64,
+	branching *models.Branching,
+	end *models.EndInfo,
+	comment string,
+	version int, // версия, которую видел клиент
+	adminId int64, // админ, который должен держать блокировку узла
+	db *gorm.DB,
+) (int, error) {
+	settings := nodeSettings{Music: music, Background: background, Comment: comment}
+
+	return updateNode(id, slug, events, settings, branching, end, version, adminId, db)
+}
+
+func updateNode(
+	id int64,
+	slug string,
+	events models.Events,
+	settings nodeSettings,
+	branching *models.Branching,
+	end *models.EndInfo,
+	version int,
+	adminId int64,
+	db *gorm.DB,
+) (int, error) {
+	node, err := GetNode(id, db)
+
+	if err != nil {
+		return 0, err
+	}
+
+	err = checkNodeLock(id, adminId, db)
+
+	if err != nil {
+		return 0, err
+	}
+
+	err = checkNodeVersion(*node, version)
+
+	if err != nil {
+		return 0, err
+	}
+
+	newNode := *node
+
+	if slug != "" && slug != node.Slug {
+		err = checkSlug(node.ChapterId, slug, id, db)
+
+		if err != nil {
+			return 0, err
+		}
+
+		newNode.Slug = slug
+	}
+
+	if events != nil {
+		newNode.Events, err = assignEventIds(events)
+
+		if err != nil {
+			return 0, err
+		}
+
+		err = checkEvents(newNode, db)
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if settings.Replace || settings.Music != 0 {
+		newNode.Music = settings.Music
+	}
+
+	if settings.Replace || settings.Background != 0 {
+		newNode.Background = settings.Background
+	}
+
+	if branching != nil {
+		err = checkBranching(newNode.ChapterId, *branching, db)
+
+		if err != nil {
+			return 0, err
+		}
+
+		newNode.Branching = *branching
+		newNode.Branching.Choices, err = assignChoiceIds(branching.Choices)
+
+		if err != nil {
+			return 0, err
+		}
+
+		err = checkConditions(newNode, db)
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if end != nil {
+		newNode.End = *end
+	}
+
+	if settings.Replace || settings.Comment != "" {
+		newNode.Comment = settings.Comment
+	}
+
+	_, err = storage.UpdateNode(db, id, newNode, adminId)
+
+	if err != nil {
+		return 0, nodeConflict(id, err, db)
+	}
+
+	newNode.Version++
+	publishNodeUpdated(newNode)
+
+	return newNode.Version, nil
+}
+
+// publishNodeUpdated сообщает редакторам главы об изменении узла
+func publishNodeUpdated(node models.Node) {
+	live.Publish(live.Event{Type: live.NodeUpdated, ChapterId: node.ChapterId, NodeId: node.Id})
+}
+
+// checkBranching проверяет, что варианты выбора ведут в узлы из списка узлов главы
+func checkBranching(chapterId int64, branching models.Branching, db *gorm.DB) error {
+	if len(branching.Choices) == 0 {
+		return nil
+	}
+
+	chapter, err := storage.SelectChapterWIthId(db, chapterId)
+
+	if err != nil {
+		return err
+	}
+
+	nodes := make(map[int64]bool, len(chapter.Nodes))
+
+	for _, nodeId := range chapter.Nodes {
+		nodes[nodeId] = true
+	}
+
+	for i, choice := range branching.Choices {
+		if choice.NextNode == 0 {
+			return fmt.Errorf("choice %d has no target node", i)
+		}
+
+		if !nodes[choice.NextNode] {
+			return fmt.Errorf("choice %d leads to node %d outside of chapter %d", i, choice.NextNode, chapterId)
+		}
+	}
+
+	return nil
+}
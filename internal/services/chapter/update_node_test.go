@@ -1,7 +1,9 @@
 package chapter
 
 import (
+	"database/sql/driver"
 	"encoding/json"
+	"errors"
 	"gorm.io/gorm/logger"
 	"regexp"
 	"testing"
@@ -45,6 +47,7 @@ func TestUpdateChapter(t *testing.T) {
 		Status:     3,
 		UpdatedAt:  map[time.Time]int64{time.Now(): 1},
 		Author:     1,
+		Version:    4,
 	}
 
 	// Тестовый случай
@@ -57,6 +60,8 @@ func TestUpdateChapter(t *testing.T) {
 		updateAuthorId    int64
 		startNode         int64
 		status            int
+		version           int
+		wantConflict      bool
 		wantErr           bool
 		expectedUpdatedAt map[time.Time]int64
 	}{
@@ -69,14 +74,26 @@ func TestUpdateChapter(t *testing.T) {
 			updateAuthorId:    2,
 			startNode:         2,
 			status:            2,
+			version:           4,
 			wantErr:           false,
 			expectedUpdatedAt: map[time.Time]int64{time.Now(): 2},
 		},
+		{
+			label:        "Глава изменена другим автором",
+			id:           testChapterId,
+			name:         "Устаревшее название",
+			nodes:        []int64{4, 5, 6},
+			characters:   []int64{3, 4},
+			status:       2,
+			version:      3,
+			wantConflict: true,
+			wantErr:      true,
+		},
 	}
 
 	// Запускаем каждый тестовый случай
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
+		t.Run(tt.label, func(t *testing.T) {
 			// Очищаем все ожидания
 			if err := mock.ExpectationsWereMet(); err != nil {
 				t.Errorf("there were unfulfilled expectations: %s", err)
@@ -104,15 +121,30 @@ func TestUpdateChapter(t *testing.T) {
 			gormDB = gormDB.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Info)})
 
 			// Ожидаем успешный поиск главы
-			mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, start_node, CAST(nodes AS TEXT) as nodes_raw, CAST(characters AS TEXT) as characters_raw, status, CAST(updated_at AS TEXT) as updated_at_raw, author FROM chapters WHERE id = $1 LIMIT 1`)).
-				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author"}).
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, start_node, CAST(nodes AS TEXT) as nodes_raw, CAST(characters AS TEXT) as characters_raw, status, CAST(updated_at AS TEXT) as updated_at_raw, author, version FROM chapters WHERE id = $1 LIMIT 1`)).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}).
 					AddRow(testChapter.Id, testChapter.Name, testChapter.StartNode,
 						string(nodesJSON), string(charactersJSON),
-						testChapter.Status, string(updatedAtJSON), testChapter.Author))
+						testChapter.Status, string(updatedAtJSON), testChapter.Author, testChapter.Version))
+
+			// Устаревшая версия отклоняется до записи
+			if tt.wantConflict {
+				_, err = UpdateChapter(tt.id, tt.name, tt.nodes, tt.characters, tt.updateAuthorId, tt.startNode, tt.status, tt.version, gormDB)
+
+				var conflictErr *ChapterConflictError
+				if !errors.As(err, &conflictErr) || conflictErr.Current.Version != testChapter.Version {
+					t.Errorf("UpdateChapter() ошибка = %v, хотим конфликт версий", err)
+				}
+
+				if err := mock.ExpectationsWereMet(); err != nil {
+					t.Errorf("не все ожидания были выполнены: %s", err)
+				}
+				return
+			}
 
 			// Ожидаем обновление записи
 			mock.ExpectBegin()
-			mock.ExpectExec(regexp.QuoteMeta(`UPDATE "chapters" SET "characters"=$1,"nodes"=$2,"start_node"=$3,"status"=$4,"updated_at"=$5,"name"=$6 WHERE id = $7`)).
+			mock.ExpectExec(regexp.QuoteMeta(`UPDATE "chapters" SET "characters"=$1,"nodes"=$2,"start_node"=$3,"status"=$4,"updated_at"=$5,"version"=version + 1,"name"=$6 WHERE id = $7 AND version = $8`)).
 				WithArgs(
 					json.RawMessage(string(charactersJSON)),
 					json.RawMessage(string(nodesJSON)),
@@ -121,12 +153,17 @@ func TestUpdateChapter(t *testing.T) {
 					sqlmock.AnyArg(),
 					tt.name,
 					testChapterId,
+					testChapter.Version,
 				).
 				WillReturnResult(sqlmock.NewResult(1, 1))
+			// Ожидаем сохранение ревизии
+			mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "revisions" ("entity_type","entity_id","chapter_id","author","data","created_at") VALUES ($1,$2,$3,$4,$5,$6) RETURNING "id"`)).
+				WithArgs(models.RevisionChapter, testChapterId, testChapterId, tt.updateAuthorId, sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 			mock.ExpectCommit()
 
 			// Выполняем тестируемую функцию
-			err = UpdateChapter(
+			version, err := UpdateChapter(
 				tt.id,
 				tt.name,
 				tt.nodes,
@@ -134,6 +171,7 @@ func TestUpdateChapter(t *testing.T) {
 				tt.updateAuthorId,
 				tt.startNode,
 				tt.status,
+				tt.version,
 				gormDB,
 			)
 
@@ -143,6 +181,10 @@ func TestUpdateChapter(t *testing.T) {
 				return
 			}
 
+			if version != testChapter.Version+1 {
+				t.Errorf("UpdateChapter() версия = %d, ожидаемая %d", version, testChapter.Version+1)
+			}
+
 			// Проверяем, что все ожидаемые запросы были выполнены
 			if err := mock.ExpectationsWereMet(); err != nil {
 				t.Errorf("не все ожидания были выполнены: %s", err)
@@ -150,3 +192,151 @@ func TestUpdateChapter(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateNodeRecordsAuthor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании моковой БД: %v", err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("ошибка при создании подключения к БД: %v", err)
+	}
+
+	const adminId = 7
+
+	mock.ExpectQuery(`FROM nodes\s+WHERE id = \$1 LIMIT 1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug", "chapter_id", "music", "background", "events_raw", "branching_raw", "end_raw", "comment", "version"}).
+			AddRow(10, "start", 5, 1, 2, "[]", "{}", "{}", "", 3))
+	mock.ExpectQuery(`FROM "node_locks"`).
+		WillReturnRows(sqlmock.NewRows([]string{"node_id", "admin_id", "expires_at"}).AddRow(10, adminId, time.Now().Add(time.Minute)))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "nodes" SET .* WHERE id = \$\d+ AND version = \$\d+`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "revisions"`)).
+		WithArgs(models.RevisionNode, 10, 5, adminId, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	version, err := UpdateNode(10, "", nil, 0, 0, nil, nil, "новый комментарий", 3, adminId, gormDB)
+
+	if err != nil {
+		t.Fatalf("UpdateNode() ошибка = %v", err)
+	}
+
+	if version != 4 {
+		t.Errorf("UpdateNode() версия = %d, ожидаемая 4", version)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("не все ожидания были выполнены: %s", err)
+	}
+}
+
+func TestUpdateNodeSettings(t *testing.T) {
+	tests := []struct {
+		label    string
+		settings nodeSettings
+		want     models.Node
+	}{
+		{
+			label:    "пустые значения оставляют текущие",
+			settings: nodeSettings{},
+			want:     models.Node{Music: 1, Background: 2, Comment: "старый"},
+		},
+		{
+			label:    "замена сценарием очищает поля",
+			settings: nodeSettings{Background: 4, Replace: true},
+			want:     models.Node{Background: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("ошибка при создании моковой БД: %v", err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatalf("ошибка при создании подключения к БД: %v", err)
+			}
+
+			mock.ExpectQuery(`FROM nodes\s+WHERE id = \$1 LIMIT 1`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "slug", "chapter_id", "music", "background", "events_raw", "branching_raw", "end_raw", "comment", "version"}).
+					AddRow(10, "start", 5, 1, 2, "[]", "{}", "{}", "старый", 3))
+			mock.ExpectQuery(`FROM "node_locks"`).
+				WillReturnRows(sqlmock.NewRows([]string{"node_id", "admin_id", "expires_at"}).AddRow(10, 7, time.Now().Add(time.Minute)))
+			mock.ExpectBegin()
+			mock.ExpectExec(`UPDATE "nodes" SET .* WHERE id = \$\d+ AND version = \$\d+`).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			// Ревизия хранит сохраненный узел целиком
+			mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "revisions"`)).
+				WithArgs(models.RevisionNode, 10, 5, 7, nodeWithSettings{tt.want}, sqlmock.AnyArg()).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			mock.ExpectCommit()
+
+			_, err = updateNode(10, "", nil, tt.settings, nil, nil, 3, 7, gormDB)
+
+			if err != nil {
+				t.Fatalf("updateNode() ошибка = %v", err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("не все ожидания были выполнены: %s", err)
+			}
+		})
+	}
+}
+
+// nodeWithSettings проверяет музыку, фон и комментарий сохраненного узла
+type nodeWithSettings struct {
+	want models.Node
+}
+
+func (m nodeWithSettings) Match(v driver.Value) bool {
+	var data []byte
+
+	switch value := v.(type) {
+	case []byte:
+		data = value
+	case string:
+		data = []byte(value)
+	default:
+		return false
+	}
+
+	var node models.Node
+
+	if err := json.Unmarshal(data, &node); err != nil {
+		return false
+	}
+
+	return node.Music == m.want.Music && node.Background == m.want.Background && node.Comment == m.want.Comment
+}
+
+func TestCheckVersion(t *testing.T) {
+	node := models.Node{Id: 10, Version: 3}
+
+	if err := checkNodeVersion(node, 0); !errors.Is(err, ErrVersionRequired) {
+		t.Errorf("checkNodeVersion() без версии = %v, хотим ErrVersionRequired", err)
+	}
+
+	var conflictErr *NodeConflictError
+
+	if err := checkNodeVersion(node, 2); !errors.As(err, &conflictErr) || conflictErr.Current.Version != 3 {
+		t.Errorf("checkNodeVersion() устаревшая версия = %v, хотим конфликт", err)
+	}
+
+	if err := checkNodeVersion(node, 3); err != nil {
+		t.Errorf("checkNodeVersion() текущая версия = %v", err)
+	}
+
+	if err := checkChapterVersion(models.Chapter{Id: 5, Version: 1}, 0); !errors.Is(err, ErrVersionRequired) {
+		t.Errorf("checkChapterVersion() без версии = %v, хотим ErrVersionRequired", err)
+	}
+}
@@ -0,0 +1,284 @@
+package chapter
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"sort"
+	"vn/internal/models"
+	"vn/internal/storage"
+)
+
+const (
+	IssueUnreachable = "unreachable"  // узел не достижим из начального
+	IssueDeadEnd     = "dead_end"     // из узла нет переходов, но он не помечен как концовка
+	IssueClosedCycle = "closed_cycle" // цикл, из которого нельзя выйти
+	IssueMissingNode = "missing_node" // ссылка на узел, которого нет в главе
+)
+
+type GraphIssue struct {
+	Type    string  `json:"type"`
+	NodeId  int64   `json:"node_id"`
+	Target  int64   `json:"target,omitempty"`
+	Nodes   []int64 `json:"nodes,omitempty"`
+	Message string  `json:"message"`
+}
+
+type GraphReport struct {
+	ChapterId int64        `json:"chapter_id"`
+	Errors    []GraphIssue `json:"errors"`
+}
+
+func (report GraphReport) Valid() bool {
+	return len(report.Errors) == 0
+}
+
+// GraphValidationError возвращается, когда граф главы не прошел проверку
+type GraphValidationError struct {
+	Report GraphReport
+}
+
+func (e *GraphValidationError) Error() string {
+	return fmt.Sprintf("chapter %d graph has %d errors", e.Report.ChapterId, len(e.Report.Errors))
+}
+
+// ValidateChapter загружает главу с узлами и проверяет ее граф
+func ValidateChapter(id int64, db *gorm.DB) (GraphReport, error) {
+	chapter, err := storage.SelectChapterWIthId(db, id)
+
+	if err != nil {
+		return GraphReport{}, err
+	}
+
+	nodes, err := loadChapterNodes(chapter, db)
+
+	if err != nil {
+		return GraphReport{}, err
+	}
+
+	return ValidateGraph(chapter, nodes), nil
+}
+
+// loadChapterNodes загружает узлы главы одним запросом. Узлы, которых нет
+// в списке главы, не учитываются
+func loadChapterNodes(chapter models.Chapter, db *gorm.DB) (map[int64]models.Node, error) {
+	loaded, err := storage.SelectNodesByChapterId(db, chapter.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	inChapter := make(map[int64]bool, len(chapter.Nodes))
+
+	for _, nodeId := range chapter.Nodes {
+		inChapter[nodeId] = true
+	}
+
+	nodes := make(map[int64]models.Node, len(chapter.Nodes))
+
+	for _, node := range loaded {
+		if inChapter[node.Id] {
+			nodes[node.Id] = node
+		}
+	}
+
+	return nodes, nil
+}
+
+// ValidateGraph обходит граф главы от StartNode по вариантам выбора.
+// nodes - загруженные узлы главы, ключ - id узла
+func ValidateGraph(chapter models.Chapter, nodes map[int64]models.Node) GraphReport {
+	report := GraphReport{ChapterId: chapter.Id, Errors: []GraphIssue{}}
+
+	inChapter := make(map[int64]bool, len(chapter.Nodes))
+
+	for _, nodeId := range chapter.Nodes {
+		inChapter[nodeId] = true
+
+		if _, ok := nodes[nodeId]; !ok {
+			report.Errors = append(report.Errors, GraphIssue{
+				Type:    IssueMissingNode,
+				NodeId:  nodeId,
+				Message: fmt.Sprintf("node %d is listed in chapter but does not exist", nodeId),
+			})
+		}
+	}
+
+	exists := func(nodeId int64) bool {
+		_, ok := nodes[nodeId]
+		return inChapter[nodeId] && ok
+	}
+
+	if !exists(chapter.StartNode) {
+		report.Errors = append(report.Errors, GraphIssue{
+			Type:    IssueMissingNode,
+			Target:  chapter.StartNode,
+			Message: fmt.Sprintf("start node %d is not in chapter", chapter.StartNode),
+		})
+
+		return report
+	}
+
+	// Обход в ширину от начального узла
+	visited := map[int64]bool{chapter.StartNode: true}
+	queue := []int64{chapter.StartNode}
+
+	for len(queue) > 0 {
+		nodeId := queue[0]
+		queue = queue[1:]
+
+		node := nodes[nodeId]
+		edges := 0
+
+		for _, target := range node.Branching.Targets() {
+			if !exists(target) {
+				report.Errors = append(report.Errors, GraphIssue{
+					Type:    IssueMissingNode,
+					NodeId:  nodeId,
+					Target:  target,
+					Message: fmt.Sprintf("node %d leads to node %d which is not in chapter", nodeId, target),
+				})
+				continue
+			}
+
+			edges++
+
+			if !visited[target] {
+				visited[target] = true
+				queue = append(queue, target)
+			}
+		}
+
+		if edges == 0 && len(node.Branching.Choices) == 0 && !node.End.Flag {
+			report.Errors = append(report.Errors, GraphIssue{
+				Type:    IssueDeadEnd,
+				NodeId:  nodeId,
+				Message: fmt.Sprintf("node %d has no choices and is not an ending", nodeId),
+			})
+		}
+	}
+
+	for _, nodeId := range chapter.Nodes {
+		if exists(nodeId) && !visited[nodeId] {
+			report.Errors = append(report.Errors, GraphIssue{
+				Type:    IssueUnreachable,
+				NodeId:  nodeId,
+				Message: fmt.Sprintf("node %d is unreachable from start node", nodeId),
+			})
+		}
+	}
+
+	for _, component := range closedCycles(visited, nodes, exists) {
+		report.Errors = append(report.Errors, GraphIssue{
+			Type:    IssueClosedCycle,
+			NodeId:  component[0],
+			Nodes:   component,
+			Message: fmt.Sprintf("nodes %v form a cycle without exit", component),
+		})
+	}
+
+	return report
+}
+
+// closedCycles ищет компоненты сильной связности (алгоритм Тарьяна), из которых
+// нет переходов наружу и в которых нет концовок
+func closedCycles(visited map[int64]bool, nodes map[int64]models.Node, exists func(int64) bool) [][]int64 {
+	ids := make([]int64, 0, len(visited))
+
+	for nodeId := range visited {
+		ids = append(ids, nodeId)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	index := 0
+	indexes := make(map[int64]int, len(ids))
+	lowLinks := make(map[int64]int, len(ids))
+	onStack := make(map[int64]bool, len(ids))
+	var stack []int64
+	var components [][]int64
+
+	var connect func(nodeId int64)
+	connect = func(nodeId int64) {
+		indexes[nodeId] = index
+		lowLinks[nodeId] = index
+		index++
+		stack = append(stack, nodeId)
+		onStack[nodeId] = true
+
+		for _, target := range nodes[nodeId].Branching.Targets() {
+			if !exists(target) {
+				continue
+			}
+
+			if _, ok := indexes[target]; !ok {
+				connect(target)
+				lowLinks[nodeId] = min(lowLinks[nodeId], lowLinks[target])
+			} else if onStack[target] {
+				lowLinks[nodeId] = min(lowLinks[nodeId], indexes[target])
+			}
+		}
+
+		if lowLinks[nodeId] != indexes[nodeId] {
+			return
+		}
+
+		var component []int64
+
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+
+			if top == nodeId {
+				break
+			}
+		}
+
+		components = append(components, component)
+	}
+
+	for _, nodeId := range ids {
+		if _, ok := indexes[nodeId]; !ok {
+			connect(nodeId)
+		}
+	}
+
+	var closed [][]int64
+
+	for _, component := range components {
+		members := make(map[int64]bool, len(component))
+
+		for _, nodeId := range component {
+			members[nodeId] = true
+		}
+
+		cyclic := len(component) > 1
+		hasExit := false
+
+		for _, nodeId := range component {
+			node := nodes[nodeId]
+
+			if node.End.Flag {
+				hasExit = true
+			}
+
+			for _, target := range node.Branching.Targets() {
+				if target == nodeId {
+					cyclic = true
+				}
+
+				if exists(target) && !members[target] {
+					hasExit = true
+				}
+			}
+		}
+
+		if cyclic && !hasExit {
+			sort.Slice(component, func(i, j int) bool { return component[i] < component[j] })
+			closed = append(closed, component)
+		}
+	}
+
+	return closed
+}
@@ -0,0 +1,119 @@
+package chapter
+
+import (
+	"testing"
+	"vn/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func choices(targets ...int64) models.Branching {
+	branching := models.Branching{Flag: len(targets) > 1}
+
+	for _, target := range targets {
+		branching.Choices = append(branching.Choices, models.Choice{NextNode: target})
+	}
+
+	return branching
+}
+
+func issuesOfType(report GraphReport, issueType string) []GraphIssue {
+	var res []GraphIssue
+
+	for _, issue := range report.Errors {
+		if issue.Type == issueType {
+			res = append(res, issue)
+		}
+	}
+
+	return res
+}
+
+func TestValidateGraph(t *testing.T) {
+	tests := []struct {
+		name     string
+		chapter  models.Chapter
+		nodes    map[int64]models.Node
+		expected map[string][]int64 // тип ошибки - id узлов
+	}{
+		{
+			name:    "Корректная глава",
+			chapter: models.Chapter{Id: 1, StartNode: 1, Nodes: []int64{1, 2, 3}},
+			nodes: map[int64]models.Node{
+				1: {Id: 1, Branching: choices(2, 3)},
+				2: {Id: 2, Branching: choices(1, 3)},
+				3: {Id: 3, End: models.EndInfo{Flag: true}},
+			},
+			expected: map[string][]int64{},
+		},
+		{
+			name:    "Недостижимый узел и тупик",
+			chapter: models.Chapter{Id: 1, StartNode: 1, Nodes: []int64{1, 2, 3}},
+			nodes: map[int64]models.Node{
+				1: {Id: 1, Branching: choices(2)},
+				2: {Id: 2},
+				3: {Id: 3, End: models.EndInfo{Flag: true}},
+			},
+			expected: map[string][]int64{
+				IssueDeadEnd:     {2},
+				IssueUnreachable: {3},
+			},
+		},
+		{
+			name:    "Цикл без выхода",
+			chapter: models.Chapter{Id: 1, StartNode: 1, Nodes: []int64{1, 2, 3}},
+			nodes: map[int64]models.Node{
+				1: {Id: 1, Branching: choices(2)},
+				2: {Id: 2, Branching: choices(3)},
+				3: {Id: 3, Branching: choices(2)},
+			},
+			expected: map[string][]int64{
+				IssueClosedCycle: {2},
+			},
+		},
+		{
+			name:    "Ссылка на отсутствующий узел",
+			chapter: models.Chapter{Id: 1, StartNode: 1, Nodes: []int64{1, 2}},
+			nodes: map[int64]models.Node{
+				1: {Id: 1, Branching: choices(2, 5)},
+				2: {Id: 2, End: models.EndInfo{Flag: true}},
+			},
+			expected: map[string][]int64{
+				IssueMissingNode: {1},
+			},
+		},
+		{
+			name:    "Начальный узел вне главы",
+			chapter: models.Chapter{Id: 1, StartNode: 9, Nodes: []int64{1}},
+			nodes: map[int64]models.Node{
+				1: {Id: 1, End: models.EndInfo{Flag: true}},
+			},
+			expected: map[string][]int64{
+				IssueMissingNode: {0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := ValidateGraph(tt.chapter, tt.nodes)
+
+			total := 0
+
+			for issueType, nodeIds := range tt.expected {
+				issues := issuesOfType(report, issueType)
+
+				var actual []int64
+				for _, issue := range issues {
+					actual = append(actual, issue.NodeId)
+				}
+
+				assert.ElementsMatch(t, nodeIds, actual, issueType)
+				total += len(nodeIds)
+			}
+
+			assert.Len(t, report.Errors, total)
+			assert.Equal(t, total == 0, report.Valid())
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package chapter
+
+import (
+	"vn/internal/models"
+)
+
+var backgroundTransitions = map[string]bool{"": true, "cut": true, "fade": true, "dissolve": true}
+
+var screenEffects = map[string]bool{
+	models.ScreenFadeIn:  true,
+	models.ScreenFadeOut: true,
+	models.ScreenFlash:   true,
+	models.ScreenShake:   true,
+}
+
+// validatePayload проверяет данные события в зависимости от его типа.
+// Для типов 0-3 данные новых типов не допускаются, остальные поля не меняются
+func validatePayload(event models.Event, refs eventReferences, fieldError func(field string, format string, args ...interface{})) {
+	payloads := map[string]bool{
+		"background":   event.Background != nil,
+		"music":        event.Music != nil,
+		"sound_effect": event.SoundEffect != nil,
+		"screen":       event.Screen != nil,
+		"wait":         event.Wait != nil,
+	}
+
+	var expected string
+
+	switch event.Type {
+	case models.EventNarration, models.EventCharacterEnter, models.EventCharacterExit, models.EventSpeech:
+	case models.EventBackground:
+		expected = "background"
+	case models.EventMusic:
+		expected = "music"
+	case models.EventSoundEffect:
+		expected = "sound_effect"
+	case models.EventScreenEffect:
+		expected = "screen"
+	case models.EventWait:
+		expected = "wait"
+	default:
+		fieldError("type", "unknown event type %d", event.Type)
+		return
+	}
+
+	for _, name := range []string{"background", "music", "sound_effect", "screen", "wait"} {
+		if payloads[name] && name != expected {
+			fieldError(name, "not allowed for event type %d", event.Type)
+		}
+	}
+
+	if expected != "" && !payloads[expected] {
+		fieldError(expected, "required for event type %d", event.Type)
+		return
+	}
+
+	checkMedia := func(field string, media int64) {
+		if media == 0 {
+			fieldError(field, "media is required")
+		} else if !refs.media[media] {
+			fieldError(field, "media %d not found", media)
+		}
+	}
+
+	switch event.Type {
+	case models.EventBackground:
+		checkMedia("background.media", event.Background.Media)
+
+		if !backgroundTransitions[event.Background.Transition] {
+			fieldError("background.transition", "unknown transition %q", event.Background.Transition)
+		}
+	case models.EventMusic:
+		switch event.Music.Action {
+		case models.MusicPlay, models.MusicCrossfade:
+			checkMedia("music.media", event.Music.Media)
+		case models.MusicStop:
+		default:
+			fieldError("music.action", "unknown music action %q", event.Music.Action)
+		}
+
+		if event.Music.FadeMs < 0 {
+			fieldError("music.fade_ms", "must not be negative")
+		}
+	case models.EventSoundEffect:
+		checkMedia("sound_effect.media", event.SoundEffect.Media)
+
+		if event.SoundEffect.Volume < 0 || event.SoundEffect.Volume > 100 {
+			fieldError("sound_effect.volume", "must be between 0 and 100")
+		}
+	case models.EventScreenEffect:
+		if !screenEffects[event.Screen.Effect] {
+			fieldError("screen.effect", "unknown screen effect %q", event.Screen.Effect)
+		}
+
+		if event.Screen.DurationMs <= 0 {
+			fieldError("screen.duration_ms", "must be positive")
+		}
+	case models.EventWait:
+		if event.Wait.DurationMs <= 0 {
+			fieldError("wait.duration_ms", "must be positive")
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package chapter
+
+import (
+	"testing"
+	"vn/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePayload(t *testing.T) {
+	refs := eventReferences{media: map[int64]bool{7: true}}
+
+	tests := []struct {
+		name           string
+		event          models.Event
+		expectedFields []string
+	}{
+		{
+			name:  "Старый тип без данных",
+			event: models.Event{Type: models.EventSpeech},
+		},
+		{
+			name:           "Старый тип с данными нового",
+			event:          models.Event{Type: models.EventNarration, Wait: &models.WaitPayload{DurationMs: 100}},
+			expectedFields: []string{"wait"},
+		},
+		{
+			name:  "Смена фона",
+			event: models.Event{Type: models.EventBackground, Background: &models.BackgroundPayload{Media: 7, Transition: "fade"}},
+		},
+		{
+			name:           "Фон без данных",
+			event:          models.Event{Type: models.EventBackground},
+			expectedFields: []string{"background"},
+		},
+		{
+			name:           "Фон с неизвестным файлом и переходом",
+			event:          models.Event{Type: models.EventBackground, Background: &models.BackgroundPayload{Media: 8, Transition: "spin"}},
+			expectedFields: []string{"background.media", "background.transition"},
+		},
+		{
+			name:  "Остановка музыки",
+			event: models.Event{Type: models.EventMusic, Music: &models.MusicPayload{Action: models.MusicStop, FadeMs: 500}},
+		},
+		{
+			name:           "Смена музыки без файла",
+			event:          models.Event{Type: models.EventMusic, Music: &models.MusicPayload{Action: models.MusicCrossfade}},
+			expectedFields: []string{"music.media"},
+		},
+		{
+			name:           "Неизвестное действие с музыкой",
+			event:          models.Event{Type: models.EventMusic, Music: &models.MusicPayload{Action: "pause", FadeMs: -1}},
+			expectedFields: []string{"music.action", "music.fade_ms"},
+		},
+		{
+			name:           "Громкость звука вне диапазона",
+			event:          models.Event{Type: models.EventSoundEffect, SoundEffect: &models.SoundEffectPayload{Media: 7, Volume: 120}},
+			expectedFields: []string{"sound_effect.volume"},
+		},
+		{
+			name:           "Неизвестный эффект экрана",
+			event:          models.Event{Type: models.EventScreenEffect, Screen: &models.ScreenEffectPayload{Effect: "spin"}},
+			expectedFields: []string{"screen.effect", "screen.duration_ms"},
+		},
+		{
+			name:           "Пауза нулевой длины",
+			event:          models.Event{Type: models.EventWait, Wait: &models.WaitPayload{}},
+			expectedFields: []string{"wait.duration_ms"},
+		},
+		{
+			name:           "Неизвестный тип",
+			event:          models.Event{Type: 42},
+			expectedFields: []string{"type"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []string
+
+			validatePayload(tt.event, refs, func(field string, format string, args ...interface{}) {
+				fields = append(fields, field)
+			})
+
+			assert.Equal(t, tt.expectedFields, fields)
+		})
+	}
+}
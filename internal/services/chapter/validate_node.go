@@ -0,0 +1,285 @@
+package chapter
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"sort"
+	"strings"
+	"vn/internal/models"
+	"vn/internal/storage"
+	"vn/pkg/condition"
+)
+
+// ValidationError - ошибка в конкретном поле узла. EventId заполняется для ошибок в событиях
+type ValidationError struct {
+	EventId int64
+	Field   string
+	Message string
+}
+
+// NodeValidationError возвращается, когда узел содержит некорректные данные
+type NodeValidationError struct {
+	NodeId int64
+	Errors []ValidationError
+}
+
+func (e *NodeValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+
+	for _, validationErr := range e.Errors {
+		messages = append(messages, validationErr.Field+": "+validationErr.Message)
+	}
+
+	return fmt.Sprintf("node %d is invalid: %s", e.NodeId, strings.Join(messages, "; "))
+}
+
+// ConditionVariables возвращает типы переменных, доступных в условиях главы
+func ConditionVariables(chapterId int64, db *gorm.DB) (map[string]condition.Type, error) {
+	variables, err := storage.SelectVariablesForChapter(db, chapterId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	types := make(map[string]condition.Type, len(variables))
+
+	for _, variable := range variables {
+		if variable.Type == models.VariableTypeFlag {
+			types[variable.Name] = condition.TypeBool
+		} else {
+			types[variable.Name] = condition.TypeInt
+		}
+	}
+
+	return types, nil
+}
+
+// validateConditions разбирает условия вариантов выбора и проверяет их типы
+func validateConditions(branching models.Branching, variables map[string]condition.Type) []ValidationError {
+	var validationErrors []ValidationError
+
+	for i, choice := range branching.Choices {
+		if strings.TrimSpace(choice.Condition) == "" {
+			continue
+		}
+
+		_, err := condition.Compile(choice.Condition, variables)
+
+		if err != nil {
+			validationErrors = append(validationErrors, ValidationError{
+				Field:   fmt.Sprintf("branching.choices[%d].condition", i),
+				Message: err.Error(),
+			})
+		}
+	}
+
+	return validationErrors
+}
+
+var effectOperations = map[string]bool{models.EffectSet: true, models.EffectAdd: true, models.EffectSub: true}
+
+// validateEffects проверяет, что события меняют только объявленные переменные
+func validateEffects(events models.Events, variables map[string]condition.Type) []ValidationError {
+	var validationErrors []ValidationError
+
+	for i, event := range events {
+		for j, effect := range event.Effects {
+			field := fmt.Sprintf("events[%d].effects[%d]", i, j)
+
+			if _, ok := variables[effect.Variable]; !ok {
+				validationErrors = append(validationErrors, ValidationError{
+					EventId: event.Id,
+					Field:   field + ".variable",
+					Message: fmt.Sprintf("undeclared variable %q", effect.Variable),
+				})
+			}
+
+			if !effectOperations[effect.Operation] {
+				validationErrors = append(validationErrors, ValidationError{
+					EventId: event.Id,
+					Field:   field + ".operation",
+					Message: fmt.Sprintf("unknown operation %q", effect.Operation),
+				})
+			}
+		}
+	}
+
+	return validationErrors
+}
+
+// checkConditions проверяет условия ветвления перед сохранением узла
+func checkConditions(node models.Node, db *gorm.DB) error {
+	hasConditions := false
+
+	for _, choice := range node.Branching.Choices {
+		if strings.TrimSpace(choice.Condition) != "" {
+			hasConditions = true
+			break
+		}
+	}
+
+	if !hasConditions {
+		return nil
+	}
+
+	variables, err := ConditionVariables(node.ChapterId, db)
+
+	if err != nil {
+		return err
+	}
+
+	validationErrors := validateConditions(node.Branching, variables)
+
+	if len(validationErrors) > 0 {
+		return &NodeValidationError{NodeId: node.Id, Errors: validationErrors}
+	}
+
+	return nil
+}
+
+// eventReferences - данные, на которые могут ссылаться события главы
+type eventReferences struct {
+	chapterCharacters map[int64]bool
+	characters        map[int64]models.Character
+	media             map[int64]bool
+}
+
+// validateEvents проверяет, что персонажи событий входят в главу,
+// эмоции есть у персонажа, а звуки существуют
+func validateEvents(events models.Events, refs eventReferences) []ValidationError {
+	var validationErrors []ValidationError
+
+	for i, event := range events {
+		fieldError := func(field string, format string, args ...interface{}) {
+			validationErrors = append(validationErrors, ValidationError{
+				EventId: event.Id,
+				Field:   fmt.Sprintf("events[%d].%s", i, field),
+				Message: fmt.Sprintf(format, args...),
+			})
+		}
+
+		if event.Character != 0 && !refs.chapterCharacters[event.Character] {
+			fieldError("character", "character %d is not in chapter", event.Character)
+		}
+
+		if event.Sound != 0 && !refs.media[event.Sound] {
+			fieldError("sound", "media %d not found", event.Sound)
+		}
+
+		validatePayload(event, refs, fieldError)
+
+		characterIds := make([]int64, 0, len(event.CharactersInEvent))
+
+		for characterId := range event.CharactersInEvent {
+			characterIds = append(characterIds, characterId)
+		}
+
+		sort.Slice(characterIds, func(a, b int) bool { return characterIds[a] < characterIds[b] })
+
+		for _, characterId := range characterIds {
+			field := fmt.Sprintf("characters_in_event.%d", characterId)
+
+			if !refs.chapterCharacters[characterId] {
+				fieldError(field, "character %d is not in chapter", characterId)
+				continue
+			}
+
+			character, ok := refs.characters[characterId]
+
+			if !ok {
+				fieldError(field, "character %d not found", characterId)
+				continue
+			}
+
+			emotions := make([]int64, 0, len(event.CharactersInEvent[characterId]))
+
+			for emotion := range event.CharactersInEvent[characterId] {
+				emotions = append(emotions, emotion)
+			}
+
+			sort.Slice(emotions, func(a, b int) bool { return emotions[a] < emotions[b] })
+
+			for _, emotion := range emotions {
+				if _, ok := character.Emotions[emotion]; !ok {
+					fieldError(fmt.Sprintf("%s.%d", field, emotion), "character %d has no emotion %d", characterId, emotion)
+				}
+			}
+		}
+	}
+
+	return validationErrors
+}
+
+// loadEventReferences загружает персонажей главы и медиафайлы, упомянутые в событиях
+func loadEventReferences(chapterId int64, events models.Events, db *gorm.DB) (eventReferences, error) {
+	chapter, err := storage.SelectChapterWIthId(db, chapterId)
+
+	if err != nil {
+		return eventReferences{}, err
+	}
+
+	refs := eventReferences{chapterCharacters: make(map[int64]bool, len(chapter.Characters))}
+
+	for _, characterId := range chapter.Characters {
+		refs.chapterCharacters[characterId] = true
+	}
+
+	refs.characters, err = storage.SelectCharactersWithIds(db, chapter.Characters)
+
+	if err != nil {
+		return eventReferences{}, err
+	}
+
+	var media []int64
+
+	for _, event := range events {
+		media = append(media, event.MediaIds()...)
+	}
+
+	refs.media, err = storage.SelectExistingMediaIds(db, media)
+
+	if err != nil {
+		return eventReferences{}, err
+	}
+
+	return refs, nil
+}
+
+// checkEvents проверяет ссылки событий узла и изменения переменных перед сохранением
+func checkEvents(node models.Node, db *gorm.DB) error {
+	if len(node.Events) == 0 {
+		return nil
+	}
+
+	refs, err := loadEventReferences(node.ChapterId, node.Events, db)
+
+	if err != nil {
+		return err
+	}
+
+	validationErrors := validateEvents(node.Events, refs)
+
+	hasEffects := false
+
+	for _, event := range node.Events {
+		hasEffects = hasEffects || len(event.Effects) > 0
+	}
+
+	// Необъявленная переменная в изменении не сохраняется, иначе опечатка незаметно
+	// превращается в новую переменную главы
+	if hasEffects {
+		variables, err := ConditionVariables(node.ChapterId, db)
+
+		if err != nil {
+			return err
+		}
+
+		validationErrors = append(validationErrors, validateEffects(node.Events, variables)...)
+	}
+
+	if len(validationErrors) > 0 {
+		return &NodeValidationError{NodeId: node.Id, Errors: validationErrors}
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package chapter
+
+import (
+	"encoding/json"
+	"testing"
+	"vn/internal/models"
+	"vn/pkg/condition"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConditions(t *testing.T) {
+	variables := map[string]condition.Type{
+		"affinity.anna": condition.TypeInt,
+		"flag.met_boss": condition.TypeBool,
+	}
+
+	branching := models.Branching{
+		Flag: true,
+		Choices: []models.Choice{
+			{Text: "Без условия", NextNode: 1},
+			{Text: "Верное условие", NextNode: 2, Condition: "affinity.anna >= 3 && !flag.met_boss"},
+			{Text: "Синтаксическая ошибка", NextNode: 3, Condition: "affinity.anna >= "},
+			{Text: "Необъявленная переменная", NextNode: 4, Condition: "affinity.kate > 1"},
+		},
+	}
+
+	validationErrors := validateConditions(branching, variables)
+
+	assert.Len(t, validationErrors, 2)
+	assert.Equal(t, "branching.choices[2].condition", validationErrors[0].Field)
+	assert.Equal(t, "branching.choices[3].condition", validationErrors[1].Field)
+}
+
+func TestValidateEffects(t *testing.T) {
+	variables := map[string]condition.Type{"affinity.anna": condition.TypeInt}
+
+	events := models.Events{
+		{Id: 1, Effects: []models.Effect{{Variable: "affinity.anna", Operation: models.EffectAdd, Value: 1}}},
+		{Id: 2, Effects: []models.Effect{
+			{Variable: "affinity.ana", Operation: models.EffectAdd, Value: 1},
+			{Variable: "affinity.anna", Operation: "mul", Value: 2},
+		}},
+	}
+
+	validationErrors := validateEffects(events, variables)
+
+	assert.Equal(t, []ValidationError{
+		{EventId: 2, Field: "events[1].effects[0].variable", Message: `undeclared variable "affinity.ana"`},
+		{EventId: 2, Field: "events[1].effects[1].operation", Message: `unknown operation "mul"`},
+	}, validationErrors)
+}
+
+func TestLegacyConditionUnmarshal(t *testing.T) {
+	var branching models.Branching
+
+	err := json.Unmarshal([]byte(`{"Flag":true,"Choices":[
+		{"Text":"a","NextNode":1,"Condition":{"flag.b":1,"affinity.anna":3}},
+		{"Text":"b","NextNode":2,"Condition":"affinity.anna < 3"},
+		{"Text":"c","NextNode":3,"Condition":null}
+	]}`), &branching)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "affinity.anna >= 3 && flag.b >= 1", branching.Choices[0].Condition)
+	assert.Equal(t, int64(1), branching.Choices[0].NextNode)
+	assert.Equal(t, "affinity.anna < 3", branching.Choices[1].Condition)
+	assert.Equal(t, "", branching.Choices[2].Condition)
+}
+
+func TestValidateEvents(t *testing.T) {
+	refs := eventReferences{
+		chapterCharacters: map[int64]bool{42: true, 43: true},
+		characters: map[int64]models.Character{
+			42: {Id: 42, Emotions: map[int64]int64{0: 100, 1: 101}},
+		},
+		media: map[int64]bool{7: true},
+	}
+
+	events := models.Events{
+		{Id: 1, Character: 42, Sound: 7, CharactersInEvent: map[int64]map[int64]int64{42: {1: 30}}},
+		{Id: 2, Character: 50, Sound: 8},
+		{Id: 3, CharactersInEvent: map[int64]map[int64]int64{42: {5: 10}, 43: {0: 0}, 51: {0: 0}}},
+	}
+
+	validationErrors := validateEvents(events, refs)
+
+	assert.Equal(t, []ValidationError{
+		{EventId: 2, Field: "events[1].character", Message: "character 50 is not in chapter"},
+		{EventId: 2, Field: "events[1].sound", Message: "media 8 not found"},
+		{EventId: 3, Field: "events[2].characters_in_event.42.5", Message: "character 42 has no emotion 5"},
+		{EventId: 3, Field: "events[2].characters_in_event.43", Message: "character 43 not found"},
+		{EventId: 3, Field: "events[2].characters_in_event.51", Message: "character 51 is not in chapter"},
+	}, validationErrors)
+}
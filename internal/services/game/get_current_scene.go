@@ -0,0 +1,48 @@
+package game
+
+import (
+	"gorm.io/gorm"
+	chapterService "vn/internal/services/chapter"
+	"vn/internal/storage"
+)
+
+// GetCurrentScene возвращает узел, на котором игрок остановился в главе, с текстом на языке locale
+func GetCurrentScene(playerId int64, chapterId int64, locale string, db *gorm.DB) (*Scene, error) {
+	catalog, err := chapterService.LoadCatalog(locale, []int64{chapterId}, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	player, err := storage.SelectPlayerWIthId(db, playerId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nodeId, ok := player.ChaptersProgress[chapterId]
+
+	if !ok {
+		return nil, ErrChapterNotStarted
+	}
+
+	version, err := playerVersion(playerId, chapterId, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := selectNode(nodeId, chapterId, version, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := loadState(playerId, chapterId, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return localizeScene(buildScene(node, state), catalog), nil
+}
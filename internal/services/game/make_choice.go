@@ -0,0 +1,82 @@
+package game
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	chapterService "vn/internal/services/chapter"
+	"vn/internal/storage"
+)
+
+// MakeChoice переводит игрока в следующий узел по выбранному варианту.
+// Для узлов без выбора (Branching.Flag == false) индекс варианта игнорируется
+func MakeChoice(playerId int64, chapterId int64, choice int, locale string, db *gorm.DB) (*Scene, error) {
+	catalog, err := chapterService.LoadCatalog(locale, []int64{chapterId}, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	player, err := storage.SelectPlayerWIthId(db, playerId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nodeId, ok := player.ChaptersProgress[chapterId]
+
+	if !ok {
+		return nil, ErrChapterNotStarted
+	}
+
+	version, err := playerVersion(playerId, chapterId, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := selectNode(nodeId, chapterId, version, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := loadState(playerId, chapterId, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	scene := buildScene(node, state)
+
+	if scene.Completed {
+		return nil, ErrChapterFinished
+	}
+
+	if len(scene.Choices) == 0 {
+		return nil, fmt.Errorf("node %d has no available choices", nodeId)
+	}
+
+	next := scene.Choices[0]
+
+	if node.Branching.Flag {
+		next = -1
+
+		for _, available := range scene.Choices {
+			if available == choice {
+				next = choice
+			}
+		}
+
+		if next == -1 {
+			return nil, ErrInvalidChoice
+		}
+	}
+
+	scene, err = enterNode(&player, chapterId, node.Branching.Choices[next].NextNode, version, state, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return localizeScene(scene, catalog), nil
+}
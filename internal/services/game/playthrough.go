@@ -0,0 +1,139 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"vn/internal/models"
+	chapterService "vn/internal/services/chapter"
+	"vn/internal/storage"
+)
+
+var (
+	ErrChapterNotPublished = chapterService.ErrChapterNotPublished
+	ErrChapterNotStarted   = errors.New("chapter is not started")
+	ErrChapterFinished     = errors.New("chapter is already finished")
+	ErrInvalidChoice       = errors.New("invalid choice")
+)
+
+// Scene - текущее состояние прохождения главы игроком
+type Scene struct {
+	Node      models.Node
+	Choices   []int // индексы вариантов выбора, доступных игроку
+	Completed bool
+	Variables map[string]int64
+}
+
+// enterNode сохраняет прогресс игрока в узле, применяет изменения переменных из событий узла
+// и отмечает главу пройденной при достижении концовки
+func enterNode(player *models.Player, chapterId int64, nodeId int64, version *models.ChapterVersion, state *State, db *gorm.DB) (*Scene, error) {
+	node, err := selectNode(nodeId, chapterId, version, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if player.ChaptersProgress == nil {
+		player.ChaptersProgress = map[int64]int64{}
+	}
+
+	player.ChaptersProgress[chapterId] = nodeId
+
+	if node.End.Flag && !isCompleted(player, chapterId) {
+		player.CompletedChapters = append(player.CompletedChapters, chapterId)
+	}
+
+	_, err = storage.UpdatePlayer(db, player.Id, *player)
+
+	if err != nil {
+		log.Println("ошибка сохранения прогресса игрока", err)
+		return nil, err
+	}
+
+	state.applyNode(node)
+
+	err = state.save(player.Id, db)
+
+	if err != nil {
+		log.Println("ошибка сохранения переменных игрока", err)
+		return nil, err
+	}
+
+	return buildScene(node, state), nil
+}
+
+// playerVersion возвращает версию главы, которую проходит игрок.
+// nil - прохождение начато до появления версий, узлы читаются из текущей главы
+func playerVersion(playerId int64, chapterId int64, db *gorm.DB) (*models.ChapterVersion, error) {
+	versionId, err := storage.SelectPlayerVersion(db, playerId, chapterId)
+
+	if err != nil || versionId == 0 {
+		return nil, err
+	}
+
+	return storage.SelectChapterVersion(db, versionId)
+}
+
+// selectNode ищет узел в версии главы игрока, а без версии - среди узлов главы
+func selectNode(nodeId int64, chapterId int64, version *models.ChapterVersion, db *gorm.DB) (models.Node, error) {
+	if version != nil {
+		node, ok := version.Content.Node(nodeId)
+
+		if !ok {
+			return models.Node{}, fmt.Errorf("node %d not found in chapter %d version %d", nodeId, chapterId, version.Version)
+		}
+
+		return node, nil
+	}
+
+	node, err := storage.SelectNodeWIthId(db, nodeId)
+
+	if err != nil {
+		return models.Node{}, err
+	}
+
+	if node == nil || node.ChapterId != chapterId {
+		return models.Node{}, fmt.Errorf("node %d not found in chapter %d", nodeId, chapterId)
+	}
+
+	return *node, nil
+}
+
+func buildScene(node models.Node, state *State) *Scene {
+	scene := &Scene{
+		Node:      node,
+		Choices:   []int{},
+		Completed: node.End.Flag,
+		Variables: state.Values,
+	}
+
+	if scene.Completed {
+		return scene
+	}
+
+	for i, choice := range node.Branching.Choices {
+		if choiceAvailable(choice, state) {
+			scene.Choices = append(scene.Choices, i)
+		}
+	}
+
+	return scene
+}
+
+// localizeScene подставляет перевод текста узла. Переходы считаются по исходному узлу,
+// перевод меняет только текст
+func localizeScene(scene *Scene, catalog *chapterService.Catalog) *Scene {
+	scene.Node = catalog.Node(scene.Node)
+	return scene
+}
+
+func isCompleted(player *models.Player, chapterId int64) bool {
+	for _, completed := range player.CompletedChapters {
+		if completed == chapterId {
+			return true
+		}
+	}
+
+	return false
+}
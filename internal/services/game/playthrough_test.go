@@ -0,0 +1,86 @@
+package game
+
+import (
+	"testing"
+	"vn/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildScene(t *testing.T) {
+	tests := []struct {
+		name              string
+		node              models.Node
+		expectedChoices   []int
+		expectedCompleted bool
+	}{
+		{
+			name: "Узел с выбором",
+			node: models.Node{
+				Id: 1,
+				Branching: models.Branching{
+					Flag: true,
+					Choices: []models.Choice{
+						{Text: "Остаться", NextNode: 2},
+						{Text: "Уйти", NextNode: 3},
+					},
+				},
+			},
+			expectedChoices:   []int{0, 1},
+			expectedCompleted: false,
+		},
+		{
+			name: "Концовка",
+			node: models.Node{
+				Id:  3,
+				End: models.EndInfo{Flag: true, EndResult: "good"},
+				Branching: models.Branching{
+					Choices: []models.Choice{{NextNode: 1}},
+				},
+			},
+			expectedChoices:   []int{},
+			expectedCompleted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scene := buildScene(tt.node, newState(1, nil))
+
+			assert.Equal(t, tt.expectedChoices, scene.Choices)
+			assert.Equal(t, tt.expectedCompleted, scene.Completed)
+		})
+	}
+}
+
+func TestIsCompleted(t *testing.T) {
+	player := &models.Player{CompletedChapters: []int64{1, 2}}
+
+	assert.True(t, isCompleted(player, 2))
+	assert.False(t, isCompleted(player, 3))
+}
+
+func TestStateEffects(t *testing.T) {
+	state := newState(1, []models.Variable{
+		{ChapterId: 0, Name: "affinity.anna", Type: models.VariableTypeInt, Default: 1},
+		{ChapterId: 1, Name: "flag.met_boss", Type: models.VariableTypeFlag},
+	})
+
+	state.applyNode(models.Node{
+		Events: models.Events{
+			{Id: 1, Effects: []models.Effect{{Variable: "affinity.anna", Operation: models.EffectSet, Value: 5}}},
+			{Id: 2, Effects: []models.Effect{{Variable: "affinity.anna", Operation: models.EffectAdd, Value: 2}}},
+			{Id: 3, Effects: []models.Effect{{Variable: "flag.met_boss", Operation: models.EffectSet, Value: 7}}},
+			{Id: 4, Effects: []models.Effect{{Variable: "deleted", Operation: models.EffectSet, Value: 1}}},
+		},
+	})
+
+	assert.Equal(t, int64(7), state.Values["affinity.anna"])
+	assert.Equal(t, int64(1), state.Values["flag.met_boss"])
+	assert.Len(t, state.changed, 2)
+	assert.NotContains(t, state.Values, "deleted")
+
+	assert.True(t, choiceAvailable(models.Choice{Condition: "affinity.anna >= 7"}, state))
+	assert.False(t, choiceAvailable(models.Choice{Condition: "affinity.anna >= 8"}, state))
+	assert.False(t, choiceAvailable(models.Choice{Condition: "affinity.anna >="}, state))
+}
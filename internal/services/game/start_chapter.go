@@ -0,0 +1,75 @@
+package game
+
+import (
+	"gorm.io/gorm"
+	chapterService "vn/internal/services/chapter"
+	"vn/internal/storage"
+)
+
+// StartChapter начинает главу заново с начального узла последней опубликованной версии.
+// Игрок проходит эту версию до конца, даже если главу потом отредактируют
+func StartChapter(playerId int64, chapterId int64, locale string, db *gorm.DB) (*Scene, error) {
+	catalog, err := chapterService.LoadCatalog(locale, []int64{chapterId}, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	chapter, err := storage.SelectChapterWIthId(db, chapterId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if chapter.Status != chapterService.PublishedStatus {
+		return nil, ErrChapterNotPublished
+	}
+
+	player, err := storage.SelectPlayerWIthId(db, playerId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := storage.SelectLatestChapterVersion(db, chapterId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Глава опубликована до появления версий - снимок создается при первом запуске
+	if version == nil {
+		version, err = chapterService.PublishVersion(chapterId, db)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = storage.PinPlayerVersion(db, playerId, chapterId, version.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Локальные переменные главы сбрасываются, глобальные сохраняются
+	_, err = storage.DeletePlayerVariables(db, playerId, chapterId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := loadState(playerId, chapterId, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	scene, err := enterNode(&player, chapterId, version.Content.Chapter.StartNode, version, state, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return localizeScene(scene, catalog), nil
+}
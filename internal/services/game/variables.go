@@ -0,0 +1,140 @@
+package game
+
+import (
+	"gorm.io/gorm"
+	"strings"
+	"vn/internal/models"
+	"vn/internal/storage"
+	"vn/pkg/condition"
+)
+
+// State - значения переменных сюжета игрока в рамках главы
+type State struct {
+	Values map[string]int64
+
+	chapterId int64
+	types     map[string]string
+	scopes    map[string]int64 // имя переменной - глава, 0 для глобальных
+	changed   map[string]bool
+}
+
+func newState(chapterId int64, variables []models.Variable) *State {
+	state := &State{
+		Values:    map[string]int64{},
+		chapterId: chapterId,
+		types:     map[string]string{},
+		scopes:    map[string]int64{},
+		changed:   map[string]bool{},
+	}
+
+	for _, variable := range variables {
+		state.Values[variable.Name] = variable.Default
+		state.types[variable.Name] = variable.Type
+		state.scopes[variable.Name] = variable.ChapterId
+	}
+
+	return state
+}
+
+// loadState собирает значения переменных игрока поверх значений по умолчанию
+func loadState(playerId int64, chapterId int64, db *gorm.DB) (*State, error) {
+	variables, err := storage.SelectVariablesForChapter(db, chapterId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := storage.SelectPlayerVariables(db, playerId, chapterId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	state := newState(chapterId, variables)
+
+	for _, value := range values {
+		state.Values[value.Name] = value.Value
+	}
+
+	return state, nil
+}
+
+func (state *State) apply(effect models.Effect) {
+	// Переменная могла быть удалена после сохранения узла
+	if _, declared := state.scopes[effect.Variable]; !declared {
+		return
+	}
+
+	value := state.Values[effect.Variable]
+
+	switch effect.Operation {
+	case models.EffectSet:
+		value = effect.Value
+	case models.EffectAdd:
+		value += effect.Value
+	case models.EffectSub:
+		value -= effect.Value
+	default:
+		return
+	}
+
+	if state.types[effect.Variable] == models.VariableTypeFlag && value != 0 {
+		value = 1
+	}
+
+	state.Values[effect.Variable] = value
+	state.changed[effect.Variable] = true
+}
+
+// applyNode применяет изменения переменных из событий узла в порядке их показа
+func (state *State) applyNode(node models.Node) {
+	for _, event := range node.Events {
+		for _, effect := range event.Effects {
+			state.apply(effect)
+		}
+	}
+}
+
+func (state *State) save(playerId int64, db *gorm.DB) error {
+	variables := make([]models.PlayerVariable, 0, len(state.changed))
+
+	for name := range state.changed {
+		variables = append(variables, models.PlayerVariable{
+			PlayerId:  playerId,
+			ChapterId: state.scopes[name],
+			Name:      name,
+			Value:     state.Values[name],
+		})
+	}
+
+	err := storage.SavePlayerVariables(db, variables)
+
+	if err != nil {
+		return err
+	}
+
+	state.changed = map[string]bool{}
+
+	return nil
+}
+
+// choiceAvailable вычисляет условие варианта. Некорректное условие делает вариант недоступным
+func choiceAvailable(choice models.Choice, state *State) bool {
+	if strings.TrimSpace(choice.Condition) == "" {
+		return true
+	}
+
+	expr, err := condition.Parse(choice.Condition)
+
+	if err != nil {
+		return false
+	}
+
+	available, err := condition.Eval(expr, state.Values)
+
+	if err != nil {
+		return false
+	}
+
+	return available
+}
@@ -0,0 +1,98 @@
+package live
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	NodeCreated          = "node_created"
+	NodeUpdated          = "node_updated"
+	NodeDeleted          = "node_deleted"
+	ChapterStatusChanged = "chapter_status_changed"
+	RequestSubmitted     = "request_submitted"
+)
+
+// bufferSize - сколько событий ждет медленного подписчика, дальше события для него теряются
+const bufferSize = 32
+
+// Event - изменение главы, которое рассылается подписанным редакторам
+type Event struct {
+	Type      string
+	ChapterId int64
+	NodeId    int64 // для событий узлов
+	Status    int   // для смены статуса главы
+	RequestId int64 // для отправленных запросов
+	At        time.Time
+}
+
+// Hub рассылает события подписчикам главы
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[chan Event]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subscribers: map[int64]map[chan Event]struct{}{}}
+}
+
+// Subscribe подписывает на события главы. Вызов cancel отписывает и закрывает канал
+func (hub *Hub) Subscribe(chapterId int64) (<-chan Event, func()) {
+	events := make(chan Event, bufferSize)
+
+	hub.mu.Lock()
+	if hub.subscribers[chapterId] == nil {
+		hub.subscribers[chapterId] = map[chan Event]struct{}{}
+	}
+	hub.subscribers[chapterId][events] = struct{}{}
+	hub.mu.Unlock()
+
+	var once sync.Once
+
+	cancel := func() {
+		once.Do(func() {
+			hub.mu.Lock()
+			defer hub.mu.Unlock()
+
+			delete(hub.subscribers[chapterId], events)
+
+			if len(hub.subscribers[chapterId]) == 0 {
+				delete(hub.subscribers, chapterId)
+			}
+
+			close(events)
+		})
+	}
+
+	return events, cancel
+}
+
+// Publish отправляет событие всем подписчикам главы, не дожидаясь их
+func (hub *Hub) Publish(event Event) {
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for events := range hub.subscribers[event.ChapterId] {
+		select {
+		case events <- event:
+		default:
+			log.Println("подписчик главы не успевает читать события, событие пропущено", event.ChapterId, event.Type)
+		}
+	}
+}
+
+// DefaultHub - общий хаб сервиса, в него пишут сервисы глав и админов
+var DefaultHub = NewHub()
+
+func Publish(event Event) {
+	DefaultHub.Publish(event)
+}
+
+func Subscribe(chapterId int64) (<-chan Event, func()) {
+	return DefaultHub.Subscribe(chapterId)
+}
@@ -0,0 +1,50 @@
+package live
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHubPublish(t *testing.T) {
+	hub := NewHub()
+
+	first, cancelFirst := hub.Subscribe(1)
+	second, cancelSecond := hub.Subscribe(1)
+	other, cancelOther := hub.Subscribe(2)
+	defer cancelOther()
+
+	hub.Publish(Event{Type: NodeUpdated, ChapterId: 1, NodeId: 10})
+
+	event := <-first
+	assert.Equal(t, NodeUpdated, event.Type)
+	assert.Equal(t, int64(10), event.NodeId)
+	assert.False(t, event.At.IsZero())
+	assert.Equal(t, event, <-second)
+	assert.Len(t, other, 0)
+
+	cancelFirst()
+	cancelFirst()
+
+	_, open := <-first
+	assert.False(t, open)
+
+	hub.Publish(Event{Type: NodeDeleted, ChapterId: 1, NodeId: 10})
+	assert.Equal(t, NodeDeleted, (<-second).Type)
+
+	cancelSecond()
+	assert.NotContains(t, hub.subscribers, int64(1))
+}
+
+func TestHubSlowSubscriber(t *testing.T) {
+	hub := NewHub()
+
+	events, cancel := hub.Subscribe(1)
+	defer cancel()
+
+	for i := 0; i < bufferSize+5; i++ {
+		hub.Publish(Event{Type: NodeCreated, ChapterId: 1, NodeId: int64(i)})
+	}
+
+	assert.Len(t, events, bufferSize)
+}
@@ -0,0 +1,261 @@
+package screenplay
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"vn/internal/models"
+)
+
+// Префиксы строк, которые не являются текстом рассказчика
+var commandPrefixes = []string{`\`, "#", "==", "->", ">", "$", "~", "@"}
+
+var effectSigns = map[string]string{
+	models.EffectSet: "=",
+	models.EffectAdd: "+=",
+	models.EffectSub: "-=",
+}
+
+// Format записывает узел текстом, который Parse разбирает обратно в те же события
+// и переходы. Id событий в текст не попадают
+func Format(node models.Node, dict *Dictionary) string {
+	var b strings.Builder
+
+	if node.Comment != "" {
+		for _, line := range strings.Split(node.Comment, "\n") {
+			b.WriteString(strings.TrimRight("# "+line, " ") + "\n")
+		}
+	}
+
+	if node.Background != 0 || node.Music != 0 {
+		b.WriteString("@scene")
+
+		if node.Background != 0 {
+			fmt.Fprintf(&b, " bg %d", node.Background)
+		}
+
+		if node.Music != 0 {
+			fmt.Fprintf(&b, " music %d", node.Music)
+		}
+
+		b.WriteString("\n")
+	}
+
+	if b.Len() > 0 && len(node.Events) > 0 {
+		b.WriteString("\n")
+	}
+
+	for _, event := range node.Events {
+		formatEvent(&b, event, dict)
+	}
+
+	if len(node.Branching.Choices) > 0 || node.End.Flag {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+	}
+
+	for _, choice := range node.Branching.Choices {
+		if node.Branching.Flag {
+			fmt.Fprintf(&b, "> %s -> %s", escapeText(choice.Text), dict.nodeSlug(choice.NextNode))
+		} else {
+			fmt.Fprintf(&b, "-> %s", dict.nodeSlug(choice.NextNode))
+		}
+
+		if choice.Condition != "" {
+			fmt.Fprintf(&b, " if %s", choice.Condition)
+		}
+
+		b.WriteString("\n")
+	}
+
+	if node.End.Flag {
+		b.WriteString("== END")
+
+		if node.End.EndResult != "" {
+			b.WriteString(" " + node.End.EndResult)
+		}
+
+		if node.End.EndText != "" {
+			b.WriteString(": " + escapeText(node.End.EndText))
+		}
+
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func formatEvent(b *strings.Builder, event models.Event, dict *Dictionary) {
+	stage := event.CharactersInEvent
+	character := event.Character
+	text := event.Text
+
+	switch event.Type {
+	case models.EventNarration:
+		b.WriteString(narrationLine(text) + "\n")
+		text = ""
+	case models.EventSpeech:
+		b.WriteString(dict.characterName(character))
+
+		// Эмоцию говорящего можно записать прямо в реплике
+		if emotions, ok := stage[character]; ok && len(stage) == 1 && len(emotions) > 0 {
+			fmt.Fprintf(b, " [%s]", formatEmotions(emotions))
+			stage = nil
+		}
+
+		b.WriteString(":")
+
+		if text != "" {
+			b.WriteString(" " + escapeText(text))
+		}
+
+		b.WriteString("\n")
+		character, text = 0, ""
+	case models.EventCharacterEnter, models.EventCharacterExit:
+		command := "enter"
+
+		if event.Type == models.EventCharacterExit {
+			command = "exit"
+		}
+
+		fmt.Fprintf(b, "@%s %s\n", command, dict.characterName(character))
+		character = 0
+	default:
+		b.WriteString(formatCommand(event) + "\n")
+	}
+
+	if character != 0 {
+		fmt.Fprintf(b, "~ character %s\n", dict.characterName(character))
+	}
+
+	if len(stage) > 0 {
+		fmt.Fprintf(b, "~ show %s\n", formatStage(stage, dict))
+	}
+
+	if event.Sound != 0 {
+		fmt.Fprintf(b, "~ sound %d\n", event.Sound)
+	}
+
+	if text != "" {
+		fmt.Fprintf(b, "~ text %s\n", escapeText(text))
+	}
+
+	for _, effect := range event.Effects {
+		fmt.Fprintf(b, "$ %s %s %d\n", effect.Variable, effectSigns[effect.Operation], effect.Value)
+	}
+}
+
+// narrationLine экранирует текст, который иначе разобрался бы как команда или реплика
+func narrationLine(text string) string {
+	line := escapeText(text)
+
+	if line == "" || line != strings.TrimSpace(line) || speakerLine.MatchString(line) {
+		return `\` + line
+	}
+
+	for _, prefix := range commandPrefixes {
+		if strings.HasPrefix(line, prefix) {
+			return `\` + line
+		}
+	}
+
+	return line
+}
+
+func formatCommand(event models.Event) string {
+	switch {
+	case event.Type == models.EventBackground && event.Background != nil:
+		line := fmt.Sprintf("@bg %d", event.Background.Media)
+
+		if event.Background.Transition != "" {
+			line += " " + event.Background.Transition
+		}
+
+		return line
+	case event.Type == models.EventMusic && event.Music != nil:
+		line := "@music " + event.Music.Action
+
+		if event.Music.Media != 0 {
+			line += fmt.Sprintf(" %d", event.Music.Media)
+		}
+
+		if event.Music.Loop {
+			line += " loop"
+		}
+
+		if event.Music.FadeMs != 0 {
+			line += fmt.Sprintf(" fade %d", event.Music.FadeMs)
+		}
+
+		return line
+	case event.Type == models.EventSoundEffect && event.SoundEffect != nil:
+		return fmt.Sprintf("@sfx %d %d", event.SoundEffect.Media, event.SoundEffect.Volume)
+	case event.Type == models.EventScreenEffect && event.Screen != nil:
+		line := fmt.Sprintf("@screen %s %d", event.Screen.Effect, event.Screen.DurationMs)
+
+		if event.Screen.Color != "" {
+			line += " " + event.Screen.Color
+		}
+
+		return line
+	case event.Type == models.EventWait && event.Wait != nil:
+		line := fmt.Sprintf("@wait %d", event.Wait.DurationMs)
+
+		if event.Wait.Skippable {
+			line += " skip"
+		}
+
+		return line
+	}
+
+	// Событие без данных своего типа записывается как текст рассказчика,
+	// остальные поля сохраняются строками ~
+	return `\`
+}
+
+func formatStage(stage map[int64]map[int64]int64, dict *Dictionary) string {
+	ids := make([]int64, 0, len(stage))
+
+	for id := range stage {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(a, b int) bool { return ids[a] < ids[b] })
+
+	parts := make([]string, 0, len(ids))
+
+	for _, id := range ids {
+		part := dict.characterName(id)
+
+		if len(stage[id]) > 0 {
+			part += " " + formatEmotions(stage[id])
+		}
+
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func formatEmotions(emotions map[int64]int64) string {
+	indexes := make([]int64, 0, len(emotions))
+
+	for emotion := range emotions {
+		indexes = append(indexes, emotion)
+	}
+
+	sort.Slice(indexes, func(a, b int) bool { return indexes[a] < indexes[b] })
+
+	parts := make([]string, 0, len(indexes))
+
+	for _, emotion := range indexes {
+		if emotions[emotion] == 0 {
+			parts = append(parts, fmt.Sprintf("%d", emotion))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d@%d", emotion, emotions[emotion]))
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
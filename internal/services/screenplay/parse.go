@@ -0,0 +1,581 @@
+package screenplay
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"vn/internal/models"
+)
+
+var (
+	speakerName = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N} _'.-]{0,39}$`)
+	speakerLine = regexp.MustCompile(`^([\p{L}\p{N}][\p{L}\p{N} _'.-]{0,39}|#\d+)\s*(?:\[([^\]]*)\])?:(?:\s+(.*))?$`)
+	// Реплика персонажа без имени: #123: текст
+	rawSpeakerLine = regexp.MustCompile(`^#\d+\s*(?:\[[^\]]*\])?:`)
+	effectLine     = regexp.MustCompile(`^\$\s*([A-Za-z_][A-Za-z0-9_]*)\s*(=|\+=|-=)\s*(-?\d+)$`)
+	stagePart      = regexp.MustCompile(`^(\d+)(?:@(-?\d+))?$`)
+)
+
+var effectOperations = map[string]string{
+	"=":  models.EffectSet,
+	"+=": models.EffectAdd,
+	"-=": models.EffectSub,
+}
+
+// Script - содержимое узла, записанное в тексте. Id событий не заполняются
+type Script struct {
+	Events     models.Events
+	Music      int64
+	Background int64
+	Branching  models.Branching
+	End        models.EndInfo
+	Comment    string
+}
+
+type parser struct {
+	dict       *Dictionary
+	script     *Script
+	line       int
+	hasScene   bool
+	transition bool // начались переходы, события дальше недопустимы
+	comments   []string
+}
+
+// Parse разбирает текст узла. Ошибка содержит номер строки
+func Parse(text string, dict *Dictionary) (*Script, error) {
+	p := &parser{dict: dict, script: &Script{Events: models.Events{}}}
+
+	scanner := bufio.NewScanner(strings.NewReader(strings.TrimPrefix(text, "\xef\xbb\xbf")))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		p.line++
+
+		if err := p.parseLine(strings.TrimSpace(scanner.Text())); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	p.script.Comment = strings.Join(p.comments, "\n")
+
+	return p.script, nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: line %d: %s", ErrInvalid, p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) parseLine(line string) error {
+	switch {
+	case line == "":
+		return nil
+	case strings.HasPrefix(line, `\`):
+		return p.addEvent(models.Event{Type: models.EventNarration, Text: unescapeText(line[1:])})
+	case strings.HasPrefix(line, "#") && !rawSpeakerLine.MatchString(line):
+		p.comments = append(p.comments, strings.TrimSpace(line[1:]))
+		return nil
+	case strings.HasPrefix(line, "=="):
+		return p.parseEnd(strings.TrimSpace(line[2:]))
+	case strings.HasPrefix(line, "->"):
+		return p.parseTransition("", strings.TrimSpace(line[2:]), false)
+	case strings.HasPrefix(line, ">"):
+		return p.parseChoice(strings.TrimSpace(line[1:]))
+	case strings.HasPrefix(line, "$"):
+		return p.parseEffect(line)
+	case strings.HasPrefix(line, "~"):
+		return p.parseAttachment(strings.TrimSpace(line[1:]))
+	case strings.HasPrefix(line, "@"):
+		return p.parseCommand(strings.Fields(line[1:]))
+	}
+
+	if match := speakerLine.FindStringSubmatch(line); match != nil {
+		characterId, ok := p.dict.character(match[1])
+
+		if ok {
+			event := models.Event{Type: models.EventSpeech, Character: characterId, Text: unescapeText(match[3])}
+
+			if match[2] != "" {
+				emotions, err := p.parseEmotions(strings.Fields(match[2]))
+
+				if err != nil {
+					return err
+				}
+
+				event.CharactersInEvent = map[int64]map[int64]int64{characterId: emotions}
+			}
+
+			return p.addEvent(event)
+		}
+
+		// Имя в верхнем регистре или с эмоцией - это реплика, а не текст с двоеточием
+		if match[2] != "" || strings.HasPrefix(match[1], "#") || isUpper(match[1]) {
+			return p.errorf("unknown character %q", match[1])
+		}
+	}
+
+	return p.addEvent(models.Event{Type: models.EventNarration, Text: unescapeText(line)})
+}
+
+func isUpper(s string) bool {
+	return strings.ToUpper(s) == s && strings.ToLower(s) != s
+}
+
+func (p *parser) addEvent(event models.Event) error {
+	if p.transition {
+		return p.errorf("event after transitions")
+	}
+
+	p.script.Events = append(p.script.Events, event)
+
+	return nil
+}
+
+// lastEvent - событие, к которому относятся строки ~ и $
+func (p *parser) lastEvent() (*models.Event, error) {
+	if len(p.script.Events) == 0 || p.transition {
+		return nil, p.errorf("no event to attach to")
+	}
+
+	return &p.script.Events[len(p.script.Events)-1], nil
+}
+
+func (p *parser) parseEnd(rest string) error {
+	if !strings.HasPrefix(rest, "END") {
+		return p.errorf("expected == END")
+	}
+
+	if p.script.End.Flag {
+		return p.errorf("duplicate END")
+	}
+
+	rest = strings.TrimPrefix(rest, "END")
+	result, text, _ := strings.Cut(rest, ":")
+
+	p.script.End = models.EndInfo{
+		Flag:      true,
+		EndResult: strings.TrimSpace(result),
+		EndText:   unescapeText(strings.TrimSpace(text)),
+	}
+	p.transition = true
+
+	return nil
+}
+
+func (p *parser) parseChoice(rest string) error {
+	i := strings.LastIndex(rest, "->")
+
+	if i < 0 {
+		return p.errorf("choice without target, expected > text -> slug")
+	}
+
+	return p.parseTransition(unescapeText(strings.TrimSpace(rest[:i])), strings.TrimSpace(rest[i+2:]), true)
+}
+
+func (p *parser) parseTransition(text string, target string, choice bool) error {
+	if len(p.script.Branching.Choices) > 0 && p.script.Branching.Flag != choice {
+		return p.errorf("choices and automatic transitions cannot be mixed")
+	}
+
+	slug, condition, _ := strings.Cut(target, " if ")
+	slug = strings.TrimSpace(slug)
+
+	if slug == "" {
+		return p.errorf("transition without target")
+	}
+
+	nodeId, ok := p.dict.node(slug)
+
+	if !ok {
+		return p.errorf("unknown node %q", slug)
+	}
+
+	p.script.Branching.Flag = choice
+	p.script.Branching.Choices = append(p.script.Branching.Choices, models.Choice{
+		Text:      text,
+		NextNode:  nodeId,
+		Condition: strings.TrimSpace(condition),
+	})
+	p.transition = true
+
+	return nil
+}
+
+func (p *parser) parseEffect(line string) error {
+	match := effectLine.FindStringSubmatch(line)
+
+	if match == nil {
+		return p.errorf("expected $ variable = value, += value or -= value")
+	}
+
+	event, err := p.lastEvent()
+
+	if err != nil {
+		return err
+	}
+
+	value, err := strconv.ParseInt(match[3], 10, 64)
+
+	if err != nil {
+		return p.errorf("invalid value %q", match[3])
+	}
+
+	event.Effects = append(event.Effects, models.Effect{Variable: match[1], Operation: effectOperations[match[2]], Value: value})
+
+	return nil
+}
+
+func (p *parser) parseAttachment(rest string) error {
+	keyword, value, _ := strings.Cut(rest, " ")
+	value = strings.TrimSpace(value)
+
+	event, err := p.lastEvent()
+
+	if err != nil {
+		return err
+	}
+
+	switch keyword {
+	case "show":
+		stage, err := p.parseStage(value)
+
+		if err != nil {
+			return err
+		}
+
+		if event.CharactersInEvent == nil {
+			event.CharactersInEvent = map[int64]map[int64]int64{}
+		}
+
+		for characterId, emotions := range stage {
+			event.CharactersInEvent[characterId] = emotions
+		}
+	case "sound":
+		event.Sound, err = p.parseMedia(value)
+
+		if err != nil {
+			return err
+		}
+	case "character":
+		characterId, ok := p.dict.character(value)
+
+		if !ok {
+			return p.errorf("unknown character %q", value)
+		}
+
+		event.Character = characterId
+	case "text":
+		event.Text = unescapeText(value)
+	default:
+		return p.errorf("unknown attachment %q, expected show, sound, character or text", keyword)
+	}
+
+	return nil
+}
+
+// parseStage разбирает "anna 2@30, boris 1@70"
+func (p *parser) parseStage(value string) (map[int64]map[int64]int64, error) {
+	stage := map[int64]map[int64]int64{}
+
+	for _, part := range strings.Split(value, ",") {
+		fields := strings.Fields(part)
+
+		if len(fields) == 0 {
+			return nil, p.errorf("empty character in show")
+		}
+
+		// Имя может состоять из нескольких слов, эмоции идут в конце
+		nameEnd := len(fields)
+
+		for nameEnd > 1 && stagePart.MatchString(fields[nameEnd-1]) {
+			nameEnd--
+		}
+
+		name := strings.Join(fields[:nameEnd], " ")
+		characterId, ok := p.dict.character(name)
+
+		if !ok {
+			return nil, p.errorf("unknown character %q", name)
+		}
+
+		emotions, err := p.parseEmotions(fields[nameEnd:])
+
+		if err != nil {
+			return nil, err
+		}
+
+		stage[characterId] = emotions
+	}
+
+	return stage, nil
+}
+
+// parseEmotions разбирает список "эмоция@позиция", позиция по умолчанию 0
+func (p *parser) parseEmotions(fields []string) (map[int64]int64, error) {
+	emotions := make(map[int64]int64, len(fields))
+
+	for _, field := range fields {
+		match := stagePart.FindStringSubmatch(field)
+
+		if match == nil {
+			return nil, p.errorf("invalid emotion %q, expected index@position", field)
+		}
+
+		emotion, _ := strconv.ParseInt(match[1], 10, 64)
+		position := int64(0)
+
+		if match[2] != "" {
+			position, _ = strconv.ParseInt(match[2], 10, 64)
+		}
+
+		emotions[emotion] = position
+	}
+
+	return emotions, nil
+}
+
+func (p *parser) parseMedia(value string) (int64, error) {
+	id, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil || id <= 0 {
+		return 0, p.errorf("invalid media id %q", value)
+	}
+
+	return id, nil
+}
+
+func (p *parser) parseNumber(value string) (int64, error) {
+	n, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil || n < 0 {
+		return 0, p.errorf("invalid number %q", value)
+	}
+
+	return n, nil
+}
+
+func (p *parser) parseCommand(fields []string) error {
+	if len(fields) == 0 {
+		return p.errorf("empty command")
+	}
+
+	command, args := fields[0], fields[1:]
+
+	if command == "scene" {
+		return p.parseScene(args)
+	}
+
+	event := models.Event{}
+	var err error
+
+	switch command {
+	case "bg":
+		event.Type = models.EventBackground
+		event.Background, err = p.parseBackground(args)
+	case "music":
+		event.Type = models.EventMusic
+		event.Music, err = p.parseMusic(args)
+	case "sfx":
+		event.Type = models.EventSoundEffect
+		event.SoundEffect, err = p.parseSoundEffect(args)
+	case "screen":
+		event.Type = models.EventScreenEffect
+		event.Screen, err = p.parseScreen(args)
+	case "wait":
+		event.Type = models.EventWait
+		event.Wait, err = p.parseWait(args)
+	case "enter", "exit":
+		event.Type = models.EventCharacterEnter
+
+		if command == "exit" {
+			event.Type = models.EventCharacterExit
+		}
+
+		if len(args) == 0 {
+			return p.errorf("@%s without character", command)
+		}
+
+		characterId, ok := p.dict.character(strings.Join(args, " "))
+
+		if !ok {
+			return p.errorf("unknown character %q", strings.Join(args, " "))
+		}
+
+		event.Character = characterId
+	default:
+		return p.errorf("unknown command @%s", command)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return p.addEvent(event)
+}
+
+func (p *parser) parseScene(args []string) error {
+	if p.hasScene {
+		return p.errorf("duplicate @scene")
+	}
+
+	if len(args) == 0 || len(args)%2 != 0 {
+		return p.errorf("expected @scene bg <media> music <media>")
+	}
+
+	for i := 0; i < len(args); i += 2 {
+		id, err := p.parseMedia(args[i+1])
+
+		if err != nil {
+			return err
+		}
+
+		switch args[i] {
+		case "bg":
+			p.script.Background = id
+		case "music":
+			p.script.Music = id
+		default:
+			return p.errorf("unknown @scene field %q", args[i])
+		}
+	}
+
+	p.hasScene = true
+
+	return nil
+}
+
+func (p *parser) parseBackground(args []string) (*models.BackgroundPayload, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, p.errorf("expected @bg <media> [cut|fade|dissolve]")
+	}
+
+	media, err := p.parseMedia(args[0])
+
+	if err != nil {
+		return nil, err
+	}
+
+	background := &models.BackgroundPayload{Media: media}
+
+	if len(args) == 2 {
+		background.Transition = args[1]
+	}
+
+	return background, nil
+}
+
+func (p *parser) parseMusic(args []string) (*models.MusicPayload, error) {
+	if len(args) == 0 {
+		return nil, p.errorf("expected @music play|stop|crossfade")
+	}
+
+	music := &models.MusicPayload{Action: args[0]}
+	args = args[1:]
+
+	switch music.Action {
+	case models.MusicPlay, models.MusicCrossfade:
+		if len(args) == 0 {
+			return nil, p.errorf("@music %s without media", music.Action)
+		}
+
+		media, err := p.parseMedia(args[0])
+
+		if err != nil {
+			return nil, err
+		}
+
+		music.Media = media
+		args = args[1:]
+	case models.MusicStop:
+	default:
+		return nil, p.errorf("unknown music action %q", music.Action)
+	}
+
+	for len(args) > 0 {
+		switch args[0] {
+		case "loop":
+			music.Loop = true
+			args = args[1:]
+		case "fade":
+			if len(args) < 2 {
+				return nil, p.errorf("fade without duration")
+			}
+
+			fade, err := p.parseNumber(args[1])
+
+			if err != nil {
+				return nil, err
+			}
+
+			music.FadeMs = fade
+			args = args[2:]
+		default:
+			return nil, p.errorf("unknown music option %q", args[0])
+		}
+	}
+
+	return music, nil
+}
+
+func (p *parser) parseSoundEffect(args []string) (*models.SoundEffectPayload, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, p.errorf("expected @sfx <media> [volume]")
+	}
+
+	media, err := p.parseMedia(args[0])
+
+	if err != nil {
+		return nil, err
+	}
+
+	sound := &models.SoundEffectPayload{Media: media, Volume: 100}
+
+	if len(args) == 2 {
+		sound.Volume, err = p.parseNumber(args[1])
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sound, nil
+}
+
+func (p *parser) parseScreen(args []string) (*models.ScreenEffectPayload, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, p.errorf("expected @screen <effect> <ms> [color]")
+	}
+
+	duration, err := p.parseNumber(args[1])
+
+	if err != nil {
+		return nil, err
+	}
+
+	screen := &models.ScreenEffectPayload{Effect: args[0], DurationMs: duration}
+
+	if len(args) == 3 {
+		screen.Color = args[2]
+	}
+
+	return screen, nil
+}
+
+func (p *parser) parseWait(args []string) (*models.WaitPayload, error) {
+	if len(args) == 0 || len(args) > 2 || (len(args) == 2 && args[1] != "skip") {
+		return nil, p.errorf("expected @wait <ms> [skip]")
+	}
+
+	duration, err := p.parseNumber(args[0])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.WaitPayload{DurationMs: duration, Skippable: len(args) == 2}, nil
+}
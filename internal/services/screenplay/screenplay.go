@@ -0,0 +1,181 @@
+// Package screenplay переводит текст узла в виде сценария в события, переходы
+// и ссылки на медиафайлы узла и обратно. Одна строка - одна команда:
+//
+//	# комментарий к узлу
+//	@scene bg 12 music 7          фон и музыка узла
+//	Текст рассказчика
+//	ANNA [2@30]: Привет!          реплика, эмоция 2 в позиции 30
+//	~ show anna 2@30, boris 1@70  персонажи на экране в предыдущем событии
+//	~ sound 15                    звук предыдущего события (озвучка)
+//	~ character anna              персонаж предыдущего события
+//	~ text Входит Анна            текст предыдущего события-команды
+//	$ trust += 1                  изменение переменной в предыдущем событии
+//	@bg 12 fade                   смена фона: cut, fade или dissolve
+//	@music play 7 loop fade 500   музыка: play, stop или crossfade
+//	@sfx 9 80                     звуковой эффект и громкость
+//	@screen shake 300 #ffffff     эффект экрана, длительность и цвет
+//	@wait 1000 skip               пауза, skip - можно пропустить
+//	@enter anna / @exit anna      персонаж появился или ушел
+//	> Пойти в лес -> forest if trust >= 2   вариант выбора
+//	-> forest if trust >= 2       автоматический переход
+//	== END good: Вы спаслись      концовка с результатом и текстом
+//
+// Персонажи указываются slug или именем без учета регистра, узлы - slug.
+// Если имени нет, используется id вида #123. Строка, начинающаяся с \,
+// всегда считается текстом рассказчика. В тексте \n - перевод строки
+package screenplay
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"vn/internal/models"
+)
+
+var ErrInvalid = errors.New("invalid screenplay")
+
+// Dictionary сопоставляет имена в тексте с id персонажей и узлов главы
+type Dictionary struct {
+	characters     map[string]int64 // slug и имя в нижнем регистре - id
+	characterNames map[int64]string
+	nodes          map[string]int64
+	nodeSlugs      map[int64]string
+}
+
+func NewDictionary(characters []models.Character, nodes []models.Node) *Dictionary {
+	dict := &Dictionary{
+		characters:     make(map[string]int64, len(characters)*2),
+		characterNames: make(map[int64]string, len(characters)),
+		nodes:          make(map[string]int64, len(nodes)),
+		nodeSlugs:      make(map[int64]string, len(nodes)),
+	}
+
+	// Сначала имена, затем slug, чтобы при совпадении slug одного персонажа
+	// с именем другого побеждал slug
+	for _, character := range characters {
+		if character.Name != "" {
+			dict.characters[strings.ToLower(character.Name)] = character.Id
+		}
+	}
+
+	for _, character := range characters {
+		if character.Slug != "" {
+			dict.characters[strings.ToLower(character.Slug)] = character.Id
+		}
+	}
+
+	for _, character := range characters {
+		switch {
+		case isName(character.Slug) && dict.characters[strings.ToLower(character.Slug)] == character.Id:
+			dict.characterNames[character.Id] = strings.ToUpper(character.Slug)
+		case isName(character.Name) && dict.characters[strings.ToLower(character.Name)] == character.Id:
+			dict.characterNames[character.Id] = character.Name
+		}
+	}
+
+	for _, node := range nodes {
+		if isSlug(node.Slug) {
+			dict.nodes[node.Slug] = node.Id
+			dict.nodeSlugs[node.Id] = node.Slug
+		}
+	}
+
+	return dict
+}
+
+// character ищет персонажа по имени, slug или id вида #123
+func (d *Dictionary) character(name string) (int64, bool) {
+	if id, ok := parseRawId(name); ok {
+		return id, true
+	}
+
+	id, ok := d.characters[strings.ToLower(strings.TrimSpace(name))]
+
+	return id, ok
+}
+
+func (d *Dictionary) node(slug string) (int64, bool) {
+	if id, ok := parseRawId(slug); ok {
+		return id, true
+	}
+
+	id, ok := d.nodes[slug]
+
+	return id, ok
+}
+
+func (d *Dictionary) characterName(id int64) string {
+	if name, ok := d.characterNames[id]; ok {
+		return name
+	}
+
+	return "#" + strconv.FormatInt(id, 10)
+}
+
+func (d *Dictionary) nodeSlug(id int64) string {
+	if slug, ok := d.nodeSlugs[id]; ok {
+		return slug
+	}
+
+	return "#" + strconv.FormatInt(id, 10)
+}
+
+func parseRawId(s string) (int64, bool) {
+	if !strings.HasPrefix(s, "#") {
+		return 0, false
+	}
+
+	id, err := strconv.ParseInt(s[1:], 10, 64)
+
+	return id, err == nil && id > 0
+}
+
+// isName - имя можно записать перед двоеточием реплики. Имя, которое кончается
+// числом, в строке ~ show спуталось бы с эмоцией
+func isName(s string) bool {
+	if s == "" || !speakerName.MatchString(s) {
+		return false
+	}
+
+	fields := strings.Fields(s)
+
+	return !stagePart.MatchString(fields[len(fields)-1])
+}
+
+// isSlug - slug можно записать в переходе без пробелов
+func isSlug(s string) bool {
+	return s != "" && !strings.ContainsAny(s, " \t") && !strings.HasPrefix(s, "#")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", "")
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+func unescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case '\\':
+				b.WriteByte('\\')
+				i++
+				continue
+			case 'n':
+				b.WriteByte('\n')
+				i++
+				continue
+			}
+		}
+
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
@@ -0,0 +1,127 @@
+package screenplay
+
+import (
+	"errors"
+	"testing"
+	"vn/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testDictionary() *Dictionary {
+	return NewDictionary(
+		[]models.Character{{Id: 5, Name: "Анна", Slug: "anna"}, {Id: 6, Name: "Борис", Slug: "boris"}},
+		[]models.Node{{Id: 100, Slug: "start"}, {Id: 101, Slug: "forest"}, {Id: 102, Slug: "home"}},
+	)
+}
+
+func TestParse(t *testing.T) {
+	text := `# Утро в деревне
+@scene bg 12 music 7
+
+@bg 13 fade
+Солнце встает.
+ANNA [2@30]: Привет!
+~ sound 15
+$ trust += 1
+@enter Борис
+~ show boris 1@70
+Время: полночь
+\> это не вариант
+
+> Пойти в лес -> forest if trust >= 2
+> Остаться -> home
+`
+
+	script, err := Parse(text, testDictionary())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Утро в деревне", script.Comment)
+	assert.Equal(t, int64(12), script.Background)
+	assert.Equal(t, int64(7), script.Music)
+	assert.Equal(t, models.Events{
+		{Type: models.EventBackground, Background: &models.BackgroundPayload{Media: 13, Transition: "fade"}},
+		{Type: models.EventNarration, Text: "Солнце встает."},
+		{
+			Type:              models.EventSpeech,
+			Character:         5,
+			Text:              "Привет!",
+			Sound:             15,
+			CharactersInEvent: map[int64]map[int64]int64{5: {2: 30}},
+			Effects:           []models.Effect{{Variable: "trust", Operation: models.EffectAdd, Value: 1}},
+		},
+		{Type: models.EventCharacterEnter, Character: 6, CharactersInEvent: map[int64]map[int64]int64{6: {1: 70}}},
+		{Type: models.EventNarration, Text: "Время: полночь"},
+		{Type: models.EventNarration, Text: "> это не вариант"},
+	}, script.Events)
+	assert.Equal(t, models.Branching{Flag: true, Choices: []models.Choice{
+		{Text: "Пойти в лес", NextNode: 101, Condition: "trust >= 2"},
+		{Text: "Остаться", NextNode: 102},
+	}}, script.Branching)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{name: "Неизвестный персонаж", text: "КАТЯ: Привет"},
+		{name: "Неизвестный узел", text: "-> nowhere"},
+		{name: "Выбор и автоматический переход", text: "> Да -> start\n-> home"},
+		{name: "Событие после переходов", text: "-> home\nТекст"},
+		{name: "Изменение без события", text: "$ trust = 1"},
+		{name: "Неизвестная команда", text: "@dance 3"},
+		{name: "Неверная эмоция", text: "ANNA [happy]: Привет"},
+		{name: "Музыка без файла", text: "@music play"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.text, testDictionary())
+			assert.True(t, errors.Is(err, ErrInvalid), err)
+		})
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	node := models.Node{
+		Comment:    "Развилка\nвторая строка",
+		Background: 12,
+		Events: models.Events{
+			{Type: models.EventNarration, Text: "@ не команда\nи перенос"},
+			{Type: models.EventNarration, Text: "Время: полночь"},
+			{Type: models.EventSpeech, Character: 5, Text: "Привет", CharactersInEvent: map[int64]map[int64]int64{5: {2: 30}, 6: {0: 70}}},
+			{Type: models.EventSpeech, Character: 99, Text: "Кто здесь?"},
+			{Type: models.EventMusic, Music: &models.MusicPayload{Action: models.MusicCrossfade, Media: 8, Loop: true, FadeMs: 500}},
+			{Type: models.EventSoundEffect, SoundEffect: &models.SoundEffectPayload{Media: 9, Volume: 80}},
+			{Type: models.EventScreenEffect, Screen: &models.ScreenEffectPayload{Effect: models.ScreenFlash, DurationMs: 200, Color: "#ffffff"}, Text: "Вспышка"},
+			{Type: models.EventWait, Wait: &models.WaitPayload{DurationMs: 1000, Skippable: true}},
+			{Type: models.EventCharacterExit, Character: 6, Effects: []models.Effect{{Variable: "met", Operation: models.EffectSet, Value: 1}}},
+		},
+		Branching: models.Branching{Choices: []models.Choice{{NextNode: 101, Condition: "met == 1"}, {NextNode: 555}}},
+		End:       models.EndInfo{Flag: true, EndResult: "good", EndText: "Конец"},
+	}
+
+	dict := testDictionary()
+	text := Format(node, dict)
+
+	script, err := Parse(text, dict)
+
+	assert.NoError(t, err, text)
+	assert.Equal(t, node.Comment, script.Comment)
+	assert.Equal(t, node.Background, script.Background)
+	assert.Equal(t, node.Events, script.Events)
+	assert.Equal(t, node.Branching, script.Branching)
+	assert.Equal(t, node.End, script.End)
+}
+
+func TestFormat(t *testing.T) {
+	node := models.Node{
+		Events: models.Events{
+			{Type: models.EventSpeech, Character: 5, Text: "Привет", CharactersInEvent: map[int64]map[int64]int64{5: {2: 0}}},
+		},
+		Branching: models.Branching{Flag: true, Choices: []models.Choice{{Text: "Дальше", NextNode: 101}}},
+	}
+
+	assert.Equal(t, "ANNA [2]: Привет\n\n> Дальше -> forest\n", Format(node, testDictionary()))
+}
@@ -122,7 +122,8 @@ func SelectChapterWIthId(db *gorm.DB, id int64) (models.Chapter, error) {
                CAST(characters AS TEXT) as characters_raw,
                status,
                CAST(updated_at AS TEXT) as updated_at_raw,
-               author
+               author,
+               version
         FROM chapters
         WHERE id = $1
         LIMIT 1
@@ -148,6 +149,7 @@ func SelectChapterWIthId(db *gorm.DB, id int64) (models.Chapter, error) {
 		&chapter.Status,
 		&updatedAtRaw,
 		&chapter.Author,
+		&chapter.Version,
 	)
 
 	log.Println(chapter)
@@ -180,6 +182,11 @@ func SelectChapterWIthId(db *gorm.DB, id int64) (models.Chapter, error) {
 	return chapter, nil
 }
 
+// ErrVersionConflict - запись изменилась после того, как ее прочитали
+var ErrVersionConflict = errors.New("version conflict")
+
+// UpdateChapter сохраняет главу, только если ее версия совпадает с newChapter.Version,
+// и увеличивает версию
 func UpdateChapter(db *gorm.DB, id int64, newChapter models.Chapter) (models.Chapter, error) {
 	var chapter models.Chapter
 
@@ -191,19 +198,32 @@ func UpdateChapter(db *gorm.DB, id int64, newChapter models.Chapter) (models.Cha
 		return models.Chapter{}, err
 	}
 
-	result := db.Model(&chapter).
-		Where("id = ?", id).
-		Updates(map[string]interface{}{
-			"name":       newChapter.Name,
-			"StartNode":  newChapter.StartNode,
-			"Nodes":      json.RawMessage(nodesJSON),
-			"Characters": json.RawMessage(charactersJSON),
-			"Status":     newChapter.Status,
-			"UpdatedAt":  json.RawMessage(updatedInfoJSON),
-		})
+	// Изменение и ревизия сохраняются вместе
+	err = db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&chapter).
+			Where("id = ? AND version = ?", id, newChapter.Version).
+			Updates(map[string]interface{}{
+				"name":       newChapter.Name,
+				"StartNode":  newChapter.StartNode,
+				"Nodes":      json.RawMessage(nodesJSON),
+				"Characters": json.RawMessage(charactersJSON),
+				"Status":     newChapter.Status,
+				"UpdatedAt":  json.RawMessage(updatedInfoJSON),
+				"Version":    gorm.Expr("version + 1"),
+			})
+
+		if result.RowsAffected == 0 {
+			return notUpdated(tx, &models.Chapter{}, id, "chapter data not update")
+		}
 
-	if result.RowsAffected == 0 {
-		return models.Chapter{}, errors.New("chapter data not update")
+		newChapter.Id = id
+		newChapter.Version++
+
+		return saveRevision(tx, models.RevisionChapter, id, id, lastEditor(newChapter.UpdatedAt), newChapter)
+	})
+
+	if err != nil {
+		return models.Chapter{}, err
 	}
 
 	return chapter, nil
@@ -229,7 +249,8 @@ func GetChaptersForAdmin(db *gorm.DB) ([]models.Chapter, error) {
             CAST(COALESCE(characters, '[]'::json) AS TEXT) as characters_raw,
             status,
             CAST(updated_at AS TEXT) as updated_at_raw,
-            author
+            author,
+            version
         FROM chapters
     `
 
@@ -256,6 +277,7 @@ func GetChaptersForAdmin(db *gorm.DB) ([]models.Chapter, error) {
 			&chapter.Status,
 			&updatedAtRaw,
 			&chapter.Author,
+			&chapter.Version,
 		)
 
 		if err != nil {
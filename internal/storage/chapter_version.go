@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"vn/internal/models"
+)
+
+func SaveChapterVersion(db *gorm.DB, version *models.ChapterVersion) error {
+	result := db.Omit("id").Create(version)
+
+	if result.Error != nil {
+		return fmt.Errorf("ошибка при сохранении версии главы: %w", result.Error)
+	}
+
+	return nil
+}
+
+// SelectLatestChapterVersion возвращает последнюю опубликованную версию главы или nil, если версий нет
+func SelectLatestChapterVersion(db *gorm.DB, chapterId int64) (*models.ChapterVersion, error) {
+	var version models.ChapterVersion
+	err := db.Where("chapter_id = ?", chapterId).Order("version DESC").First(&version).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// SelectLatestChapterVersions возвращает последние версии указанных глав по id главы
+func SelectLatestChapterVersions(db *gorm.DB, chapterIds []int64) (map[int64]models.ChapterVersion, error) {
+	versions := make(map[int64]models.ChapterVersion, len(chapterIds))
+
+	if len(chapterIds) == 0 {
+		return versions, nil
+	}
+
+	var rows []models.ChapterVersion
+	result := db.Raw(`SELECT DISTINCT ON (chapter_id) * FROM chapter_versions WHERE chapter_id IN ? ORDER BY chapter_id, version DESC`, chapterIds).
+		Scan(&rows)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	for _, version := range rows {
+		versions[version.ChapterId] = version
+	}
+
+	return versions, nil
+}
+
+func SelectChapterVersion(db *gorm.DB, id int64) (*models.ChapterVersion, error) {
+	var version models.ChapterVersion
+	err := db.First(&version, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("версия главы с ID %d не найдена", id)
+	}
+	return &version, err
+}
+
+func SelectChapterVersions(db *gorm.DB, chapterId int64) ([]models.ChapterVersion, error) {
+	var versions []models.ChapterVersion
+	result := db.Where("chapter_id = ?", chapterId).Order("version").Find(&versions)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return versions, nil
+}
+
+// PinPlayerVersion закрепляет за игроком версию главы, с которой он начал прохождение
+func PinPlayerVersion(db *gorm.DB, playerId int64, chapterId int64, versionId int64) error {
+	pin := models.PlayerChapterVersion{PlayerId: playerId, ChapterId: chapterId, VersionId: versionId}
+
+	result := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "player_id"}, {Name: "chapter_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"version_id"}),
+	}).Omit("id").Create(&pin)
+
+	if result.Error != nil {
+		return fmt.Errorf("ошибка при сохранении версии главы игрока: %w", result.Error)
+	}
+
+	return nil
+}
+
+// SelectPlayerVersion возвращает id версии главы, которую проходит игрок, или 0
+func SelectPlayerVersion(db *gorm.DB, playerId int64, chapterId int64) (int64, error) {
+	var pin models.PlayerChapterVersion
+	err := db.Where("player_id = ? AND chapter_id = ?", playerId, chapterId).First(&pin).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return pin.VersionId, nil
+}
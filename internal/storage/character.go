@@ -115,6 +115,48 @@ func SelectCharacters(db *gorm.DB) ([]models.Character, error) {
 	return characters, rows.Err()
 }
 
+// SelectCharactersWithIds загружает персонажей по списку id
+func SelectCharactersWithIds(db *gorm.DB, ids []int64) (map[int64]models.Character, error) {
+	characters := make(map[int64]models.Character, len(ids))
+
+	if len(ids) == 0 {
+		return characters, nil
+	}
+
+	query := `
+        SELECT id, name, slug, color, 
+               CAST(emotions AS TEXT) as emotions_raw
+        FROM characters
+        WHERE id IN ?
+    `
+
+	rows, err := db.Raw(query, ids).Rows()
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var c models.Character
+		var emotionsRaw string
+
+		err = rows.Scan(&c.Id, &c.Name, &c.Slug, &c.Color, &emotionsRaw)
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal([]byte(emotionsRaw), &c.Emotions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal emotions: %w", err)
+		}
+
+		characters[c.Id] = c
+	}
+
+	return characters, rows.Err()
+}
+
 func UpdateCharacter(db *gorm.DB, id int64, newCharacter models.Character) (models.Character, error) {
 	var character models.Character
 
@@ -42,3 +42,47 @@ func DeleteMedia(db *gorm.DB, id int64) (int64, error) {
 	}
 	return result.RowsAffected, nil
 }
+
+// SelectExistingMediaIds возвращает те id из списка, для которых есть медиафайлы.
+// Сами файлы не загружаются
+func SelectExistingMediaIds(db *gorm.DB, ids []int64) (map[int64]bool, error) {
+	existing := make(map[int64]bool, len(ids))
+
+	if len(ids) == 0 {
+		return existing, nil
+	}
+
+	var found []int64
+	result := db.Model(&models.Media{}).Where("id IN ?", ids).Pluck("id", &found)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	for _, id := range found {
+		existing[id] = true
+	}
+
+	return existing, nil
+}
+
+// SelectMediaInfo возвращает описания медиафайлов по списку id без загрузки содержимого
+func SelectMediaInfo(db *gorm.DB, ids []int64) ([]models.MediaInfo, error) {
+	var info []models.MediaInfo
+
+	if len(ids) == 0 {
+		return info, nil
+	}
+
+	result := db.Model(&models.Media{}).
+		Select("id, content_type, octet_length(file_data) as size").
+		Where("id IN ?", ids).
+		Order("id").
+		Scan(&info)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return info, nil
+}
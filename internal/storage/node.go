@@ -12,7 +12,7 @@ import (
 func RegisterNode(db *gorm.DB, node models.Node) (int64, error) {
 	// Инициализация значений по умолчанию...
 	if node.Events == nil {
-		node.Events = map[int]models.Event{}
+		node.Events = models.Events{}
 	}
 
 	node.Branching = models.Branching{}
@@ -114,12 +114,8 @@ func RegisterNode(db *gorm.DB, node models.Node) (int64, error) {
 //	return node, nil
 //}
 
-func SelectNodeWIthId(db *gorm.DB, nodeId int64) (*models.Node, error) {
-	var nodes []models.Node
-
-	// Используем raw SQL с явной обработкой JSON
-	query := `
-        SELECT 
+// nodeColumns - колонки узла, JSON поля читаются как текст
+const nodeColumns = `
             id,
             slug,
             chapter_id,
@@ -128,11 +124,19 @@ func SelectNodeWIthId(db *gorm.DB, nodeId int64) (*models.Node, error) {
             CAST(events AS TEXT) as events_raw,
             CAST(branching AS TEXT) as branching_raw,
             CAST(end_info AS TEXT) as end_raw,
-            comment
+            comment,
+            version
         FROM nodes
-    `
+`
 
-	rows, err := db.Raw(query).Rows()
+// selectNodes читает узлы по условию, условие должно использовать индекс
+func selectNodes(db *gorm.DB, condition string, args ...interface{}) ([]models.Node, error) {
+	var nodes []models.Node
+
+	// Используем raw SQL с явной обработкой JSON
+	query := `SELECT ` + nodeColumns + ` WHERE ` + condition
+
+	rows, err := db.Raw(query, args...).Rows()
 	if err != nil {
 		return nil, err
 	}
@@ -158,6 +162,7 @@ func SelectNodeWIthId(db *gorm.DB, nodeId int64) (*models.Node, error) {
 			&branchingRaw,
 			&endRaw,
 			&n.Comment,
+			&n.Version,
 		)
 		if err != nil {
 			return nil, err
@@ -179,13 +184,34 @@ func SelectNodeWIthId(db *gorm.DB, nodeId int64) (*models.Node, error) {
 		nodes = append(nodes, n)
 	}
 
-	for _, i := range nodes {
-		if i.Id == nodeId {
-			return &i, err
-		}
+	return nodes, rows.Err()
+}
+
+// SelectNodeWIthId возвращает узел по id или nil, если узла нет
+func SelectNodeWIthId(db *gorm.DB, nodeId int64) (*models.Node, error) {
+	nodes, err := selectNodes(db, `id = ? LIMIT 1`, nodeId)
+
+	if err != nil || len(nodes) == 0 {
+		return nil, err
 	}
 
-	return nil, rows.Err()
+	return &nodes[0], nil
+}
+
+// SelectNodesByChapterId загружает все узлы главы одним запросом
+func SelectNodesByChapterId(db *gorm.DB, chapterId int64) ([]models.Node, error) {
+	return selectNodes(db, `chapter_id = ? ORDER BY id`, chapterId)
+}
+
+// SelectNodeWithSlug возвращает узел главы по slug или nil, если узла нет
+func SelectNodeWithSlug(db *gorm.DB, chapterId int64, slug string) (*models.Node, error) {
+	nodes, err := selectNodes(db, `chapter_id = ? AND slug = ? LIMIT 1`, chapterId, slug)
+
+	if err != nil || len(nodes) == 0 {
+		return nil, err
+	}
+
+	return &nodes[0], nil
 }
 
 //func UpdateNode(db *gorm.DB, id int64, newNode models.Node) (models.Node, error) {
@@ -197,8 +223,15 @@ func SelectNodeWIthId(db *gorm.DB, nodeId int64) (*models.Node, error) {
 //	return node, nil
 //}
 
-func UpdateNode(db *gorm.DB, id int64, newNode models.Node) (models.Node, error) {
+// UpdateNode сохраняет узел, только если его версия совпадает с newNode.Version,
+// и увеличивает версию. author записывается в ревизию, 0 - автор неизвестен
+func UpdateNode(db *gorm.DB, id int64, newNode models.Node, author int64) (models.Node, error) {
 	var node models.Node
+
+	if err := checkBranchingTargets(db, newNode.ChapterId, newNode.Branching); err != nil {
+		return models.Node{}, err
+	}
+
 	// Сериализуем JSON поля
 	eventsJSON, err := json.Marshal(newNode.Events)
 	if err != nil {
@@ -218,20 +251,34 @@ func UpdateNode(db *gorm.DB, id int64, newNode models.Node) (models.Node, error)
 	}
 
 	// Обновляем данные с использованием raw SQL
-	result := db.Model(&node).
-		Where("id = ?", id).
-		Updates(map[string]interface{}{
-			"slug":       newNode.Slug,
-			"events":     json.RawMessage(eventsJSON),
-			"chapter_id": newNode.ChapterId,
-			"music":      newNode.Music,
-			"background": newNode.Background,
-			"branching":  json.RawMessage(branchingJSON),
-			"end_info":   json.RawMessage(endInfoJSON),
-		})
+	// Изменение и ревизия сохраняются вместе
+	err = db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&node).
+			Where("id = ? AND version = ?", id, newNode.Version).
+			Updates(map[string]interface{}{
+				"slug":       newNode.Slug,
+				"events":     json.RawMessage(eventsJSON),
+				"chapter_id": newNode.ChapterId,
+				"music":      newNode.Music,
+				"background": newNode.Background,
+				"branching":  json.RawMessage(branchingJSON),
+				"end_info":   json.RawMessage(endInfoJSON),
+				"comment":    newNode.Comment,
+				"version":    gorm.Expr("version + 1"),
+			})
+
+		if result.RowsAffected == 0 {
+			return notUpdated(tx, &models.Node{}, id, "node data not update")
+		}
 
-	if result.RowsAffected == 0 {
-		return models.Node{}, errors.New("node data not update")
+		newNode.Id = id
+		newNode.Version++
+
+		return saveRevision(tx, models.RevisionNode, id, newNode.ChapterId, author, newNode)
+	})
+
+	if err != nil {
+		return models.Node{}, err
 	}
 
 	return node, nil
@@ -259,3 +306,34 @@ func GetNodeById(db *gorm.DB, id int64) (models.Node, error) {
 
 	return node, nil
 }
+
+// checkBranchingTargets проверяет, что все варианты выбора ведут в узлы той же главы
+func checkBranchingTargets(db *gorm.DB, chapterId int64, branching models.Branching) error {
+	targets := branching.Targets()
+
+	if len(targets) == 0 {
+		return nil
+	}
+
+	var ids []int64
+	result := db.Model(&models.Node{}).
+		Where("chapter_id = ? AND id IN ?", chapterId, targets).
+		Pluck("id", &ids)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to check branching targets: %w", result.Error)
+	}
+
+	found := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		found[id] = true
+	}
+
+	for i, target := range targets {
+		if !found[target] {
+			return fmt.Errorf("choice %d leads to node %d outside of chapter %d", i, target, chapterId)
+		}
+	}
+
+	return nil
+}
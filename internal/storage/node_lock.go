@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+	"vn/internal/models"
+)
+
+// AcquireNodeLock ставит или продлевает блокировку узла. Чужая действующая блокировка
+// перезаписывается только при force. Возвращает false, если узел занят другим админом
+func AcquireNodeLock(db *gorm.DB, lock models.NodeLock, now time.Time, force bool) (bool, error) {
+	conflict := clause.OnConflict{
+		Columns:   []clause.Column{{Name: "node_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"admin_id", "expires_at"}),
+	}
+
+	if !force {
+		conflict.Where = clause.Where{Exprs: []clause.Expression{
+			clause.Or(
+				clause.Eq{Column: clause.Column{Table: "node_locks", Name: "admin_id"}, Value: lock.AdminId},
+				clause.Lt{Column: clause.Column{Table: "node_locks", Name: "expires_at"}, Value: now},
+			),
+		}}
+	}
+
+	result := db.Clauses(conflict).Create(&lock)
+
+	if result.Error != nil {
+		return false, fmt.Errorf("ошибка при блокировке узла: %w", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// RenewNodeLock продлевает действующую блокировку админа. Возвращает false, если админ ее не держит
+func RenewNodeLock(db *gorm.DB, nodeId int64, adminId int64, now time.Time, expiresAt time.Time) (bool, error) {
+	result := db.Model(&models.NodeLock{}).
+		Where("node_id = ? AND admin_id = ? AND expires_at >= ?", nodeId, adminId, now).
+		Update("expires_at", expiresAt)
+
+	if result.Error != nil {
+		return false, fmt.Errorf("ошибка при продлении блокировки узла: %w", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// SelectNodeLock возвращает действующую блокировку узла или nil
+func SelectNodeLock(db *gorm.DB, nodeId int64, now time.Time) (*models.NodeLock, error) {
+	var lock models.NodeLock
+	err := db.Where("node_id = ? AND expires_at >= ?", nodeId, now).First(&lock).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// DeleteNodeLock снимает блокировку узла, только если ее держит adminId
+func DeleteNodeLock(db *gorm.DB, nodeId int64, adminId int64) (int64, error) {
+	result := db.Where("node_id = ? AND admin_id = ?", nodeId, adminId).Delete(&models.NodeLock{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+	"vn/internal/models"
+)
+
+// saveRevision сохраняет состояние сущности после изменения
+func saveRevision(db *gorm.DB, entityType string, entityId int64, chapterId int64, author int64, entity interface{}) error {
+	data, err := json.Marshal(entity)
+
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга ревизии: %w", err)
+	}
+
+	revision := models.Revision{
+		EntityType: entityType,
+		EntityId:   entityId,
+		ChapterId:  chapterId,
+		Author:     author,
+		Data:       data,
+	}
+
+	result := db.Omit("id").Create(&revision)
+
+	if result.Error != nil {
+		return fmt.Errorf("ошибка при сохранении ревизии: %w", result.Error)
+	}
+
+	return nil
+}
+
+// lastEditor возвращает автора последнего изменения из истории UpdatedAt
+func lastEditor(updatedAt map[time.Time]int64) int64 {
+	var (
+		last   time.Time
+		author int64
+	)
+
+	for at, id := range updatedAt {
+		if at.After(last) {
+			last, author = at, id
+		}
+	}
+
+	return author
+}
+
+func SelectRevision(db *gorm.DB, id int64) (*models.Revision, error) {
+	var revision models.Revision
+	err := db.First(&revision, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("ревизия с ID %d не найдена", id)
+	}
+	return &revision, err
+}
+
+// SelectRevisions возвращает ревизии сущности, новые первыми
+func SelectRevisions(db *gorm.DB, entityType string, entityId int64) ([]models.Revision, error) {
+	var revisions []models.Revision
+	result := db.Where("entity_type = ? AND entity_id = ?", entityType, entityId).Order("id DESC").Find(&revisions)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return revisions, nil
+}
+
+// notUpdated отличает конфликт версий от отсутствующей записи, когда обновление не затронуло строк
+func notUpdated(db *gorm.DB, model interface{}, id int64, message string) error {
+	var count int64
+
+	if err := db.Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return ErrVersionConflict
+	}
+
+	return errors.New(message)
+}
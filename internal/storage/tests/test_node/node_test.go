@@ -17,8 +17,8 @@ func TestRegisterNode(t *testing.T) {
 			name: "Успешная регистрация",
 			inputNode: models.Node{
 				Slug: "test-slug",
-				Events: map[int]models.Event{
-					1: {},
+				Events: models.Events{
+					{Id: 1},
 				},
 				Branching:  models.Branching{},
 				End:        models.EndInfo{},
@@ -77,8 +77,8 @@ func TestSelectNodeWithId(t *testing.T) {
 			expectedNode: &models.Node{
 				Id:   1,
 				Slug: "test-slug",
-				Events: map[int]models.Event{
-					1: {},
+				Events: models.Events{
+					{Id: 1},
 				},
 				Branching:  models.Branching{},
 				End:        models.EndInfo{},
@@ -134,8 +134,8 @@ func TestUpdateNode(t *testing.T) {
 			id:   1,
 			newNode: models.Node{
 				Slug: "updated-slug",
-				Events: map[int]models.Event{
-					1: {},
+				Events: models.Events{
+					{Id: 1},
 				},
 				Branching:  models.Branching{},
 				End:        models.EndInfo{},
@@ -146,8 +146,8 @@ func TestUpdateNode(t *testing.T) {
 			expectedNode: models.Node{
 				Id:   1,
 				Slug: "updated-slug",
-				Events: map[int]models.Event{
-					1: {},
+				Events: models.Events{
+					{Id: 1},
 				},
 				Branching:  models.Branching{},
 				End:        models.EndInfo{},
@@ -252,8 +252,8 @@ func TestGetNodeById(t *testing.T) {
 			expectedNode: models.Node{
 				Id:   1,
 				Slug: "test-slug",
-				Events: map[int]models.Event{
-					1: {},
+				Events: models.Events{
+					{Id: 1},
 				},
 				Branching:  models.Branching{},
 				End:        models.EndInfo{},
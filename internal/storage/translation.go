@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"vn/internal/models"
+)
+
+// SaveTranslations добавляет переводы или обновляет существующие с тем же языком и ключом
+func SaveTranslations(db *gorm.DB, translations []models.Translation) error {
+	if len(translations) == 0 {
+		return nil
+	}
+
+	result := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "locale"}, {Name: "chapter_id"}, {Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"source", "text", "updated_at"}),
+	}).Create(&translations)
+
+	if result.Error != nil {
+		return fmt.Errorf("ошибка при сохранении переводов: %w", result.Error)
+	}
+
+	return nil
+}
+
+// SelectTranslations возвращает переводы глав на указанные языки
+func SelectTranslations(db *gorm.DB, locales []string, chapterIds []int64) ([]models.Translation, error) {
+	var translations []models.Translation
+
+	result := db.Where("locale IN ? AND chapter_id IN ?", locales, chapterIds).Find(&translations)
+	if result.Error != nil {
+		return nil, fmt.Errorf("ошибка при получении переводов: %w", result.Error)
+	}
+
+	return translations, nil
+}
@@ -0,0 +1,294 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+	"vn/internal/models"
+)
+
+func SaveDeletedChapter(db *gorm.DB, deleted models.DeletedChapter) error {
+	result := db.Create(&deleted)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.New("deleted chapter not saved")
+	}
+
+	return nil
+}
+
+func SelectDeletedChapter(db *gorm.DB, id int64) (*models.DeletedChapter, error) {
+	var deleted models.DeletedChapter
+	err := db.First(&deleted, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("удаленная глава с ID %d не найдена", id)
+	}
+	return &deleted, err
+}
+
+func SelectDeletedChapters(db *gorm.DB) ([]models.DeletedChapter, error) {
+	var deleted []models.DeletedChapter
+	result := db.Order("deleted_at DESC").Find(&deleted)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return deleted, nil
+}
+
+func DeleteDeletedChapter(db *gorm.DB, id int64) error {
+	return db.Where("id = ?", id).Delete(&models.DeletedChapter{}).Error
+}
+
+// PurgeDeletedChapters окончательно удаляет главы, удаленные раньше before
+func PurgeDeletedChapters(db *gorm.DB, before time.Time) (int64, error) {
+	result := db.Where("deleted_at < ?", before).Delete(&models.DeletedChapter{})
+	return result.RowsAffected, result.Error
+}
+
+// RestoreChapter вставляет главу с ее прежним id
+func RestoreChapter(db *gorm.DB, chapter models.Chapter) error {
+	nodesJSON, err := json.Marshal(chapter.Nodes)
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга Nodes: %w", err)
+	}
+
+	charactersJSON, err := json.Marshal(chapter.Characters)
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга Characters: %w", err)
+	}
+
+	updatedAtJSON, err := json.Marshal(chapter.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга UpdatedAt: %w", err)
+	}
+
+	result := db.Model(&models.Chapter{}).
+		Create(map[string]interface{}{
+			"id":         chapter.Id,
+			"name":       chapter.Name,
+			"nodes":      json.RawMessage(nodesJSON),
+			"characters": json.RawMessage(charactersJSON),
+			"status":     chapter.Status,
+			"author":     chapter.Author,
+			"start_node": chapter.StartNode,
+			"updated_at": json.RawMessage(updatedAtJSON),
+			"version":    chapter.Version,
+		})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("не удалось восстановить главу")
+	}
+
+	return nil
+}
+
+// RestoreNode вставляет узел со всеми полями, в отличие от RegisterNode
+func RestoreNode(db *gorm.DB, node models.Node) error {
+	eventsJSON, err := json.Marshal(node.Events)
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга Events: %w", err)
+	}
+
+	branchingJSON, err := json.Marshal(node.Branching)
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга Branching: %w", err)
+	}
+
+	endInfoJSON, err := json.Marshal(node.End)
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга End: %w", err)
+	}
+
+	result := db.Model(&models.Node{}).
+		Create(map[string]interface{}{
+			"id":         node.Id,
+			"slug":       node.Slug,
+			"events":     json.RawMessage(eventsJSON),
+			"chapter_id": node.ChapterId,
+			"music":      node.Music,
+			"background": node.Background,
+			"branching":  json.RawMessage(branchingJSON),
+			"end_info":   json.RawMessage(endInfoJSON),
+			"comment":    node.Comment,
+			"version":    node.Version,
+		})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("не удалось восстановить узел")
+	}
+
+	return nil
+}
+
+func DeleteNodesByChapterId(db *gorm.DB, chapterId int64) error {
+	return db.Where("chapter_id = ?", chapterId).Delete(&models.Node{}).Error
+}
+
+func SelectRequestsByChapterId(db *gorm.DB, chapterId int64) ([]models.Request, error) {
+	var requests []models.Request
+	result := db.Where("requested_chapter_id = ?", chapterId).Find(&requests)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return requests, nil
+}
+
+func DeleteRequestsByChapterId(db *gorm.DB, chapterId int64) error {
+	return db.Where("requested_chapter_id = ?", chapterId).Delete(&models.Request{}).Error
+}
+
+func RestoreRequests(db *gorm.DB, requests []models.Request) error {
+	if len(requests) == 0 {
+		return nil
+	}
+
+	return db.Create(&requests).Error
+}
+
+func DeleteVariablesByChapterId(db *gorm.DB, chapterId int64) error {
+	return db.Where("chapter_id = ?", chapterId).Delete(&models.Variable{}).Error
+}
+
+func RestoreVariables(db *gorm.DB, variables []models.Variable) error {
+	if len(variables) == 0 {
+		return nil
+	}
+
+	return db.Create(&variables).Error
+}
+
+// DeleteNodeLocks снимает блокировки узлов удаленной главы
+func DeleteNodeLocks(db *gorm.DB, nodeIds []int64) error {
+	if len(nodeIds) == 0 {
+		return nil
+	}
+
+	return db.Where("node_id IN ?", nodeIds).Delete(&models.NodeLock{}).Error
+}
+
+func DeleteChapterVersionsByChapterId(db *gorm.DB, chapterId int64) error {
+	return db.Where("chapter_id = ?", chapterId).Delete(&models.ChapterVersion{}).Error
+}
+
+// RestoreChapterVersions вставляет версии с прежними id, на них ссылаются версии игроков
+func RestoreChapterVersions(db *gorm.DB, versions []models.ChapterVersion) error {
+	if len(versions) == 0 {
+		return nil
+	}
+
+	return db.Create(&versions).Error
+}
+
+func SelectPlayerVersionsByChapterId(db *gorm.DB, chapterId int64) ([]models.PlayerChapterVersion, error) {
+	var pins []models.PlayerChapterVersion
+	result := db.Where("chapter_id = ?", chapterId).Find(&pins)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return pins, nil
+}
+
+func DeletePlayerVersionsByChapterId(db *gorm.DB, chapterId int64) error {
+	return db.Where("chapter_id = ?", chapterId).Delete(&models.PlayerChapterVersion{}).Error
+}
+
+func RestorePlayerVersions(db *gorm.DB, pins []models.PlayerChapterVersion) error {
+	if len(pins) == 0 {
+		return nil
+	}
+
+	return db.Create(&pins).Error
+}
+
+// SelectPlayerVariablesByChapterId возвращает значения переменных главы у всех игроков
+func SelectPlayerVariablesByChapterId(db *gorm.DB, chapterId int64) ([]models.PlayerVariable, error) {
+	var variables []models.PlayerVariable
+	result := db.Where("chapter_id = ?", chapterId).Find(&variables)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return variables, nil
+}
+
+func DeletePlayerVariablesByChapterId(db *gorm.DB, chapterId int64) error {
+	return db.Where("chapter_id = ?", chapterId).Delete(&models.PlayerVariable{}).Error
+}
+
+func RestorePlayerVariables(db *gorm.DB, variables []models.PlayerVariable) error {
+	if len(variables) == 0 {
+		return nil
+	}
+
+	return db.Create(&variables).Error
+}
+
+// SelectRevisionsByChapterId возвращает ревизии главы и всех ее узлов
+func SelectRevisionsByChapterId(db *gorm.DB, chapterId int64) ([]models.Revision, error) {
+	var revisions []models.Revision
+	result := db.Where("chapter_id = ?", chapterId).Order("id").Find(&revisions)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return revisions, nil
+}
+
+func DeleteRevisionsByChapterId(db *gorm.DB, chapterId int64) error {
+	return db.Where("chapter_id = ?", chapterId).Delete(&models.Revision{}).Error
+}
+
+func RestoreRevisions(db *gorm.DB, revisions []models.Revision) error {
+	if len(revisions) == 0 {
+		return nil
+	}
+
+	return db.Create(&revisions).Error
+}
+
+// SelectTranslationsByChapterId возвращает переводы главы на все языки.
+// Переводы имен персонажей общие для всех глав и сюда не входят
+func SelectTranslationsByChapterId(db *gorm.DB, chapterId int64) ([]models.Translation, error) {
+	var translations []models.Translation
+	result := db.Where("chapter_id = ?", chapterId).Find(&translations)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return translations, nil
+}
+
+func DeleteTranslationsByChapterId(db *gorm.DB, chapterId int64) error {
+	return db.Where("chapter_id = ?", chapterId).Delete(&models.Translation{}).Error
+}
+
+func RestoreTranslations(db *gorm.DB, translations []models.Translation) error {
+	if len(translations) == 0 {
+		return nil
+	}
+
+	return db.Create(&translations).Error
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"vn/internal/models"
+)
+
+func RegisterVariable(db *gorm.DB, variable models.Variable) (int64, error) {
+	result := db.Create(&variable)
+	if result.RowsAffected == 0 {
+		return 0, errors.New("variable not created")
+	}
+	return result.RowsAffected, nil
+}
+
+// SelectVariablesForChapter возвращает переменные главы вместе с глобальными переменными
+func SelectVariablesForChapter(db *gorm.DB, chapterId int64) ([]models.Variable, error) {
+	var variables []models.Variable
+
+	result := db.Where("chapter_id IN ?", []int64{0, chapterId}).Find(&variables)
+	if result.Error != nil {
+		return nil, fmt.Errorf("ошибка при получении переменных: %w", result.Error)
+	}
+
+	return variables, nil
+}
+
+func DeleteVariable(db *gorm.DB, id int64) (int64, error) {
+	result := db.Where("id = ?", id).Delete(&models.Variable{})
+	if result.RowsAffected == 0 {
+		return 0, errors.New("variable data not found")
+	}
+	return result.RowsAffected, result.Error
+}
+
+// SelectPlayerVariables возвращает значения переменных игрока для главы и глобальные значения
+func SelectPlayerVariables(db *gorm.DB, playerId int64, chapterId int64) ([]models.PlayerVariable, error) {
+	var variables []models.PlayerVariable
+
+	result := db.Where("player_id = ? AND chapter_id IN ?", playerId, []int64{0, chapterId}).Find(&variables)
+	if result.Error != nil {
+		return nil, fmt.Errorf("ошибка при получении переменных игрока: %w", result.Error)
+	}
+
+	return variables, nil
+}
+
+// SavePlayerVariables создает или обновляет значения переменных игрока
+func SavePlayerVariables(db *gorm.DB, variables []models.PlayerVariable) error {
+	if len(variables) == 0 {
+		return nil
+	}
+
+	result := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "player_id"}, {Name: "chapter_id"}, {Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value"}),
+	}).Omit("id").Create(&variables)
+
+	if result.Error != nil {
+		return fmt.Errorf("ошибка при сохранении переменных игрока: %w", result.Error)
+	}
+
+	return nil
+}
+
+// DeletePlayerVariables сбрасывает переменные игрока, относящиеся только к главе
+func DeletePlayerVariables(db *gorm.DB, playerId int64, chapterId int64) (int64, error) {
+	result := db.Where("player_id = ? AND chapter_id = ?", playerId, chapterId).Delete(&models.PlayerVariable{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,112 @@
+package chapter
+
+import (
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/services/chapter"
+	"vn/pkg/metrick"
+)
+
+type CreateVariableRequest struct {
+	ChapterId string `json:"chapter_id,omitempty"` // пусто - глобальная переменная
+	Name      string `json:"name"`
+	Type      string `json:"type"` // int или flag
+	Default   int64  `json:"default,omitempty"`
+}
+
+func CreateVariableHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("chapter", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"chapter",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на создание переменной")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in create variable")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req CreateVariableRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in create variable")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in create variable")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		var chapterId int64
+
+		if req.ChapterId != "" {
+			chapterId, err = strconv.ParseInt(req.ChapterId, 10, 64)
+
+			if err != nil {
+				log.Error().Msg("Failed to covert id in create variable")
+				http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		id, err := chapter.CreateVariable(chapterId, req.Name, req.Type, req.Default, db)
+
+		if err != nil {
+			log.Error().Msg("fail to create variable in create variable")
+			http.Error(rw, "fail to create variable", http.StatusBadRequest)
+			return
+		}
+
+		// Формируем ответ
+		response := map[string]interface{}{
+			"id": utils.ToString(id),
+		}
+
+		// Отправляем ответ клиенту
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(response)
+	}
+}
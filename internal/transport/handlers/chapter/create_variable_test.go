@@ -0,0 +1,123 @@
+package chapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestCreateVariableHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"chapter_id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"chapter_id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := CreateVariableHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/create-variable", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestCreateVariableHandler_Declare(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	variableColumns := []string{"id", "chapter_id", "name", "type", "default"}
+
+	// Флаг сохраняется со значением по умолчанию 1
+	mock.ExpectQuery(`FROM "variables"`).
+		WillReturnRows(sqlmock.NewRows(variableColumns).AddRow(3, 0, "affinity.anna", "int", 0))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "variables"`).
+		WithArgs(5, "flag.met_boss", "flag", 1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectCommit()
+
+	// Глобальная переменная уже объявлена
+	mock.ExpectQuery(`FROM "variables"`).
+		WillReturnRows(sqlmock.NewRows(variableColumns).AddRow(3, 0, "affinity.anna", "int", 0))
+
+	handler := CreateVariableHandler(gormDB, new(zerolog.Logger))
+
+	req := httptest.NewRequest(http.MethodPost, "/create-variable", bytes.NewReader([]byte(`{"chapter_id": "5", "name": "flag.met_boss", "type": "flag", "default": 7}`)))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response struct {
+		Id string `json:"id"`
+	}
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.NotEmpty(t, response.Id)
+
+	req = httptest.NewRequest(http.MethodPost, "/create-variable", bytes.NewReader([]byte(`{"chapter_id": "5", "name": "affinity.anna", "type": "int"}`)))
+	w = httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
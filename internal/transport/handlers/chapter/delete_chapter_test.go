@@ -0,0 +1,125 @@
+package chapter
+
+import (
+	"bytes"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestDeleteChapterHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := DeleteChapterHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/delete-chapter", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestDeleteChapterHandler_Trash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chapterColumns := []string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}
+	nodeColumns := []string{"id", "slug", "chapter_id", "music", "background", "events_raw", "branching_raw", "end_raw", "comment", "version"}
+
+	// Глава без автора и запросов, поэтому админы не меняются
+	mock.ExpectQuery("FROM chapters").
+		WillReturnRows(sqlmock.NewRows(chapterColumns).AddRow(5, "Глава", 10, "[10]", "[]", 1, "{}", 0, 2))
+	mock.ExpectQuery("FROM nodes").
+		WillReturnRows(sqlmock.NewRows(nodeColumns).AddRow(10, "start", 5, 0, 0, "[]", "{}", "{}", "", 1))
+	mock.ExpectQuery(`FROM "requests"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`FROM "variables"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`FROM "chapter_versions"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`FROM "player_chapter_versions"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`FROM "player_variables"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`FROM "revisions"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`FROM "translations"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	// Снимок главы сохраняется в корзину в одной транзакции с удалением
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "deleted_chapters"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectExec(`DELETE FROM "nodes"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM "requests"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM "node_locks"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM "player_chapter_versions"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM "chapter_versions"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM "player_variables"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM "revisions"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM "translations"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM "chapters"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	handler := DeleteChapterHandler(gormDB, new(zerolog.Logger))
+
+	req := httptest.NewRequest(http.MethodPost, "/delete-chapter", bytes.NewReader([]byte(`{"id": "5"}`)))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
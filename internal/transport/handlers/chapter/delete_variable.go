@@ -0,0 +1,95 @@
+package chapter
+
+import (
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/services/chapter"
+	"vn/pkg/metrick"
+)
+
+type DeleteVariableRequest struct {
+	Id string `json:"id"`
+}
+
+func DeleteVariableHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("chapter", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"chapter",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на удаление переменной")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in delete variable")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req DeleteVariableRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in delete variable")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in delete variable")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		id, err := strconv.ParseInt(req.Id, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in delete variable")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		err = chapter.DeleteVariable(id, db)
+
+		if err != nil {
+			log.Error().Msg("fail to delete variable in delete variable")
+			http.Error(rw, "fail to delete variable", http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package chapter
+
+import (
+	"bytes"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestDeleteVariableHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := DeleteVariableHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/delete-variable", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestDeleteVariableHandler_Delete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "variables"`).
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Переменной 4 нет
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "variables"`).
+		WithArgs(4).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	handler := DeleteVariableHandler(gormDB, new(zerolog.Logger))
+
+	req := httptest.NewRequest(http.MethodPost, "/delete-variable", bytes.NewReader([]byte(`{"id": "3"}`)))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/delete-variable", bytes.NewReader([]byte(`{"id": "4"}`)))
+	w = httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
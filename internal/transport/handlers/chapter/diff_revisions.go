@@ -0,0 +1,136 @@
+package chapter
+
+import (
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/services/chapter"
+	"vn/pkg/jsondiff"
+	"vn/pkg/metrick"
+)
+
+type DiffRevisionsRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func DiffRevisionsHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("chapter", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"chapter",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на сравнение ревизий")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in diff revisions")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req DiffRevisionsRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in diff revisions")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in diff revisions")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		from, err := strconv.ParseInt(req.From, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in diff revisions")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		to, err := strconv.ParseInt(req.To, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in diff revisions")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		changes, err := chapter.DiffRevisions(from, to, db)
+
+		if err != nil {
+			log.Error().Msg("fail to diff revisions in diff revisions")
+			http.Error(rw, "fail to diff revisions", statusForRevisionError(err))
+			return
+		}
+
+		// Формируем ответ
+		response := map[string]interface{}{
+			"changes": PrepareChangesForResponse(changes),
+		}
+
+		// Отправляем ответ клиенту
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(response)
+	}
+}
+
+type ResponseChange struct {
+	Path string      `json:"path"`
+	Op   string      `json:"op"` // added, removed или changed
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+func PrepareChangesForResponse(changes []jsondiff.Change) []ResponseChange {
+	res := make([]ResponseChange, 0, len(changes))
+
+	for _, change := range changes {
+		res = append(res, ResponseChange{
+			Path: change.Path,
+			Op:   change.Op,
+			Old:  change.Old,
+			New:  change.New,
+		})
+	}
+
+	return res
+}
@@ -0,0 +1,132 @@
+package chapter
+
+import (
+	"bytes"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestDiffRevisionsHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"from": "not a number", "to": "1"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := DiffRevisionsHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "//diff_revisions", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestDiffRevisionsHandler_Changes(t *testing.T) {
+	tests := []struct {
+		name           string
+		toType         string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Изменения узла",
+			toType:         "node",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"changes": [{"path": "Slug", "op": "changed", "old": "start", "new": "intro"}]}`,
+		},
+		{
+			name:           "Ревизии разных сущностей",
+			toType:         "chapter",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	revisionColumns := []string{"id", "entity_type", "entity_id", "chapter_id", "author", "data", "created_at"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mock.ExpectQuery(`FROM "revisions" WHERE id = \$1`).
+				WithArgs(20, 1).
+				WillReturnRows(sqlmock.NewRows(revisionColumns).AddRow(20, "node", 10, 5, 7, `{"Slug":"start"}`, time.Now()))
+			mock.ExpectQuery(`FROM "revisions" WHERE id = \$1`).
+				WithArgs(21, 1).
+				WillReturnRows(sqlmock.NewRows(revisionColumns).AddRow(21, tt.toType, 10, 5, 7, `{"Slug":"intro"}`, time.Now()))
+
+			handler := DiffRevisionsHandler(gormDB, new(zerolog.Logger))
+
+			req := httptest.NewRequest(http.MethodPost, "/diff-revisions", bytes.NewReader([]byte(`{"from": "20", "to": "21"}`)))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
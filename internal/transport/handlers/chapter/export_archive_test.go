@@ -0,0 +1,145 @@
+package chapter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vn/internal/models"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestExportArchiveHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := ExportArchiveHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "//export-archive", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestExportArchiveHandler_Archive(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery("FROM chapters").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}).
+			AddRow(5, "Глава", 10, "[10]", "[]", 3, "{}", 1, 2))
+	mock.ExpectQuery("FROM nodes").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug", "chapter_id", "music", "background", "events_raw", "branching_raw", "end_raw", "comment", "version"}).
+			AddRow(10, "start", 5, 0, 0, `[{"Id":1,"Type":0,"Text":"Привет"}]`, "{}", `{"Flag":true}`, "", 1))
+	// Глобальная переменная не попадает в архив
+	mock.ExpectQuery(`FROM "variables"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chapter_id", "name", "type", "default"}).
+			AddRow(1, 0, "coins", "int", 0).
+			AddRow(2, 5, "trust", "int", 0))
+
+	handler := ExportArchiveHandler(gormDB, new(zerolog.Logger))
+
+	req := httptest.NewRequest(http.MethodPost, "/export-archive", bytes.NewReader([]byte(`{"id": "5"}`)))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="chapter_5.zip"`, w.Header().Get("Content-Disposition"))
+
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]byte{}
+
+	for _, file := range archive.File {
+		r, err := file.Open()
+		assert.NoError(t, err)
+		files[file.Name], err = io.ReadAll(r)
+		assert.NoError(t, err)
+		r.Close()
+	}
+
+	var nodes []models.Node
+	var variables []models.Variable
+
+	assert.NoError(t, json.Unmarshal(files["nodes.json"], &nodes))
+	assert.NoError(t, json.Unmarshal(files["variables.json"], &variables))
+
+	if assert.Len(t, nodes, 1) {
+		assert.Equal(t, "Привет", nodes[0].Events[0].Text)
+	}
+
+	if assert.Len(t, variables, 1) {
+		assert.Equal(t, "trust", variables[0].Name)
+	}
+
+	assert.Contains(t, files, "chapter.json")
+	assert.JSONEq(t, `[]`, string(files["media.json"]))
+}
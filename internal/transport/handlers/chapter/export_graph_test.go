@@ -0,0 +1,153 @@
+package chapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestExportGraphHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "not a number", "format": "dot"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := ExportGraphHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "//export-graph", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestExportGraphHandler_Graph(t *testing.T) {
+	tests := []struct {
+		name           string
+		format         string
+		found          bool
+		expectedStatus int
+	}{
+		{name: "Граф Mermaid", format: "mermaid", found: true, expectedStatus: http.StatusOK},
+		{name: "Неизвестный формат", format: "svg", expectedStatus: http.StatusBadRequest},
+		{name: "Глава не найдена", format: "dot", expectedStatus: http.StatusNotFound},
+	}
+
+	chapterColumns := []string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}
+	nodeColumns := []string{"id", "slug", "chapter_id", "music", "background", "events_raw", "branching_raw", "end_raw", "comment", "version"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Формат проверяется до обращения к базе
+			if tt.format != "svg" {
+				rows := sqlmock.NewRows(chapterColumns)
+
+				if tt.found {
+					rows.AddRow(5, "Пролог", 10, "[10,11]", "[]", 1, "{}", 1, 1)
+				}
+
+				mock.ExpectQuery("FROM chapters").WillReturnRows(rows)
+			}
+
+			if tt.found {
+				mock.ExpectQuery("FROM nodes").
+					WillReturnRows(sqlmock.NewRows(nodeColumns).
+						AddRow(10, "start", 5, 0, 0, "[]", `{"Flag":true,"Choices":[{"Id":1,"Text":"Дальше","NextNode":11}]}`, "{}", "", 1).
+						AddRow(11, "finale", 5, 0, 0, "[]", "{}", `{"Flag":true,"EndResult":"good"}`, "", 1))
+			}
+
+			handler := ExportGraphHandler(gormDB, new(zerolog.Logger))
+
+			body := []byte(`{"id": "5", "format": "` + tt.format + `"}`)
+			req := httptest.NewRequest(http.MethodPost, "/export-graph", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			if !tt.found {
+				return
+			}
+
+			var response struct {
+				Format string `json:"format"`
+				Graph  string `json:"graph"`
+			}
+
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, "mermaid", response.Format)
+			assert.Equal(t, `%% Пролог
+flowchart TD
+    n10(["start"])
+    n11["finale<br/>[good]"]
+    n10 -->|"Дальше"| n11
+    classDef end0 fill:#8dd3c7
+    class n11 end0
+`, response.Graph)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package chapter
+
+import (
+	"bytes"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestExportRenpyHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := ExportRenpyHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "//export-renpy", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestExportRenpyHandler_Script(t *testing.T) {
+	tests := []struct {
+		name           string
+		found          bool
+		expectedStatus int
+	}{
+		{name: "Скрипт главы", found: true, expectedStatus: http.StatusOK},
+		{name: "Глава не найдена", expectedStatus: http.StatusNotFound},
+	}
+
+	chapterColumns := []string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}
+	nodeColumns := []string{"id", "slug", "chapter_id", "music", "background", "events_raw", "branching_raw", "end_raw", "comment", "version"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rows := sqlmock.NewRows(chapterColumns)
+
+			if tt.found {
+				rows.AddRow(5, "Пролог", 10, "[10]", "[]", 1, "{}", 1, 1)
+			}
+
+			mock.ExpectQuery("FROM chapters").WillReturnRows(rows)
+
+			if tt.found {
+				mock.ExpectQuery("FROM nodes").
+					WillReturnRows(sqlmock.NewRows(nodeColumns).
+						AddRow(10, "start", 5, 0, 0, `[{"Id":1,"Type":0,"Text":"Ты уходишь."}]`, "{}", `{"Flag":true,"EndResult":"good"}`, "", 1))
+				mock.ExpectQuery(`FROM "variables"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "chapter_id", "name", "type", "default"}).
+						AddRow(1, 0, "trust", "int", 2))
+			}
+
+			handler := ExportRenpyHandler(gormDB, new(zerolog.Logger))
+
+			req := httptest.NewRequest(http.MethodPost, "/export-renpy", bytes.NewReader([]byte(`{"id": "5"}`)))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			if !tt.found {
+				return
+			}
+
+			assert.Equal(t, `attachment; filename="chapter_5.rpy"`, w.Header().Get("Content-Disposition"))
+			assert.Equal(t, `# Глава "Пролог", экспорт в Ren'Py
+
+default trust = 2
+
+label start:
+    jump start_10
+
+label start_10:
+    "Ты уходишь."
+    # концовка: good
+    return
+`, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package chapter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/services/chapter"
+	"vn/pkg/l10n"
+	"vn/pkg/metrick"
+)
+
+type ExportTranslationsRequest struct {
+	Id     string `json:"id"`
+	Locale string `json:"locale"`
+	Format string `json:"format"` // po или xliff
+}
+
+func ExportTranslationsHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("chapter", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"chapter",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на выгрузку строк для перевода")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in export translations")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req ExportTranslationsRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in export translations")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in export translations")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		id, err := strconv.ParseInt(req.Id, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in export translations")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		data, err := chapter.ExportTranslations(id, req.Locale, req.Format, db)
+
+		if err != nil {
+			log.Error().Msg("fail to export translations in export translations")
+			http.Error(rw, "fail to export translations", statusForTranslationError(err))
+			return
+		}
+
+		contentType, extension := "text/x-gettext-translation; charset=utf-8", "po"
+
+		if req.Format == chapter.TranslationXLIFF {
+			contentType, extension = "application/xliff+xml; charset=utf-8", "xlf"
+		}
+
+		// Отдаем файлом, который можно сразу передать переводчику
+		rw.Header().Set("Content-Type", contentType)
+		rw.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="chapter_%d.%s.%s"`, id, req.Locale, extension))
+		rw.Header().Set("Access-Control-Expose-Headers", "Content-Disposition")
+		rw.Write(data)
+	}
+}
+
+// statusForTranslationError отличает ошибки в запросе или файле перевода от отсутствующей главы
+func statusForTranslationError(err error) int {
+	switch {
+	case errors.Is(err, chapter.ErrInvalidLocale),
+		errors.Is(err, chapter.ErrUnknownTranslationFormat),
+		errors.Is(err, chapter.ErrLocaleMismatch),
+		errors.Is(err, l10n.ErrInvalid):
+		return http.StatusBadRequest
+	default:
+		return http.StatusNotFound
+	}
+}
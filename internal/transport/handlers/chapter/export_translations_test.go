@@ -0,0 +1,134 @@
+package chapter
+
+import (
+	"bytes"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestExportTranslationsHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "not a number", "locale": "en", "format": "po"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := ExportTranslationsHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "//export-translations", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestExportTranslationsHandler_File(t *testing.T) {
+	tests := []struct {
+		name           string
+		format         string
+		expectedStatus int
+	}{
+		{name: "Файл PO", format: "po", expectedStatus: http.StatusOK},
+		{name: "Неизвестный формат", format: "csv", expectedStatus: http.StatusBadRequest},
+	}
+
+	chapterColumns := []string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}
+	nodeColumns := []string{"id", "slug", "chapter_id", "music", "background", "events_raw", "branching_raw", "end_raw", "comment", "version"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				mock.ExpectQuery("FROM chapters").
+					WillReturnRows(sqlmock.NewRows(chapterColumns).AddRow(5, "Пролог", 10, "[10]", "[]", 1, "{}", 1, 1))
+				mock.ExpectQuery("FROM nodes").
+					WillReturnRows(sqlmock.NewRows(nodeColumns).
+						AddRow(10, "start", 5, 0, 0, `[{"Id":1,"Type":0,"Text":"Привет"}]`, "{}", "{}", "", 1))
+				// Реплику перевели, когда она звучала иначе
+				mock.ExpectQuery(`FROM "translations"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "locale", "chapter_id", "key", "source", "text", "updated_at"}).
+						AddRow(1, "en", 5, "chapter.name", "Пролог", "Prologue", time.Now()).
+						AddRow(2, "en", 5, "node.10.event.1", "Здравствуй", "Greetings", time.Now()))
+			}
+
+			handler := ExportTranslationsHandler(gormDB, new(zerolog.Logger))
+
+			body := []byte(`{"id": "5", "locale": "en", "format": "` + tt.format + `"}`)
+			req := httptest.NewRequest(http.MethodPost, "/export-translations", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, `attachment; filename="chapter_5.en.po"`, w.Header().Get("Content-Disposition"))
+			assert.Contains(t, w.Body.String(), "msgctxt \"chapter.name\"\nmsgid \"Пролог\"\nmsgstr \"Prologue\"\n")
+			assert.Contains(t, w.Body.String(), "#, fuzzy\nmsgctxt \"node.10.event.1\"\nmsgid \"Привет\"\nmsgstr \"Greetings\"\n")
+		})
+	}
+}
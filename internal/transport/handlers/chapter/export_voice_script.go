@@ -0,0 +1,135 @@
+package chapter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/services/chapter"
+	"vn/pkg/metrick"
+)
+
+type ExportVoiceScriptRequest struct {
+	Id        string `json:"id"`
+	Character string `json:"character,omitempty"` // пусто - все реплики главы
+	Format    string `json:"format"`              // fountain или csv
+}
+
+func ExportVoiceScriptHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("chapter", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"chapter",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на сценарий для озвучки")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in export voice script")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req ExportVoiceScriptRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in export voice script")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in export voice script")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		id, err := strconv.ParseInt(req.Id, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in export voice script")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		characterId, err := parseOptionalId(req.Character)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert character id in export voice script")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		data, err := chapter.ExportVoiceScript(id, characterId, req.Format, db)
+
+		if err != nil {
+			log.Error().Msg("fail to export voice script in export voice script")
+			http.Error(rw, "fail to export voice script", statusForVoiceError(err))
+			return
+		}
+
+		contentType, extension := "text/plain; charset=utf-8", "fountain"
+
+		if req.Format == chapter.VoiceCSV {
+			contentType, extension = "text/csv; charset=utf-8", "csv"
+		}
+
+		// Отдаем файлом, который можно сразу передать студии озвучки
+		rw.Header().Set("Content-Type", contentType)
+		rw.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="chapter_%d_voice.%s"`, id, extension))
+		rw.Header().Set("Access-Control-Expose-Headers", "Content-Disposition")
+		rw.Write(data)
+	}
+}
+
+// statusForVoiceError отличает ошибки в запросе или архиве от отсутствующей главы
+func statusForVoiceError(err error) int {
+	var lockedErr *chapter.NodeLockedError
+
+	switch {
+	case errors.Is(err, chapter.ErrUnknownVoiceFormat),
+		errors.Is(err, chapter.ErrCharacterNotInChapter),
+		errors.Is(err, chapter.ErrInvalidVoiceArchive):
+		return http.StatusBadRequest
+	case errors.As(err, &lockedErr), errors.Is(err, chapter.ErrLockNotHeld):
+		return http.StatusLocked
+	default:
+		return http.StatusNotFound
+	}
+}
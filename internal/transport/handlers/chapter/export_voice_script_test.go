@@ -0,0 +1,129 @@
+package chapter
+
+import (
+	"bytes"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestExportVoiceScriptHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "not a number", "format": "fountain"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := ExportVoiceScriptHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "//export-voice-script", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestExportVoiceScriptHandler_Character(t *testing.T) {
+	tests := []struct {
+		name           string
+		character      string
+		expectedStatus int
+	}{
+		{name: "Реплики персонажа", character: "7", expectedStatus: http.StatusOK},
+		{name: "Персонажа нет в главе", character: "8", expectedStatus: http.StatusBadRequest},
+	}
+
+	chapterColumns := []string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}
+	nodeColumns := []string{"id", "slug", "chapter_id", "music", "background", "events_raw", "branching_raw", "end_raw", "comment", "version"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mock.ExpectQuery("FROM chapters").
+				WillReturnRows(sqlmock.NewRows(chapterColumns).AddRow(5, "Глава", 10, "[10]", "[7]", 1, "{}", 1, 1))
+			mock.ExpectQuery("FROM nodes").
+				WillReturnRows(sqlmock.NewRows(nodeColumns).
+					AddRow(10, "start", 5, 0, 0, `[{"Id":1,"Type":0,"Text":"Тишина."},{"Id":2,"Type":3,"Character":7,"Text":"Привет"}]`, "{}", "{}", "", 3))
+			mock.ExpectQuery("FROM characters").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "color", "emotions_raw"}).
+					AddRow(7, "Алиса", "alice", "#ff0000", "{}"))
+
+			handler := ExportVoiceScriptHandler(gormDB, new(zerolog.Logger))
+
+			body := []byte(`{"id": "5", "character": "` + tt.character + `", "format": "csv"}`)
+			req := httptest.NewRequest(http.MethodPost, "/export-voice-script", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			// Текст рассказчика в сценарий персонажа не попадает
+			assert.Equal(t, `attachment; filename="chapter_5_voice.csv"`, w.Header().Get("Content-Disposition"))
+			assert.Equal(t, "line_id,node,speaker,emotion,text,sound\n10_2,start,alice,,Привет,\n", w.Body.String())
+		})
+	}
+}
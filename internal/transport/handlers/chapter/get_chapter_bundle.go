@@ -0,0 +1,226 @@
+package chapter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"vn/internal/models"
+	"vn/internal/services/chapter"
+	"vn/internal/transport/handlers/character"
+	"vn/internal/transport/handlers/node"
+	"vn/pkg/metrick"
+)
+
+type GetChapterBundleRequest struct {
+	Id     string `json:"id"`
+	Locale string `json:"locale,omitempty"`
+}
+
+func GetChapterBundleHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("chapter", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"chapter",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на получение главы для офлайн игры")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept, If-None-Match")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		var req GetChapterBundleRequest
+
+		switch r.Method {
+		case http.MethodGet:
+			// GET кэшируется клиентом и получает 304, параметры передаются в строке запроса
+			req.Id = r.URL.Query().Get("id")
+			req.Locale = r.URL.Query().Get("locale")
+		case http.MethodPost:
+			// Читаем тело запроса
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				log.Error().Msg("Failed to read request body in get chapter bundle")
+				http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+				return
+			}
+
+			// Разбираем JSON
+			err = json.Unmarshal(body, &req)
+			if err != nil {
+				log.Error().Msg("Invalid JSON format in get chapter bundle")
+				http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+				return
+			}
+		default:
+			log.Error().Msg("Only GET and POST requests allowed in get chapter bundle")
+			http.Error(rw, "Only GET and POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := strconv.ParseInt(req.Id, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in get chapter bundle")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		bundle, err := chapter.GetChapterBundle(id, req.Locale, db)
+
+		if err != nil {
+			log.Error().Msg("fail to get chapter bundle in get chapter bundle")
+			http.Error(rw, "fail to get chapter bundle", statusForBundleError(err))
+			return
+		}
+
+		body, err := json.Marshal(map[string]interface{}{
+			"bundle": PrepareBundleForResponse(*bundle),
+		})
+
+		if err != nil {
+			log.Error().Msg("fail to encode bundle in get chapter bundle")
+			http.Error(rw, "fail to encode bundle", http.StatusInternalServerError)
+			return
+		}
+
+		// ETag - хэш содержимого, клиент может не скачивать главу повторно
+		hash := sha256.Sum256(body)
+		etag := `"` + hex.EncodeToString(hash[:]) + `"`
+
+		rw.Header().Set("ETag", etag)
+		rw.Header().Set("Access-Control-Expose-Headers", "ETag")
+
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			// 304 допустим только для GET, для остальных методов условие не выполнено (RFC 9110, 13.1.2)
+			if r.Method == http.MethodGet {
+				rw.WriteHeader(http.StatusNotModified)
+			} else {
+				rw.WriteHeader(http.StatusPreconditionFailed)
+			}
+
+			return
+		}
+
+		// Отправляем ответ клиенту
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write(body)
+	}
+}
+
+// etagMatches проверяет заголовок If-None-Match: список тегов через запятую или *.
+// Теги сравниваются слабым сравнением, префикс W/ не учитывается
+func etagMatches(header string, etag string) bool {
+	header = strings.TrimSpace(header)
+
+	if header == "" {
+		return false
+	}
+
+	if header == "*" {
+		return true
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+type ResponseMedia struct {
+	Id          string `json:"id"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+type ResponseBundle struct {
+	Chapter    ResponceChapter               `json:"chapter"`
+	Nodes      []node.ResponseNode           `json:"nodes"`
+	Characters []character.ResponseCharacter `json:"characters"`
+	Media      []ResponseMedia               `json:"media"`
+}
+
+func PrepareBundleForResponse(bundle chapter.Bundle) ResponseBundle {
+	nodes := make([]node.ResponseNode, 0, len(bundle.Nodes))
+
+	for _, n := range bundle.Nodes {
+		nodes = append(nodes, node.PrepareNodeForResponse(n))
+	}
+
+	characters := character.PrepareCharacterForResponse(&bundle.Characters)
+
+	if characters == nil {
+		characters = []character.ResponseCharacter{}
+	}
+
+	return ResponseBundle{
+		Chapter:    prepareChaptersForResponce([]models.Chapter{bundle.Chapter})[0],
+		Nodes:      nodes,
+		Characters: characters,
+		Media:      prepareMediaForResponse(bundle.Media),
+	}
+}
+
+func prepareMediaForResponse(media []models.MediaInfo) []ResponseMedia {
+	res := make([]ResponseMedia, 0, len(media))
+
+	for _, m := range media {
+		res = append(res, ResponseMedia{
+			Id:          utils.ToString(m.Id),
+			ContentType: m.ContentType,
+			Size:        m.Size,
+		})
+	}
+
+	return res
+}
+
+// statusForBundleError отличает неопубликованную главу от остальных ошибок
+func statusForBundleError(err error) int {
+	if errors.Is(err, chapter.ErrChapterNotPublished) {
+		return http.StatusForbidden
+	}
+
+	if errors.Is(err, chapter.ErrInvalidLocale) {
+		return http.StatusBadRequest
+	}
+
+	return http.StatusNotFound
+}
@@ -0,0 +1,172 @@
+package chapter
+
+import (
+	"bytes"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestGetChapterBundleHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodPut,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := GetChapterBundleHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/get-chapter-bundle", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestGetChapterBundleHandler_ETag(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chapterColumns := []string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}
+	versionColumns := []string{"id", "chapter_id", "version", "content", "published_at"}
+
+	// Опубликованный снимок. Черновик главы после публикации переименован
+	content := `{"Chapter":{"Id":5,"Name":"Глава","StartNode":10,"Nodes":[10],"Status":3,"Version":1},
+		"Nodes":[{"Id":10,"Slug":"start","ChapterId":5,"Music":1,"Background":2,"Version":1}]}`
+
+	// Один и тот же ответ базы для трех запросов
+	for i := 0; i < 3; i++ {
+		mock.ExpectQuery("FROM chapters").
+			WillReturnRows(sqlmock.NewRows(chapterColumns).AddRow(5, "Черновик", 10, "[10,11]", "[]", 3, "{}", 1, 2))
+		mock.ExpectQuery(`FROM "chapter_versions"`).
+			WillReturnRows(sqlmock.NewRows(versionColumns).AddRow(7, 5, 1, content, time.Now()))
+		mock.ExpectQuery(`FROM "media"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "content_type", "size"}).AddRow(1, "audio/ogg", 100).AddRow(2, "image/png", 200))
+	}
+
+	handler := GetChapterBundleHandler(gormDB, new(zerolog.Logger))
+
+	req := httptest.NewRequest(http.MethodGet, "/get-chapter-bundle?id=5", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.Contains(t, w.Body.String(), `"image/png"`)
+	assert.Contains(t, w.Body.String(), `"Глава"`)
+	assert.NotContains(t, w.Body.String(), `"Черновик"`)
+
+	// Слабый тег в списке совпадает с ответом
+	req = httptest.NewRequest(http.MethodGet, "/get-chapter-bundle?id=5", nil)
+	req.Header.Set("If-None-Match", `"stale", W/`+etag)
+	w = httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	// POST не получает 304
+	req = httptest.NewRequest(http.MethodPost, "/get-chapter-bundle", bytes.NewReader([]byte(`{"id": "5"}`)))
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEtagMatches(t *testing.T) {
+	etag := `"abc"`
+
+	assert.True(t, etagMatches(`"abc"`, etag))
+	assert.True(t, etagMatches(`"x", "abc"`, etag))
+	assert.True(t, etagMatches(`W/"abc"`, etag))
+	assert.True(t, etagMatches(`*`, etag))
+	assert.False(t, etagMatches(``, etag))
+	assert.False(t, etagMatches(`"ab"`, etag))
+	assert.False(t, etagMatches(`abc`, etag))
+}
+
+func TestGetChapterBundleHandler_NotPublished(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery("FROM chapters").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}).
+			AddRow(5, "Глава", 10, "[10]", "[]", 1, "{}", 1, 1))
+
+	handler := GetChapterBundleHandler(gormDB, new(zerolog.Logger))
+
+	req := httptest.NewRequest(http.MethodPost, "/get-chapter-bundle", bytes.NewReader([]byte(`{"id": "5"}`)))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,179 @@
+package chapter
+
+import (
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/services/chapter"
+	"vn/pkg/metrick"
+)
+
+type GetChapterStatsRequest struct {
+	Id string `json:"id"`
+}
+
+func GetChapterStatsHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("chapter", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"chapter",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на статистику главы")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in chapter stats")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req GetChapterStatsRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in chapter stats")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in chapter stats")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		id, err := strconv.ParseInt(req.Id, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in chapter stats")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		stats, err := chapter.GetChapterStats(id, db)
+
+		if err != nil {
+			log.Error().Msg("fail to count chapter stats in chapter stats")
+			http.Error(rw, "fail to count chapter stats", http.StatusNotFound)
+			return
+		}
+
+		// Формируем ответ
+		response := map[string]interface{}{
+			"stats": PrepareChapterStatsForResponse(*stats),
+		}
+
+		// Отправляем ответ клиенту
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(response)
+	}
+}
+
+type ResponseChapterStats struct {
+	ChapterId      string                   `json:"chapter_id"`
+	Nodes          int                      `json:"nodes"`
+	Events         int                      `json:"events"`
+	Words          int                      `json:"words"`
+	ReadingSeconds int                      `json:"reading_seconds"`
+	Choices        int                      `json:"choices"`
+	Endings        int                      `json:"endings"`
+	ShortestPath   *ResponseStoryPath       `json:"shortest_path"` // null - концовка недостижима
+	LongestPath    *ResponseStoryPath       `json:"longest_path"`  // в главе с циклами - оценка, см. approximate
+	Characters     []ResponseCharacterLines `json:"characters"`
+}
+
+type ResponseStoryPath struct {
+	Nodes          []string `json:"nodes"`
+	Length         int      `json:"length"`
+	Words          int      `json:"words"`
+	ReadingSeconds int      `json:"reading_seconds"`
+	Approximate    bool     `json:"approximate"` // true - путь может быть не самым длинным
+}
+
+type ResponseCharacterLines struct {
+	CharacterId string `json:"character_id"`
+	Name        string `json:"name"`
+	Lines       int    `json:"lines"`
+	Words       int    `json:"words"`
+}
+
+func PrepareChapterStatsForResponse(stats chapter.ChapterStats) ResponseChapterStats {
+	response := ResponseChapterStats{
+		ChapterId:      utils.ToString(stats.ChapterId),
+		Nodes:          stats.Nodes,
+		Events:         stats.Events,
+		Words:          stats.Words,
+		ReadingSeconds: stats.ReadingSeconds,
+		Choices:        stats.Choices,
+		Endings:        stats.Endings,
+		ShortestPath:   prepareStoryPath(stats.ShortestPath),
+		LongestPath:    prepareStoryPath(stats.LongestPath),
+		Characters:     make([]ResponseCharacterLines, 0, len(stats.Characters)),
+	}
+
+	for _, character := range stats.Characters {
+		response.Characters = append(response.Characters, ResponseCharacterLines{
+			CharacterId: utils.ToString(character.CharacterId),
+			Name:        character.Name,
+			Lines:       character.Lines,
+			Words:       character.Words,
+		})
+	}
+
+	return response
+}
+
+func prepareStoryPath(path *chapter.StoryPath) *ResponseStoryPath {
+	if path == nil {
+		return nil
+	}
+
+	nodes := make([]string, 0, len(path.Nodes))
+
+	for _, nodeId := range path.Nodes {
+		nodes = append(nodes, utils.ToString(nodeId))
+	}
+
+	return &ResponseStoryPath{
+		Nodes:          nodes,
+		Length:         len(nodes),
+		Words:          path.Words,
+		ReadingSeconds: path.ReadingSeconds,
+		Approximate:    path.Approximate,
+	}
+}
@@ -0,0 +1,159 @@
+package chapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestGetChapterStatsHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := GetChapterStatsHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "//get-chapter-stats", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestGetChapterStatsHandler_Stats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Из start можно вернуться в start, поэтому самый длинный путь - оценка
+	mock.ExpectQuery("FROM chapters").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}).
+			AddRow(5, "Глава", 10, "[10,11]", "[7]", 1, "{}", 1, 1))
+	mock.ExpectQuery("FROM nodes").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug", "chapter_id", "music", "background", "events_raw", "branching_raw", "end_raw", "comment", "version"}).
+			AddRow(10, "start", 5, 0, 0, `[{"Id":1,"Type":3,"Character":7,"Text":"Привет, мир"}]`,
+				`{"Flag":true,"Choices":[{"Id":1,"Text":"Еще раз","NextNode":10},{"Id":2,"Text":"Уйти","NextNode":11}]}`, "{}", "", 1).
+			AddRow(11, "finale", 5, 0, 0, "[]", "{}", `{"Flag":true,"EndResult":"good"}`, "", 1))
+	mock.ExpectQuery("FROM characters").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "color", "emotions_raw"}).
+			AddRow(7, "Алиса", "alice", "#ff0000", "{}"))
+
+	handler := GetChapterStatsHandler(gormDB, new(zerolog.Logger))
+
+	req := httptest.NewRequest(http.MethodPost, "/get-chapter-stats", bytes.NewReader([]byte(`{"id": "5"}`)))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	var response struct {
+		Stats ResponseChapterStats `json:"stats"`
+	}
+
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	stats := response.Stats
+
+	assert.Equal(t, "5", stats.ChapterId)
+	assert.Equal(t, 2, stats.Nodes)
+	assert.Equal(t, 2, stats.Choices)
+	assert.Equal(t, 1, stats.Endings)
+
+	if assert.NotNil(t, stats.ShortestPath) {
+		assert.Equal(t, []string{"10", "11"}, stats.ShortestPath.Nodes)
+		assert.False(t, stats.ShortestPath.Approximate)
+	}
+
+	if assert.NotNil(t, stats.LongestPath) {
+		assert.Equal(t, []string{"10", "11"}, stats.LongestPath.Nodes)
+		assert.True(t, stats.LongestPath.Approximate)
+	}
+
+	assert.Equal(t, []ResponseCharacterLines{{CharacterId: "7", Name: "Алиса", Lines: 1, Words: 2}}, stats.Characters)
+}
+
+func TestGetChapterStatsHandler_Missing(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery("FROM chapters").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}))
+
+	handler := GetChapterStatsHandler(gormDB, new(zerolog.Logger))
+
+	req := httptest.NewRequest(http.MethodPost, "/get-chapter-stats", bytes.NewReader([]byte(`{"id": "5"}`)))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,130 @@
+package chapter
+
+import (
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/models"
+	"vn/internal/services/chapter"
+	"vn/pkg/metrick"
+)
+
+type GetChapterVersionsRequest struct {
+	Id string `json:"id"`
+}
+
+func GetChapterVersionsHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("chapter", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"chapter",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на получение версий главы")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in get chapter versions")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req GetChapterVersionsRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in get chapter versions")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in get chapter versions")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		id, err := strconv.ParseInt(req.Id, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in get chapter versions")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		versions, err := chapter.GetChapterVersions(id, db)
+
+		if err != nil {
+			log.Error().Msg("fail to get chapter versions in get chapter versions")
+			http.Error(rw, "fail to get chapter versions", http.StatusNotFound)
+			return
+		}
+
+		// Формируем ответ
+		response := map[string]interface{}{
+			"versions": PrepareChapterVersionsForResponse(versions),
+		}
+
+		// Отправляем ответ клиенту
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(response)
+	}
+}
+
+type ResponseChapterVersion struct {
+	Id          string `json:"id"`
+	Version     int    `json:"version"`
+	Name        string `json:"name"`
+	Nodes       int    `json:"nodes"`
+	PublishedAt string `json:"published_at"`
+}
+
+func PrepareChapterVersionsForResponse(versions []models.ChapterVersion) []ResponseChapterVersion {
+	res := make([]ResponseChapterVersion, 0, len(versions))
+
+	for _, version := range versions {
+		res = append(res, ResponseChapterVersion{
+			Id:          utils.ToString(version.Id),
+			Version:     version.Version,
+			Name:        version.Content.Chapter.Name,
+			Nodes:       len(version.Content.Nodes),
+			PublishedAt: version.PublishedAt.Format(time.RFC3339),
+		})
+	}
+
+	return res
+}
@@ -0,0 +1,144 @@
+package chapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestGetChapterVersionsHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := GetChapterVersionsHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "//get-chapter-versions", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestGetChapterVersionsHandler_Versions(t *testing.T) {
+	tests := []struct {
+		name           string
+		found          bool
+		expectedStatus int
+	}{
+		{name: "Версии главы", found: true, expectedStatus: http.StatusOK},
+		{name: "Глава не найдена", expectedStatus: http.StatusNotFound},
+	}
+
+	chapterColumns := []string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}
+	versionColumns := []string{"id", "chapter_id", "version", "content", "published_at"}
+	publishedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rows := sqlmock.NewRows(chapterColumns)
+
+			if tt.found {
+				rows.AddRow(5, "Глава", 10, "[10,11]", "[]", 3, "{}", 1, 6)
+			}
+
+			mock.ExpectQuery("FROM chapters").WithArgs(5).WillReturnRows(rows)
+
+			if tt.found {
+				mock.ExpectQuery(`FROM "chapter_versions" WHERE chapter_id = \$1 ORDER BY version`).
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows(versionColumns).
+						AddRow(1, 5, 1, `{"Chapter":{"Id":5,"Name":"Черновик"},"Nodes":[{"Id":10}]}`, publishedAt).
+						AddRow(2, 5, 2, `{"Chapter":{"Id":5,"Name":"Глава"},"Nodes":[{"Id":10},{"Id":11}]}`, publishedAt.Add(time.Hour)))
+			}
+
+			handler := GetChapterVersionsHandler(gormDB, new(zerolog.Logger))
+
+			req := httptest.NewRequest(http.MethodPost, "/get-chapter-versions", bytes.NewReader([]byte(`{"id": "5"}`)))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			if !tt.found {
+				return
+			}
+
+			var response struct {
+				Versions []ResponseChapterVersion `json:"versions"`
+			}
+
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, []ResponseChapterVersion{
+				{Id: "1", Version: 1, Name: "Черновик", Nodes: 1, PublishedAt: "2025-03-01T12:00:00Z"},
+				{Id: "2", Version: 2, Name: "Глава", Nodes: 2, PublishedAt: "2025-03-01T13:00:00Z"},
+			}, response.Versions)
+		})
+	}
+}
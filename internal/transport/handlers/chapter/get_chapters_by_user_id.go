@@ -2,6 +2,7 @@ package chapter
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/rs/zerolog"
 	"gorm.io/gorm"
 	"gorm.io/gorm/utils"
@@ -17,6 +18,7 @@ import (
 
 type GetChaptersByUserIdRequest struct {
 	UserId string `json:"user_id"`
+	Locale string `json:"locale,omitempty"`
 }
 
 func GetChaptersByUserIdHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
@@ -89,11 +91,18 @@ func GetChaptersByUserIdHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFu
 			}
 		}
 
-		chapters, err := chapter.GetChaptersByUserId(db, id)
+		chapters, err := chapter.GetChaptersByUserId(db, id, req.Locale)
 
 		if err != nil {
 			log.Error().Msg("fail to get chapters in chapters")
-			http.Error(w, "fail to get chapters", http.StatusInternalServerError)
+
+			status := http.StatusInternalServerError
+
+			if errors.Is(err, chapter.ErrInvalidLocale) {
+				status = http.StatusBadRequest
+			}
+
+			http.Error(w, "fail to get chapters", status)
 			return // Добавлен return
 		}
 
@@ -118,6 +127,7 @@ type ResponceChapter struct {
 	Characters []string
 	Status     int
 	Author     string
+	Version    int
 }
 
 func prepareChaptersForResponce(chapters []models.Chapter) []ResponceChapter {
@@ -144,6 +154,7 @@ func prepareChaptersForResponce(chapters []models.Chapter) []ResponceChapter {
 			Characters: characters,
 			Status:     ch.Status,
 			Author:     utils.ToString(ch.Author),
+			Version:    ch.Version,
 		})
 	}
 
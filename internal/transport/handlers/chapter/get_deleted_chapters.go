@@ -0,0 +1,144 @@
+package chapter
+
+import (
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/models"
+	"vn/internal/services/chapter"
+	"vn/pkg/metrick"
+)
+
+type GetDeletedChaptersRequest struct {
+	Author string `json:"author,omitempty"` // пусто - главы всех авторов
+}
+
+func GetDeletedChaptersHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("chapter", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"chapter",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на получение удаленных глав")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in get deleted chapters")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req GetDeletedChaptersRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in get deleted chapters")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in get deleted chapters")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		author, err := parseOptionalId(req.Author)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in get deleted chapters")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		deleted, err := chapter.GetDeletedChapters(db)
+
+		if err != nil {
+			log.Error().Msg("fail to get deleted chapters in get deleted chapters")
+			http.Error(rw, "fail to get deleted chapters", http.StatusInternalServerError)
+			return
+		}
+
+		// Формируем ответ
+		response := map[string]interface{}{
+			"chapters": PrepareDeletedChaptersForResponse(deleted, author),
+		}
+
+		// Отправляем ответ клиенту
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(response)
+	}
+}
+
+type ResponseDeletedChapter struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	Author      string `json:"author"`
+	Nodes       int    `json:"nodes"`
+	DeletedAt   string `json:"deleted_at"`
+	RestoreTill string `json:"restore_till"`
+}
+
+func PrepareDeletedChaptersForResponse(deleted []models.DeletedChapter, author int64) []ResponseDeletedChapter {
+	res := make([]ResponseDeletedChapter, 0, len(deleted))
+
+	for _, d := range deleted {
+		if author != 0 && d.Snapshot.Chapter.Author != author {
+			continue
+		}
+
+		res = append(res, ResponseDeletedChapter{
+			Id:          utils.ToString(d.Id),
+			Name:        d.Snapshot.Chapter.Name,
+			Author:      utils.ToString(d.Snapshot.Chapter.Author),
+			Nodes:       len(d.Snapshot.Nodes),
+			DeletedAt:   d.DeletedAt.Format(time.RFC3339),
+			RestoreTill: d.DeletedAt.Add(chapter.RetentionPeriod).Format(time.RFC3339),
+		})
+	}
+
+	return res
+}
+
+func parseOptionalId(id string) (int64, error) {
+	if id == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseInt(id, 10, 64)
+}
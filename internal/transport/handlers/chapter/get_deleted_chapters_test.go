@@ -0,0 +1,121 @@
+package chapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"vn/internal/services/chapter"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestGetDeletedChaptersHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"author": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"author": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := GetDeletedChaptersHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/get-deleted-chapters", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestGetDeletedChaptersHandler_Author(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deletedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`FROM "deleted_chapters" ORDER BY deleted_at DESC`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "snapshot", "deleted_at"}).
+			AddRow(5, `{"Chapter":{"Id":5,"Name":"Своя","Author":1},"Nodes":[{"Id":10},{"Id":11}]}`, deletedAt).
+			AddRow(6, `{"Chapter":{"Id":6,"Name":"Чужая","Author":2}}`, deletedAt))
+
+	handler := GetDeletedChaptersHandler(gormDB, new(zerolog.Logger))
+
+	req := httptest.NewRequest(http.MethodPost, "/get-deleted-chapters", bytes.NewReader([]byte(`{"author": "1"}`)))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	var response struct {
+		Chapters []ResponseDeletedChapter `json:"chapters"`
+	}
+
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	// Главы других авторов отфильтрованы
+	assert.Equal(t, []ResponseDeletedChapter{{
+		Id:          "5",
+		Name:        "Своя",
+		Author:      "1",
+		Nodes:       2,
+		DeletedAt:   "2025-03-01T12:00:00Z",
+		RestoreTill: deletedAt.Add(chapter.RetentionPeriod).Format(time.RFC3339),
+	}}, response.Chapters)
+}
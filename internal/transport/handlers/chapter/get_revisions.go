@@ -0,0 +1,154 @@
+package chapter
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/models"
+	"vn/internal/services/chapter"
+	"vn/pkg/metrick"
+)
+
+type GetRevisionsRequest struct {
+	EntityType string `json:"entity_type"` // chapter или node
+	EntityId   string `json:"entity_id"`
+}
+
+func GetRevisionsHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("chapter", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"chapter",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на получение ревизий")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in get revisions")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req GetRevisionsRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in get revisions")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in get revisions")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		entityId, err := strconv.ParseInt(req.EntityId, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in get revisions")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		revisions, err := chapter.GetRevisions(req.EntityType, entityId, db)
+
+		if err != nil {
+			log.Error().Msg("fail to get revisions in get revisions")
+			http.Error(rw, "fail to get revisions", statusForRevisionError(err))
+			return
+		}
+
+		// Формируем ответ
+		response := map[string]interface{}{
+			"revisions": PrepareRevisionsForResponse(revisions),
+		}
+
+		// Отправляем ответ клиенту
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(response)
+	}
+}
+
+type ResponseRevision struct {
+	Id         string `json:"id"`
+	EntityType string `json:"entity_type"`
+	EntityId   string `json:"entity_id"`
+	Author     string `json:"author"`
+	CreatedAt  string `json:"created_at"`
+}
+
+func PrepareRevisionsForResponse(revisions []models.Revision) []ResponseRevision {
+	res := make([]ResponseRevision, 0, len(revisions))
+
+	for _, revision := range revisions {
+		res = append(res, ResponseRevision{
+			Id:         utils.ToString(revision.Id),
+			EntityType: revision.EntityType,
+			EntityId:   utils.ToString(revision.EntityId),
+			Author:     utils.ToString(revision.Author),
+			CreatedAt:  revision.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	return res
+}
+
+// statusForRevisionError возвращает 400 для некорректного запроса, 422 для ревизии, которая
+// больше не проходит проверку узла, 423 без блокировки узла и 404 для остальных ошибок
+func statusForRevisionError(err error) int {
+	if errors.Is(err, chapter.ErrUnknownRevisionType) || errors.Is(err, chapter.ErrRevisionMismatch) {
+		return http.StatusBadRequest
+	}
+
+	var validationErr *chapter.NodeValidationError
+
+	if errors.As(err, &validationErr) {
+		return http.StatusUnprocessableEntity
+	}
+
+	var lockedErr *chapter.NodeLockedError
+
+	if errors.Is(err, chapter.ErrLockNotHeld) || errors.As(err, &lockedErr) {
+		return http.StatusLocked
+	}
+
+	return http.StatusNotFound
+}
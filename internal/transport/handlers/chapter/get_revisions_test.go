@@ -0,0 +1,136 @@
+package chapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestGetRevisionsHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"entity_type": "chapter", "entity_id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := GetRevisionsHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "//get_revisions", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestGetRevisionsHandler_History(t *testing.T) {
+	tests := []struct {
+		name           string
+		entityType     string
+		expectedStatus int
+	}{
+		{name: "История узла", entityType: "node", expectedStatus: http.StatusOK},
+		{name: "Неизвестный тип", entityType: "character", expectedStatus: http.StatusBadRequest},
+	}
+
+	revisionColumns := []string{"id", "entity_type", "entity_id", "chapter_id", "author", "data", "created_at"}
+	createdAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				mock.ExpectQuery(`FROM "revisions" WHERE entity_type = \$1 AND entity_id = \$2 ORDER BY id DESC`).
+					WithArgs("node", 10).
+					WillReturnRows(sqlmock.NewRows(revisionColumns).
+						AddRow(21, "node", 10, 5, 7, "{}", createdAt.Add(time.Hour)).
+						AddRow(20, "node", 10, 5, 8, "{}", createdAt))
+			}
+
+			handler := GetRevisionsHandler(gormDB, new(zerolog.Logger))
+
+			body := []byte(`{"entity_type": "` + tt.entityType + `", "entity_id": "10"}`)
+			req := httptest.NewRequest(http.MethodPost, "/get-revisions", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Revisions []ResponseRevision `json:"revisions"`
+			}
+
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, []ResponseRevision{
+				{Id: "21", EntityType: "node", EntityId: "10", Author: "7", CreatedAt: "2025-03-01T13:00:00Z"},
+				{Id: "20", EntityType: "node", EntityId: "10", Author: "8", CreatedAt: "2025-03-01T12:00:00Z"},
+			}, response.Revisions)
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package chapter
+
+import (
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/models"
+	"vn/internal/services/chapter"
+	"vn/pkg/metrick"
+)
+
+type GetVariablesRequest struct {
+	ChapterId string `json:"chapter_id"`
+}
+
+func GetVariablesHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("chapter", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"chapter",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на получение переменных")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in get variables")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req GetVariablesRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in get variables")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in get variables")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		chapterId, err := strconv.ParseInt(req.ChapterId, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in get variables")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		variables, err := chapter.GetVariables(chapterId, db)
+
+		if err != nil {
+			log.Error().Msg("fail to get variables in get variables")
+			http.Error(rw, "fail to get variables", http.StatusInternalServerError)
+			return
+		}
+
+		// Формируем ответ
+		response := map[string]interface{}{
+			"variables": PrepareVariablesForResponse(variables),
+		}
+
+		// Отправляем ответ клиенту
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(response)
+	}
+}
+
+type ResponseVariable struct {
+	Id        string `json:"id"`
+	ChapterId string `json:"chapter_id"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Default   int64  `json:"default"`
+}
+
+func PrepareVariablesForResponse(variables []models.Variable) []ResponseVariable {
+	res := make([]ResponseVariable, 0, len(variables))
+
+	for _, variable := range variables {
+		res = append(res, ResponseVariable{
+			Id:        utils.ToString(variable.Id),
+			ChapterId: utils.ToString(variable.ChapterId),
+			Name:      variable.Name,
+			Type:      variable.Type,
+			Default:   variable.Default,
+		})
+	}
+
+	return res
+}
@@ -0,0 +1,184 @@
+package chapter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vn/internal/models"
+	"vn/internal/services/chapter"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestImportArchiveHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"author": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"author": "not a number", "data": ""}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := ImportArchiveHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "//import-archive", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+// testChapterArchive собирает архив главы из одного узла без персонажей и медиафайлов
+func testChapterArchive(t *testing.T, format int) []byte {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	files := []struct {
+		name  string
+		value interface{}
+	}{
+		{"manifest.json", map[string]interface{}{"format": format, "chapter_id": 5}},
+		{"chapter.json", models.Chapter{Id: 5, Name: "Глава", StartNode: 10, Nodes: []int64{10}, Status: 3}},
+		{"nodes.json", []models.Node{{Id: 10, Slug: "start", ChapterId: 5, End: models.EndInfo{Flag: true}}}},
+		{"characters.json", []models.Character{}},
+		{"variables.json", []models.Variable{}},
+		{"media.json", []interface{}{}},
+	}
+
+	for _, file := range files {
+		w, err := archive.Create(file.name)
+		assert.NoError(t, err)
+		assert.NoError(t, json.NewEncoder(w).Encode(file.value))
+	}
+
+	assert.NoError(t, archive.Close())
+
+	return buf.Bytes()
+}
+
+func TestImportArchiveHandler_Import(t *testing.T) {
+	tests := []struct {
+		name           string
+		data           []byte
+		imported       bool
+		expectedStatus int
+	}{
+		{
+			name:           "Глава импортирована",
+			data:           testChapterArchive(t, chapter.ArchiveFormat),
+			imported:       true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Неизвестный формат",
+			data:           testChapterArchive(t, chapter.ArchiveFormat+1),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Не zip",
+			data:           []byte("не zip"),
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.imported {
+				mock.ExpectBegin()
+				mock.ExpectQuery("FROM characters").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "color", "emotions_raw"}))
+				mock.ExpectQuery(`INSERT INTO "nodes"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(20))
+				mock.ExpectQuery(`INSERT INTO "chapters"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(6))
+				mock.ExpectCommit()
+			}
+
+			handler := ImportArchiveHandler(gormDB, new(zerolog.Logger))
+
+			body, err := json.Marshal(ImportArchiveRequest{Author: "2", Data: tt.data})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/import-archive", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			if !tt.imported {
+				return
+			}
+
+			// Глава и узлы получают новые id
+			var response struct {
+				Id        string `json:"id"`
+				StartNode string `json:"start_node"`
+			}
+
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.NotEqual(t, "5", response.Id)
+			assert.NotEqual(t, "10", response.StartNode)
+			assert.NotEmpty(t, response.StartNode)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package chapter
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/services/chapter"
+	"vn/pkg/metrick"
+	"vn/pkg/story"
+)
+
+type ImportStoryRequest struct {
+	Author string `json:"author"`
+	Format string `json:"format"` // twee или ink
+	Data   string `json:"data"`   // содержимое файла
+	// Слова перед двоеточием, которые не являются именами, например "Поздно ночью".
+	// Строки с ними остаются текстом рассказчика, остальные говорящие становятся персонажами
+	Narration []string `json:"narration"`
+}
+
+func ImportStoryHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("chapter", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"chapter",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на импорт истории")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in import story")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req ImportStoryRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in import story")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in import story")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		authorId, err := strconv.ParseInt(req.Author, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in import story")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		id, nodeId, created, err := chapter.ImportStory(req.Format, []byte(req.Data), req.Narration, authorId, db)
+
+		if err != nil {
+			log.Error().Msg("fail to import story in import story")
+			http.Error(rw, "fail to import story", statusForImportError(err))
+			return
+		}
+
+		// Созданных персонажей автор проверяет сам: лишних можно исключить через narration
+		if created == nil {
+			created = []string{}
+		}
+
+		// Формируем ответ
+		response := map[string]interface{}{
+			"id":                 utils.ToString(id),
+			"start_node":         utils.ToString(nodeId),
+			"created_characters": created,
+		}
+
+		// Отправляем ответ клиенту
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(response)
+	}
+}
+
+// statusForImportError отличает ошибки в файле истории от ошибок сервера
+func statusForImportError(err error) int {
+	if errors.Is(err, chapter.ErrUnknownImportFormat) || errors.Is(err, chapter.ErrBrokenLink) || errors.Is(err, story.ErrInvalid) {
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,166 @@
+package chapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestImportTranslationsHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "not a number", "locale": "en", "format": "po", "data": ""}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := ImportTranslationsHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "//import-translations", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestImportTranslationsHandler_Report(t *testing.T) {
+	tests := []struct {
+		name           string
+		data           string
+		saved          bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Переводы сохранены",
+			data: `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgctxt "chapter.name"
+msgid "Пролог"
+msgstr "Prologue"
+
+#, fuzzy
+msgctxt "node.10.event.1"
+msgid "Привет"
+msgstr "Hi"
+
+msgctxt "node.99.end"
+msgid "Конец"
+msgstr "The end"
+`,
+			saved:          true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"saved": 1, "skipped": 1, "unknown": ["node.99.end"]}`,
+		},
+		{
+			name: "Файл на другом языке",
+			data: `msgid ""
+msgstr ""
+"Language: de\n"
+`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	chapterColumns := []string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}
+	nodeColumns := []string{"id", "slug", "chapter_id", "music", "background", "events_raw", "branching_raw", "end_raw", "comment", "version"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.saved {
+				mock.ExpectQuery("FROM chapters").
+					WillReturnRows(sqlmock.NewRows(chapterColumns).AddRow(5, "Пролог", 10, "[10]", "[]", 1, "{}", 1, 1))
+				mock.ExpectQuery("FROM nodes").
+					WillReturnRows(sqlmock.NewRows(nodeColumns).
+						AddRow(10, "start", 5, 0, 0, `[{"Id":1,"Type":0,"Text":"Привет"}]`, "{}", "{}", "", 1))
+				// Сохраняется только проверенный перевод известной строки
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO "translations" .* ON CONFLICT`).
+					WithArgs("en", 5, "chapter.name", "Пролог", "Prologue", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			}
+
+			handler := ImportTranslationsHandler(gormDB, new(zerolog.Logger))
+
+			body, err := json.Marshal(ImportTranslationsRequest{Id: "5", Locale: "en", Format: "po", Data: tt.data})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/import-translations", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
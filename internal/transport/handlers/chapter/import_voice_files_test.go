@@ -0,0 +1,160 @@
+package chapter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestImportVoiceFilesHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := ImportVoiceFilesHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "//import-voice-files", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestImportVoiceFilesHandler_Attach(t *testing.T) {
+	tests := []struct {
+		name           string
+		lockAdmin      int64
+		expectedStatus int
+	}{
+		{name: "Файл прикреплен", lockAdmin: 7, expectedStatus: http.StatusOK},
+		{name: "Узел заблокирован другим", lockAdmin: 8, expectedStatus: http.StatusLocked},
+	}
+
+	chapterColumns := []string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}
+	nodeColumns := []string{"id", "slug", "chapter_id", "music", "background", "events_raw", "branching_raw", "end_raw", "comment", "version"}
+
+	// 99_1.ogg не относится ни к одному событию главы
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+
+	for _, name := range []string{"10_2.ogg", "99_1.ogg"} {
+		w, err := writer.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte("ogg"))
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, writer.Close())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mock.ExpectQuery("FROM chapters").
+				WillReturnRows(sqlmock.NewRows(chapterColumns).AddRow(5, "Глава", 10, "[10]", "[7]", 1, "{}", 1, 1))
+			mock.ExpectQuery("FROM nodes").
+				WillReturnRows(sqlmock.NewRows(nodeColumns).
+					AddRow(10, "start", 5, 0, 0, `[{"Id":1,"Type":0,"Text":"Тишина."},{"Id":2,"Type":3,"Character":7,"Text":"Привет"}]`, "{}", "{}", "", 3))
+			mock.ExpectQuery("FROM characters").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "color", "emotions_raw"}).
+					AddRow(7, "Алиса", "alice", "#ff0000", "{}"))
+			mock.ExpectQuery(`FROM "node_locks"`).
+				WillReturnRows(sqlmock.NewRows([]string{"node_id", "admin_id", "expires_at"}).
+					AddRow(10, tt.lockAdmin, time.Now().Add(time.Minute)))
+
+			if tt.expectedStatus == http.StatusOK {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO "media"`).
+					WithArgs([]byte("ogg"), "audio/ogg", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec(`UPDATE "nodes" SET .* WHERE id = \$\d+ AND version = \$\d+`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(`INSERT INTO "revisions"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			}
+
+			handler := ImportVoiceFilesHandler(gormDB, new(zerolog.Logger))
+
+			body, err := json.Marshal(ImportVoiceFilesRequest{Id: "5", AdminId: "7", Data: archive.Bytes()})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/import-voice-files", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			if tt.expectedStatus == http.StatusOK {
+				assert.JSONEq(t, `{"attached": 1, "unknown": ["99_1.ogg"]}`, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package chapter
+
+import (
+	"bytes"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"vn/internal/services/chapter"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestRestoreChapterHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := RestoreChapterHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/restore-chapter", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRestoreChapterHandler_Trash(t *testing.T) {
+	tests := []struct {
+		name           string
+		deletedAt      time.Time
+		found          bool
+		restored       bool
+		expectedStatus int
+	}{
+		{
+			name:           "Глава восстановлена",
+			deletedAt:      time.Now().Add(-time.Hour),
+			found:          true,
+			restored:       true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Срок восстановления истек",
+			deletedAt:      time.Now().Add(-chapter.RetentionPeriod - time.Hour),
+			found:          true,
+			expectedStatus: http.StatusGone,
+		},
+		{
+			name:           "Главы нет в корзине",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rows := sqlmock.NewRows([]string{"id", "snapshot", "deleted_at"})
+
+			if tt.found {
+				rows.AddRow(5, `{"Chapter":{"Id":5,"Name":"Глава","Version":4}}`, tt.deletedAt)
+			}
+
+			mock.ExpectQuery(`FROM "deleted_chapters"`).WillReturnRows(rows)
+
+			if tt.restored {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO "chapters"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+				mock.ExpectExec(`DELETE FROM "deleted_chapters"`).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			}
+
+			handler := RestoreChapterHandler(gormDB, new(zerolog.Logger))
+
+			req := httptest.NewRequest(http.MethodPost, "/restore-chapter", bytes.NewReader([]byte(`{"id": "5"}`)))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package chapter
+
+import (
+	"bytes"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestRollbackRevisionHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := RollbackRevisionHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "//rollback_revision", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRollbackRevisionHandler_Node(t *testing.T) {
+	tests := []struct {
+		name           string
+		lockAdmin      int64
+		data           string
+		expectedStatus int
+	}{
+		{name: "Откат узла", lockAdmin: 7, data: `{"Slug":"start","Comment":"старый"}`, expectedStatus: http.StatusOK},
+		{name: "Узел заблокирован другим", lockAdmin: 8, data: `{"Slug":"start","Comment":"старый"}`, expectedStatus: http.StatusLocked},
+		// Персонажа 9 больше нет в главе, ревизия не проходит проверку событий
+		{
+			name:           "Ревизия ссылается на удаленного персонажа",
+			lockAdmin:      7,
+			data:           `{"Slug":"start","Events":[{"Id":1,"Type":3,"Character":9,"Text":"Привет"}]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	revisionColumns := []string{"id", "entity_type", "entity_id", "chapter_id", "author", "data", "created_at"}
+	nodeColumns := []string{"id", "slug", "chapter_id", "music", "background", "events_raw", "branching_raw", "end_raw", "comment", "version"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mock.ExpectQuery(`FROM "revisions" WHERE id = \$1`).
+				WithArgs(20, 1).
+				WillReturnRows(sqlmock.NewRows(revisionColumns).
+					AddRow(20, "node", 10, 5, 7, tt.data, time.Now()))
+			mock.ExpectQuery("FROM nodes").
+				WillReturnRows(sqlmock.NewRows(nodeColumns).AddRow(10, "start", 5, 0, 0, "[]", "{}", "{}", "новый", 3))
+			mock.ExpectQuery(`FROM "node_locks"`).
+				WillReturnRows(sqlmock.NewRows([]string{"node_id", "admin_id", "expires_at"}).
+					AddRow(10, tt.lockAdmin, time.Now().Add(time.Minute)))
+
+			if tt.expectedStatus == http.StatusUnprocessableEntity {
+				mock.ExpectQuery("FROM chapters").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}).
+						AddRow(5, "Глава", 10, "[10]", "[]", 1, "{}", 1, 2))
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				// Откат сохраняется с текущей версией узла и пишет новую ревизию
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE "nodes" SET .* WHERE id = \$\d+ AND version = \$\d+`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(`INSERT INTO "revisions"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(22))
+				mock.ExpectCommit()
+			}
+
+			handler := RollbackRevisionHandler(gormDB, new(zerolog.Logger))
+
+			req := httptest.NewRequest(http.MethodPost, "/rollback-revision", bytes.NewReader([]byte(`{"id": "20", "author": "7"}`)))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package chapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/services/live"
+	"vn/internal/storage"
+	"vn/pkg/metrick"
+)
+
+// heartbeatInterval - как часто отправляется комментарий, чтобы прокси не закрывали соединение
+const heartbeatInterval = 30 * time.Second
+
+type ResponseLiveEvent struct {
+	Type      string `json:"type"`
+	ChapterId string `json:"chapter_id"`
+	NodeId    string `json:"node_id,omitempty"`
+	Status    int    `json:"status,omitempty"`
+	RequestId string `json:"request_id,omitempty"`
+	At        string `json:"at"`
+}
+
+// SubscribeChapterHandler - поток Server-Sent Events с изменениями главы для редактора.
+// EventSource умеет только GET, поэтому id главы передается в строке запроса: /subscribe-chapter?id=1
+func SubscribeChapterHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("chapter", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"chapter",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на подписку на изменения главы")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept, Last-Event-ID")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это GET-запрос
+		if r.Method != http.MethodGet {
+			log.Error().Msg("Only GET requests allowed in subscribe chapter")
+			http.Error(rw, "Only GET requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in subscribe chapter")
+			http.Error(rw, "Failed to covert id", http.StatusBadRequest)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+
+		if !ok {
+			log.Error().Msg("streaming unsupported in subscribe chapter")
+			http.Error(rw, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		_, err = storage.SelectChapterWIthId(db, id)
+
+		if err != nil {
+			log.Error().Msg("fail to get chapter in subscribe chapter")
+			http.Error(rw, "fail to get chapter", http.StatusNotFound)
+			return
+		}
+
+		events, cancel := live.Subscribe(id)
+		defer cancel()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		rw.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+				flusher.Flush()
+			case event, open := <-events:
+				if !open {
+					return
+				}
+
+				data, err := json.Marshal(PrepareLiveEventForResponse(event))
+
+				if err != nil {
+					log.Error().Msg("fail to marshal event in subscribe chapter")
+					continue
+				}
+
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+func PrepareLiveEventForResponse(event live.Event) ResponseLiveEvent {
+	res := ResponseLiveEvent{
+		Type:      event.Type,
+		ChapterId: utils.ToString(event.ChapterId),
+		Status:    event.Status,
+		At:        event.At.Format(time.RFC3339Nano),
+	}
+
+	if event.NodeId != 0 {
+		res.NodeId = utils.ToString(event.NodeId)
+	}
+
+	if event.RequestId != 0 {
+		res.RequestId = utils.ToString(event.RequestId)
+	}
+
+	return res
+}
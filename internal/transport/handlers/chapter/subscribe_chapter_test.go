@@ -0,0 +1,118 @@
+package chapter
+
+import (
+	"bufio"
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"vn/internal/services/live"
+)
+
+func TestSubscribeChapterHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		query          string
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodPost,
+			query:          "?id=1",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodGet,
+			query:          "?id=not-a-number",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := SubscribeChapterHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/subscribe-chapter"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestSubscribeChapterHandler_Stream(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery("FROM chapters").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}).
+			AddRow(7, "Глава", 10, "[10]", "[]", 1, "{}", 1, 1))
+
+	server := httptest.NewServer(SubscribeChapterHandler(gormDB, new(zerolog.Logger)))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?id=7", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// Событие другой главы не должно попасть в поток
+	live.Publish(live.Event{Type: live.NodeDeleted, ChapterId: 8, NodeId: 1})
+	live.Publish(live.Event{Type: live.NodeUpdated, ChapterId: 7, NodeId: 11})
+
+	reader := bufio.NewReader(resp.Body)
+
+	eventLine, err := reader.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "event: node_updated\n", eventLine)
+
+	dataLine, err := reader.ReadString('\n')
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(dataLine, "data: "))
+	assert.Contains(t, dataLine, `"chapter_id":"7"`)
+	assert.Contains(t, dataLine, `"node_id":"11"`)
+}
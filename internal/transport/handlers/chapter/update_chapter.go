@@ -2,12 +2,14 @@ package chapter
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/rs/zerolog"
 	"gorm.io/gorm"
 	"io/ioutil"
 	"net/http"
 	"strconv"
 	"time"
+	"vn/internal/models"
 	"vn/internal/services/chapter"
 	"vn/pkg/metrick"
 )
@@ -20,6 +22,7 @@ type UpdateChapterRequest struct {
 	Characters     []string `json:"characters,omitempty"`
 	Status         int      `json:"status,omitempty"` // 0 - черновик, 1 - на проверке, 2 - опубликована
 	UpdateAuthorId string   `json:"update_author_id,omitempty"`
+	Version        int      `json:"version"` // версия, которую видел клиент, обязательна
 }
 
 func UpdateChapterHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
@@ -152,11 +155,36 @@ func UpdateChapterHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
 			startNode = 0
 		}
 
-		err = chapter.UpdateChapter(id, req.Name, nodes, characters, author, startNode, req.Status, db)
+		version, err := chapter.UpdateChapter(id, req.Name, nodes, characters, author, startNode, req.Status, req.Version, db)
+
+		var conflictErr *chapter.ChapterConflictError
+
+		if errors.As(err, &conflictErr) {
+			log.Error().Msg("version conflict in chapters update")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"chapter": prepareChaptersForResponce([]models.Chapter{conflictErr.Current})[0],
+			})
+			return
+		}
+
+		if errors.Is(err, chapter.ErrVersionRequired) {
+			log.Error().Msg("version is required in chapters update")
+			http.Error(w, "version is required", http.StatusBadRequest)
+			return
+		}
 
 		if err != nil {
 			log.Error().Msg("fail to create chapter in chapters update")
 			http.Error(w, "fail to create chapter", http.StatusInternalServerError)
+			return
 		}
+
+		// Новая версия нужна клиенту для следующего изменения
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"version": version,
+		})
 	}
 }
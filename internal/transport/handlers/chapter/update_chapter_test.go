@@ -6,6 +6,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"net/http"
 	"net/http/httptest"
@@ -180,3 +181,100 @@ func TestUpdateChapterHandler_UpdateChapterError(t *testing.T) {
 
 	assert.Equal(t, 400, w.Code)
 }
+
+func TestUpdateChapterHandler_VersionConflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := UpdateChapterHandler(gormDB, new(zerolog.Logger))
+
+	// Глава уже изменена до версии 5
+	mock.ExpectQuery("FROM chapters").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}).
+			AddRow(1, "Текущая глава", 10, "[10]", "[]", 1, "{}", 123, 5))
+
+	reqBody := []byte(`{"id": "1", "name": "Updated Chapter", "update_author_id": "123", "version": 4}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/update-chapter", bytes.NewReader(reqBody))
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"Name":"Текущая глава"`)
+	assert.Contains(t, w.Body.String(), `"Version":5`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateChapterHandler_NewVersion(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		saved          bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Версия увеличивается",
+			body:           `{"id": "1", "name": "Новое имя", "update_author_id": "123", "version": 5}`,
+			saved:          true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"version": 6}`,
+		},
+		{
+			name:           "Версия не передана",
+			body:           `{"id": "1", "name": "Новое имя", "update_author_id": "123"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mock.ExpectQuery("FROM chapters").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}).
+					AddRow(1, "Текущая глава", 10, "[10]", "[]", 1, "{}", 123, 5))
+
+			if tt.saved {
+				// Глава сохраняется только с той версией, которую видел клиент
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE "chapters" SET .* WHERE id = \$\d+ AND version = \$\d+`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(`INSERT INTO "revisions"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			}
+
+			handler := UpdateChapterHandler(gormDB, new(zerolog.Logger))
+
+			req := httptest.NewRequest(http.MethodPost, "/update-chapter", bytes.NewReader([]byte(tt.body)))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package chapter
+
+import (
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/services/chapter"
+	"vn/pkg/metrick"
+)
+
+type ValidateChapterRequest struct {
+	Id string `json:"id"`
+}
+
+func ValidateChapterHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("chapter", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"chapter",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на проверку главы")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in chapter validation")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req ValidateChapterRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in chapter validation")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in chapter validation")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		id, err := strconv.ParseInt(req.Id, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in chapter validation")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		report, err := chapter.ValidateChapter(id, db)
+
+		if err != nil {
+			log.Error().Msg("fail to validate chapter in chapter validation")
+			http.Error(rw, "fail to validate chapter", http.StatusInternalServerError)
+			return
+		}
+
+		// Формируем ответ
+		response := map[string]interface{}{
+			"valid":  report.Valid(),
+			"errors": PrepareGraphIssuesForResponse(report.Errors),
+		}
+
+		// Отправляем ответ клиенту
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(response)
+	}
+}
+
+type ResponseGraphIssue struct {
+	Type    string   `json:"type"`
+	NodeId  string   `json:"node_id,omitempty"`
+	Target  string   `json:"target,omitempty"`
+	Nodes   []string `json:"nodes,omitempty"`
+	Message string   `json:"message"`
+}
+
+func PrepareGraphIssuesForResponse(issues []chapter.GraphIssue) []ResponseGraphIssue {
+	res := make([]ResponseGraphIssue, 0, len(issues))
+
+	for _, issue := range issues {
+		var nodes []string
+
+		for _, nodeId := range issue.Nodes {
+			nodes = append(nodes, utils.ToString(nodeId))
+		}
+
+		responseIssue := ResponseGraphIssue{
+			Type:    issue.Type,
+			Nodes:   nodes,
+			Message: issue.Message,
+		}
+
+		if issue.NodeId != 0 {
+			responseIssue.NodeId = utils.ToString(issue.NodeId)
+		}
+
+		if issue.Target != 0 {
+			responseIssue.Target = utils.ToString(issue.Target)
+		}
+
+		res = append(res, responseIssue)
+	}
+
+	return res
+}
@@ -0,0 +1,136 @@
+package chapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestValidateChapterHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := ValidateChapterHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/validate-chapter", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestValidateChapterHandler_Report(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chapterColumns := []string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}
+	nodeColumns := []string{"id", "slug", "chapter_id", "music", "background", "events_raw", "branching_raw", "end_raw", "comment", "version"}
+
+	// Узел 10 ведет в концовку 11
+	mock.ExpectQuery("FROM chapters").
+		WillReturnRows(sqlmock.NewRows(chapterColumns).AddRow(5, "Глава", 10, "[10,11]", "[]", 1, "{}", 1, 1))
+	mock.ExpectQuery("FROM nodes").
+		WillReturnRows(sqlmock.NewRows(nodeColumns).
+			AddRow(10, "start", 5, 0, 0, "[]", `{"Flag":false,"Choices":[{"NextNode":11}]}`, "{}", "", 1).
+			AddRow(11, "end", 5, 0, 0, "[]", "{}", `{"Flag":true}`, "", 1))
+
+	// Узел 11 удален из базы, но остался в списке главы, а узел 10 стал тупиком
+	mock.ExpectQuery("FROM chapters").
+		WillReturnRows(sqlmock.NewRows(chapterColumns).AddRow(5, "Глава", 10, "[10,11]", "[]", 1, "{}", 1, 1))
+	mock.ExpectQuery("FROM nodes").
+		WillReturnRows(sqlmock.NewRows(nodeColumns).
+			AddRow(10, "start", 5, 0, 0, "[]", "{}", "{}", "", 1))
+
+	handler := ValidateChapterHandler(gormDB, new(zerolog.Logger))
+
+	req := httptest.NewRequest(http.MethodPost, "/validate-chapter", bytes.NewReader([]byte(`{"id": "5"}`)))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"valid": true, "errors": []}`, w.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/validate-chapter", bytes.NewReader([]byte(`{"id": "5"}`)))
+	w = httptest.NewRecorder()
+	handler(w, req)
+
+	var response struct {
+		Valid  bool                 `json:"valid"`
+		Errors []ResponseGraphIssue `json:"errors"`
+	}
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.False(t, response.Valid)
+
+	types := map[string]string{}
+	for _, issue := range response.Errors {
+		types[issue.Type] = issue.NodeId
+	}
+
+	assert.Equal(t, "11", types["missing_node"])
+	assert.Equal(t, "10", types["dead_end"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,181 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/services/chapter"
+	"vn/internal/services/game"
+	"vn/internal/transport/handlers/node"
+	"vn/pkg/metrick"
+)
+
+type GetCurrentSceneRequest struct {
+	PlayerId  string `json:"player_id"`
+	ChapterId string `json:"chapter_id"`
+	Locale    string `json:"locale,omitempty"`
+}
+
+func GetCurrentSceneHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("game", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"game",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на получение текущей сцены")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in get current scene")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req GetCurrentSceneRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in get current scene")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in get current scene")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		playerId, err := strconv.ParseInt(req.PlayerId, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in get current scene")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		chapterId, err := strconv.ParseInt(req.ChapterId, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in get current scene")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		scene, err := game.GetCurrentScene(playerId, chapterId, req.Locale, db)
+
+		if err != nil {
+			log.Error().Msg("fail to get scene in get current scene")
+			http.Error(rw, "fail to get scene", statusForError(err))
+			return
+		}
+
+		// Формируем ответ
+		response := map[string]interface{}{
+			"scene": PrepareSceneForResponse(scene),
+		}
+
+		// Отправляем ответ клиенту
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(response)
+	}
+}
+
+type ResponseChoice struct {
+	Index int    `json:"index"`
+	Text  string `json:"text"`
+}
+
+type ResponseScene struct {
+	NodeId     string                `json:"node_id"`
+	ChapterId  string                `json:"chapter_id"`
+	Events     []node.ResponseEvent  `json:"events"`
+	Music      string                `json:"music"`
+	Background string                `json:"background"`
+	Choice     bool                  `json:"choice"` // true - игрок должен выбрать вариант
+	Choices    []ResponseChoice      `json:"choices"`
+	Completed  bool                  `json:"completed"`
+	Variables  map[string]int64      `json:"variables"`
+	End        *node.ResponseEndInfo `json:"end,omitempty"`
+}
+
+func PrepareSceneForResponse(scene *game.Scene) ResponseScene {
+	responseNode := node.PrepareNodeForResponse(scene.Node)
+
+	choices := make([]ResponseChoice, 0, len(scene.Choices))
+
+	for _, index := range scene.Choices {
+		choices = append(choices, ResponseChoice{
+			Index: index,
+			Text:  scene.Node.Branching.Choices[index].Text,
+		})
+	}
+
+	res := ResponseScene{
+		NodeId:     responseNode.Id,
+		ChapterId:  responseNode.ChapterId,
+		Events:     responseNode.Events,
+		Music:      responseNode.Music,
+		Background: responseNode.Background,
+		Choice:     scene.Node.Branching.Flag,
+		Choices:    choices,
+		Completed:  scene.Completed,
+		Variables:  scene.Variables,
+	}
+
+	if scene.Completed {
+		res.End = &responseNode.End
+	}
+
+	return res
+}
+
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, game.ErrInvalidChoice), errors.Is(err, chapter.ErrInvalidLocale):
+		return http.StatusBadRequest
+	case errors.Is(err, game.ErrChapterNotPublished):
+		return http.StatusForbidden
+	case errors.Is(err, game.ErrChapterNotStarted):
+		return http.StatusNotFound
+	case errors.Is(err, game.ErrChapterFinished):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,132 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestGetCurrentSceneHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"player_id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"player_id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "Invalid chapter ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"player_id": "1", "chapter_id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := GetCurrentSceneHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/get-current-scene", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestGetCurrentSceneHandler_Progress(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery("FROM players").
+		WillReturnRows(sqlmock.NewRows(testPlayerColumns).AddRow(1, "Игрок", "p@example.com", "", "", false, "[]", `{"5":10}`, 50))
+	mock.ExpectQuery(`FROM "player_chapter_versions"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "player_id", "chapter_id", "version_id"}).AddRow(1, 1, 5, 7))
+	mock.ExpectQuery(`FROM "chapter_versions"`).
+		WillReturnRows(sqlmock.NewRows(testVersionColumns).AddRow(7, 5, 1, testVersionContent, time.Now()))
+	mock.ExpectQuery(`FROM "variables"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chapter_id", "name", "type", "default"}).AddRow(3, 5, "trust", "int", 2))
+	mock.ExpectQuery(`FROM "player_variables"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "player_id", "chapter_id", "name", "value"}).AddRow(4, 1, 5, "trust", 4))
+
+	// Игрок не начинал главу 6
+	mock.ExpectQuery("FROM players").
+		WillReturnRows(sqlmock.NewRows(testPlayerColumns).AddRow(1, "Игрок", "p@example.com", "", "", false, "[]", `{"5":10}`, 50))
+
+	handler := GetCurrentSceneHandler(gormDB, new(zerolog.Logger))
+
+	req := httptest.NewRequest(http.MethodPost, "/get-current-scene", bytes.NewReader([]byte(`{"player_id": "1", "chapter_id": "5"}`)))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response struct {
+		Scene ResponseScene `json:"scene"`
+	}
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "10", response.Scene.NodeId)
+	assert.Equal(t, "Утро", response.Scene.Events[0].Text)
+	assert.Equal(t, map[string]int64{"trust": 4}, response.Scene.Variables)
+
+	req = httptest.NewRequest(http.MethodPost, "/get-current-scene", bytes.NewReader([]byte(`{"player_id": "1", "chapter_id": "6"}`)))
+	w = httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
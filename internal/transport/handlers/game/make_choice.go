@@ -0,0 +1,115 @@
+package game
+
+import (
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/services/game"
+	"vn/pkg/metrick"
+)
+
+type MakeChoiceRequest struct {
+	PlayerId  string `json:"player_id"`
+	ChapterId string `json:"chapter_id"`
+	Choice    int    `json:"choice"`
+	Locale    string `json:"locale,omitempty"`
+}
+
+func MakeChoiceHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("game", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"game",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на выбор варианта")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in make choice")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req MakeChoiceRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in make choice")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in make choice")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		playerId, err := strconv.ParseInt(req.PlayerId, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in make choice")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		chapterId, err := strconv.ParseInt(req.ChapterId, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in make choice")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		scene, err := game.MakeChoice(playerId, chapterId, req.Choice, req.Locale, db)
+
+		if err != nil {
+			log.Error().Msg("fail to make choice in make choice")
+			http.Error(rw, "fail to make choice", statusForError(err))
+			return
+		}
+
+		// Формируем ответ
+		response := map[string]interface{}{
+			"scene": PrepareSceneForResponse(scene),
+		}
+
+		// Отправляем ответ клиенту
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(response)
+	}
+}
@@ -0,0 +1,139 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestMakeChoiceHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"player_id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"player_id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "Invalid chapter ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"player_id": "1", "chapter_id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := MakeChoiceHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/make-choice", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestMakeChoiceHandler_Choice(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Оба запроса застают игрока в узле 10 закрепленной версии 7
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery("FROM players").
+			WillReturnRows(sqlmock.NewRows(testPlayerColumns).AddRow(1, "Игрок", "p@example.com", "", "", false, "[]", `{"5":10}`, 50))
+		mock.ExpectQuery(`FROM "player_chapter_versions"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "player_id", "chapter_id", "version_id"}).AddRow(1, 1, 5, 7))
+		mock.ExpectQuery(`FROM "chapter_versions"`).
+			WillReturnRows(sqlmock.NewRows(testVersionColumns).AddRow(7, 5, 1, testVersionContent, time.Now()))
+		mock.ExpectQuery(`FROM "variables"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "chapter_id", "name", "type", "default"}))
+		mock.ExpectQuery(`FROM "player_variables"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "player_id", "chapter_id", "name", "value"}))
+	}
+
+	// Прогресс сохраняется, глава отмечается пройденной
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "players"`).
+		WithArgs(false, []byte(`{"5":11}`), []byte(`[5]`), "p@example.com", "Игрок", "", "", 50, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	handler := MakeChoiceHandler(gormDB, new(zerolog.Logger))
+
+	// Варианта 3 в узле нет
+	req := httptest.NewRequest(http.MethodPost, "/make-choice", bytes.NewReader([]byte(`{"player_id": "1", "chapter_id": "5", "choice": 3}`)))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/make-choice", bytes.NewReader([]byte(`{"player_id": "1", "chapter_id": "5", "choice": 0}`)))
+	w = httptest.NewRecorder()
+	handler(w, req)
+
+	var response struct {
+		Scene ResponseScene `json:"scene"`
+	}
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "11", response.Scene.NodeId)
+	assert.True(t, response.Scene.Completed)
+	assert.Equal(t, "Конец", response.Scene.End.EndText)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
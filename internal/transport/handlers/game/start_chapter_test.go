@@ -0,0 +1,174 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestStartChapterHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"player_id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"player_id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "Invalid chapter ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"player_id": "1", "chapter_id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := StartChapterHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/start-chapter", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+// Опубликованная версия главы 5: из начала 10 можно пойти в концовку 11
+const testVersionContent = `{"Chapter":{"Id":5,"Name":"Глава","StartNode":10,"Nodes":[10,11],"Status":3,"Version":1},
+	"Nodes":[{"Id":10,"Slug":"start","ChapterId":5,"Events":[{"Id":1,"Type":1,"Text":"Утро"}],
+		"Branching":{"Flag":true,"Choices":[{"Id":21,"Text":"Уйти","NextNode":11}]}},
+	{"Id":11,"Slug":"end","ChapterId":5,"End":{"Flag":true,"EndResult":"good","EndText":"Конец"}}]}`
+
+var (
+	testChapterColumns = []string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}
+	testPlayerColumns  = []string{"id", "name", "email", "phone", "password", "admin", "completed_chapters_raw", "chapters_progress_raw", "sound_settings"}
+	testVersionColumns = []string{"id", "chapter_id", "version", "content", "published_at"}
+)
+
+func TestStartChapterHandler_Published(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery("FROM chapters").
+		WillReturnRows(sqlmock.NewRows(testChapterColumns).AddRow(5, "Черновик", 12, "[12]", "[]", 3, "{}", 1, 2))
+	mock.ExpectQuery("FROM players").
+		WillReturnRows(sqlmock.NewRows(testPlayerColumns).AddRow(1, "Игрок", "p@example.com", "", "", false, "[]", "{}", 50))
+	mock.ExpectQuery(`FROM "chapter_versions"`).
+		WillReturnRows(sqlmock.NewRows(testVersionColumns).AddRow(7, 5, 1, testVersionContent, time.Now()))
+	// Игрок закрепляется за версией 7, локальные переменные сбрасываются
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "player_chapter_versions"`).
+		WithArgs(1, 5, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "player_variables"`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`FROM "variables"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chapter_id", "name", "type", "default"}))
+	mock.ExpectQuery(`FROM "player_variables"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "player_id", "chapter_id", "name", "value"}))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "players"`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	handler := StartChapterHandler(gormDB, new(zerolog.Logger))
+
+	req := httptest.NewRequest(http.MethodPost, "/start-chapter", bytes.NewReader([]byte(`{"player_id": "1", "chapter_id": "5"}`)))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response struct {
+		Scene ResponseScene `json:"scene"`
+	}
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	// Начальный узел берется из опубликованной версии, а не из черновика
+	assert.Equal(t, "10", response.Scene.NodeId)
+	assert.Equal(t, []ResponseChoice{{Index: 0, Text: "Уйти"}}, response.Scene.Choices)
+	assert.False(t, response.Scene.Completed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStartChapterHandler_NotPublished(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery("FROM chapters").
+		WillReturnRows(sqlmock.NewRows(testChapterColumns).AddRow(5, "Черновик", 10, "[10]", "[]", 1, "{}", 1, 1))
+
+	handler := StartChapterHandler(gormDB, new(zerolog.Logger))
+
+	req := httptest.NewRequest(http.MethodPost, "/start-chapter", bytes.NewReader([]byte(`{"player_id": "1", "chapter_id": "5"}`)))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,124 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/services/chapter"
+	"vn/pkg/metrick"
+)
+
+type CreateNodeRequest struct {
+	ChapterId string `json:"chapter_id"`
+	Slug      string `json:"slug,omitempty"`
+}
+
+func CreateNodeHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("node", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"node",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на создание узла")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in create node")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req CreateNodeRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in create node")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in create node")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		chapterId, err := strconv.ParseInt(req.ChapterId, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in create node")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		id, err := chapter.CreateNode(chapterId, req.Slug, db)
+
+		if err != nil {
+			log.Error().Msg("fail to create node in create node")
+			http.Error(rw, "fail to create node", statusForNodeError(err))
+			return
+		}
+
+		// Формируем ответ
+		response := map[string]interface{}{
+			"id": utils.ToString(id),
+		}
+
+		// Отправляем ответ клиенту
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(response)
+	}
+}
+
+// statusForNodeError возвращает 409, если slug уже занят в главе или в узел ведут варианты выбора
+func statusForNodeError(err error) int {
+	if errors.Is(err, chapter.ErrSlugTaken) || errors.Is(err, chapter.ErrNodeHasIncomingChoices) {
+		return http.StatusConflict
+	}
+
+	if errors.Is(err, chapter.ErrLockNotHeld) {
+		return http.StatusLocked
+	}
+
+	if errors.Is(err, chapter.ErrVersionRequired) {
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
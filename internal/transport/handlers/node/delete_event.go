@@ -0,0 +1,158 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/services/chapter"
+	"vn/pkg/metrick"
+)
+
+type DeleteEventRequest struct {
+	NodeId  string `json:"node_id"`
+	EventId string `json:"event_id"`
+	Version int    `json:"version"`  // версия узла, которую видел клиент
+	AdminId string `json:"admin_id"` // админ, держащий блокировку узла
+}
+
+func DeleteEventHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("node", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"node",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на удаление события")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in delete event")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req DeleteEventRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in delete event")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in delete event")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		nodeId, err := strconv.ParseInt(req.NodeId, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in delete event")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		eventId, err := strconv.ParseInt(req.EventId, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in delete event")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		adminId, err := parseOptionalId(req.AdminId)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert admin id in delete event")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		version, err := chapter.DeleteEvent(nodeId, eventId, req.Version, adminId, db)
+
+		var lockedErr *chapter.NodeLockedError
+
+		if errors.As(err, &lockedErr) {
+			log.Error().Msg("node is locked in delete event")
+			writeNodeLocked(rw, lockedErr)
+			return
+		}
+
+		var conflictErr *chapter.NodeConflictError
+
+		if errors.As(err, &conflictErr) {
+			log.Error().Msg("version conflict in delete event")
+			writeNodeConflict(rw, conflictErr)
+			return
+		}
+
+		if err != nil {
+			log.Error().Msg("fail to delete event in delete event")
+			http.Error(rw, "fail to delete event", statusForEventError(err))
+			return
+		}
+
+		// Формируем ответ
+		response := map[string]interface{}{
+			"version": version,
+		}
+
+		// Отправляем ответ клиенту
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(response)
+	}
+}
+
+// statusForEventError отличает отсутствующее событие, запрос без версии и узел без блокировки
+// от остальных ошибок
+func statusForEventError(err error) int {
+	if errors.Is(err, chapter.ErrEventNotFound) {
+		return http.StatusNotFound
+	}
+
+	if errors.Is(err, chapter.ErrVersionRequired) {
+		return http.StatusBadRequest
+	}
+
+	if errors.Is(err, chapter.ErrLockNotHeld) {
+		return http.StatusLocked
+	}
+
+	return http.StatusInternalServerError
+}
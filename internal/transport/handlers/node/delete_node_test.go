@@ -0,0 +1,143 @@
+package node
+
+import (
+	"bytes"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestDeleteNodeHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := DeleteNodeHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/delete-node", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestDeleteNodeHandler_Choices(t *testing.T) {
+	tests := []struct {
+		name           string
+		startChoices   string
+		conflict       bool
+		expectedStatus int
+	}{
+		{name: "Узел удален", startChoices: "{}", expectedStatus: http.StatusOK},
+		{name: "В узел ведет вариант выбора", startChoices: `{"Choices":[{"Id":1,"NextNode":11}]}`, expectedStatus: http.StatusConflict},
+		// Глава изменилась, узел остается на месте
+		{name: "Конфликт версии главы", startChoices: "{}", conflict: true, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mock.ExpectQuery("FROM nodes").
+				WillReturnRows(sqlmock.NewRows(testNodeColumns).AddRow(11, "finale", 5, 0, 0, "[]", "{}", "{}", "", 1))
+			mock.ExpectQuery(`FROM "node_locks"`).
+				WillReturnRows(sqlmock.NewRows(testLockColumns).AddRow(11, 7, time.Now().Add(time.Minute)))
+			mock.ExpectQuery("FROM chapters").
+				WillReturnRows(sqlmock.NewRows(testChapterColumns).AddRow(5, "Глава", 10, "[10,11]", "[]", 1, "{}", 1, 2))
+			mock.ExpectBegin()
+			mock.ExpectQuery("FROM nodes").
+				WillReturnRows(sqlmock.NewRows(testNodeColumns).
+					AddRow(10, "start", 5, 0, 0, "[]", tt.startChoices, "{}", "", 1).
+					AddRow(11, "finale", 5, 0, 0, "[]", "{}", "{}", "", 1))
+
+			if tt.expectedStatus == http.StatusConflict {
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectExec(`DELETE FROM "nodes"`).WithArgs(11).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`DELETE FROM "node_locks"`).WithArgs(11, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+
+				if tt.conflict {
+					mock.ExpectExec(`UPDATE "chapters"`).WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectQuery(`SELECT count`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+					mock.ExpectExec("ROLLBACK TO SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectRollback()
+				} else {
+					mock.ExpectExec(`UPDATE "chapters"`).WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectQuery(`INSERT INTO "revisions"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+					mock.ExpectCommit()
+				}
+			}
+
+			handler := DeleteNodeHandler(gormDB, new(zerolog.Logger))
+
+			req := httptest.NewRequest(http.MethodPost, "/delete-node", bytes.NewReader([]byte(`{"id": "11", "admin_id": "7"}`)))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestDuplicateEventHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"node_id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"node_id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "Invalid event ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"node_id": "1", "event_id": "first"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := DuplicateEventHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/duplicate-event", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestDuplicateEventHandler_Versioning(t *testing.T) {
+	tests := []struct {
+		name           string
+		version        int
+		lockAdmin      int64
+		saved          bool
+		expectedStatus int
+	}{
+		{name: "Новая версия", version: 3, lockAdmin: 7, saved: true, expectedStatus: http.StatusOK},
+		{name: "Устаревшая версия", version: 2, lockAdmin: 7, expectedStatus: http.StatusConflict},
+		{name: "Чужая блокировка", version: 3, lockAdmin: 8, expectedStatus: http.StatusLocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectNodeWrite(mock, tt.lockAdmin, tt.saved)
+
+			handler := DuplicateEventHandler(gormDB, new(zerolog.Logger))
+
+			body := fmt.Sprintf(`{"node_id": "10", "event_id": "2", "version": %d, "admin_id": "7"}`, tt.version)
+			req := httptest.NewRequest(http.MethodPost, "/duplicate-event", bytes.NewReader([]byte(body)))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			var response struct {
+				Id      string           `json:"id"`
+				Version int              `json:"version"`
+				Node    ResponseNode     `json:"node"`
+				Lock    ResponseNodeLock `json:"lock"`
+			}
+
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+			switch tt.expectedStatus {
+			case http.StatusOK:
+				assert.Equal(t, 4, response.Version)
+				// Новое событие получает свой id
+				assert.NotContains(t, []string{"", "1", "2"}, response.Id)
+			case http.StatusConflict:
+				assert.Equal(t, 3, response.Node.Version)
+			case http.StatusLocked:
+				assert.Equal(t, "8", response.Lock.AdminId)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package node
+
+import (
+	"gorm.io/gorm/utils"
+	"vn/internal/models"
+)
+
+// Данные новых типов событий. Используются и в запросах, и в ответах
+
+type ResponseBackground struct {
+	Media      string `json:"media"`
+	Transition string `json:"transition,omitempty"`
+}
+
+type ResponseMusic struct {
+	Action string `json:"action"`
+	Media  string `json:"media,omitempty"`
+	FadeMs int64  `json:"fade_ms,omitempty"`
+	Loop   bool   `json:"loop,omitempty"`
+}
+
+type ResponseSoundEffect struct {
+	Media  string `json:"media"`
+	Volume int64  `json:"volume"`
+}
+
+type eventPayloads struct {
+	Background  *ResponseBackground
+	Music       *ResponseMusic
+	SoundEffect *ResponseSoundEffect
+	Screen      *models.ScreenEffectPayload
+	Wait        *models.WaitPayload
+}
+
+func parseEventPayloads(event *models.Event, payloads eventPayloads) error {
+	if payloads.Background != nil {
+		media, err := parseOptionalId(payloads.Background.Media)
+
+		if err != nil {
+			return err
+		}
+
+		event.Background = &models.BackgroundPayload{
+			Media:      media,
+			Transition: payloads.Background.Transition,
+		}
+	}
+
+	if payloads.Music != nil {
+		media, err := parseOptionalId(payloads.Music.Media)
+
+		if err != nil {
+			return err
+		}
+
+		event.Music = &models.MusicPayload{
+			Action: payloads.Music.Action,
+			Media:  media,
+			FadeMs: payloads.Music.FadeMs,
+			Loop:   payloads.Music.Loop,
+		}
+	}
+
+	if payloads.SoundEffect != nil {
+		media, err := parseOptionalId(payloads.SoundEffect.Media)
+
+		if err != nil {
+			return err
+		}
+
+		event.SoundEffect = &models.SoundEffectPayload{
+			Media:  media,
+			Volume: payloads.SoundEffect.Volume,
+		}
+	}
+
+	event.Screen = payloads.Screen
+	event.Wait = payloads.Wait
+
+	return nil
+}
+
+func prepareEventPayloadsForResponse(event models.Event) eventPayloads {
+	payloads := eventPayloads{
+		Screen: event.Screen,
+		Wait:   event.Wait,
+	}
+
+	if event.Background != nil {
+		payloads.Background = &ResponseBackground{
+			Media:      utils.ToString(event.Background.Media),
+			Transition: event.Background.Transition,
+		}
+	}
+
+	if event.Music != nil {
+		payloads.Music = &ResponseMusic{
+			Action: event.Music.Action,
+			FadeMs: event.Music.FadeMs,
+			Loop:   event.Music.Loop,
+		}
+
+		if event.Music.Media != 0 {
+			payloads.Music.Media = utils.ToString(event.Music.Media)
+		}
+	}
+
+	if event.SoundEffect != nil {
+		payloads.SoundEffect = &ResponseSoundEffect{
+			Media:  utils.ToString(event.SoundEffect.Media),
+			Volume: event.SoundEffect.Volume,
+		}
+	}
+
+	return payloads
+}
@@ -0,0 +1,226 @@
+package node
+
+import (
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/models"
+	"vn/internal/services/chapter"
+	"vn/pkg/metrick"
+)
+
+type GetNodeRequest struct {
+	Id string `json:"id"`
+}
+
+func GetNodeHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("node", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"node",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на получение узла")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in get node")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req GetNodeRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in get node")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in get node")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		id, err := strconv.ParseInt(req.Id, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in get node")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		node, err := chapter.GetNode(id, db)
+
+		if err != nil {
+			log.Error().Msg("fail to get node in get node")
+			http.Error(rw, "fail to get node", http.StatusNotFound)
+			return
+		}
+
+		// Формируем ответ
+		response := map[string]interface{}{
+			"node": PrepareNodeForResponse(*node),
+		}
+
+		// Отправляем ответ клиенту
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(response)
+	}
+}
+
+type ResponseEvent struct {
+	Id                string                      `json:"id"`
+	Type              int64                       `json:"type"`
+	Character         string                      `json:"character"`
+	Sound             string                      `json:"sound"`
+	CharactersInEvent map[string]map[string]int64 `json:"characters_in_event"`
+	Text              string                      `json:"text"`
+	Effects           []models.Effect             `json:"effects,omitempty"`
+	Background        *ResponseBackground         `json:"background,omitempty"`
+	Music             *ResponseMusic              `json:"music,omitempty"`
+	SoundEffect       *ResponseSoundEffect        `json:"sound_effect,omitempty"`
+	Screen            *models.ScreenEffectPayload `json:"screen,omitempty"`
+	Wait              *models.WaitPayload         `json:"wait,omitempty"`
+}
+
+type ResponseBranching struct {
+	Flag    bool             `json:"flag"`
+	Choices []ResponseChoice `json:"choices"`
+}
+
+type ResponseChoice struct {
+	Id        string `json:"id"`
+	Text      string `json:"text"`
+	NextNode  string `json:"next_node"`
+	Condition string `json:"condition,omitempty"`
+}
+
+type ResponseEndInfo struct {
+	Flag      bool   `json:"flag"`
+	EndResult string `json:"end_result"`
+	EndText   string `json:"end_text"`
+}
+
+type ResponseNode struct {
+	Id         string            `json:"id"`
+	Slug       string            `json:"slug"`
+	Events     []ResponseEvent   `json:"events"`
+	ChapterId  string            `json:"chapter_id"`
+	Music      string            `json:"music"`
+	Background string            `json:"background"`
+	Branching  ResponseBranching `json:"branching"`
+	End        ResponseEndInfo   `json:"end"`
+	Comment    string            `json:"comment"`
+	Version    int               `json:"version"`
+}
+
+func PrepareNodeForResponse(node models.Node) ResponseNode {
+	events := make([]ResponseEvent, 0, len(node.Events))
+
+	for _, event := range node.Events {
+		events = append(events, PrepareEventForResponse(event))
+	}
+
+	return ResponseNode{
+		Id:         utils.ToString(node.Id),
+		Slug:       node.Slug,
+		Events:     events,
+		ChapterId:  utils.ToString(node.ChapterId),
+		Music:      utils.ToString(node.Music),
+		Background: utils.ToString(node.Background),
+		Branching:  prepareBranchingForResponse(node.Branching),
+		End: ResponseEndInfo{
+			Flag:      node.End.Flag,
+			EndResult: node.End.EndResult,
+			EndText:   node.End.EndText,
+		},
+		Comment: node.Comment,
+		Version: node.Version,
+	}
+}
+
+func prepareBranchingForResponse(branching models.Branching) ResponseBranching {
+	choices := make([]ResponseChoice, 0, len(branching.Choices))
+
+	for _, choice := range branching.Choices {
+		choices = append(choices, ResponseChoice{
+			Id:        utils.ToString(choice.Id),
+			Text:      choice.Text,
+			NextNode:  utils.ToString(choice.NextNode),
+			Condition: choice.Condition,
+		})
+	}
+
+	return ResponseBranching{
+		Flag:    branching.Flag,
+		Choices: choices,
+	}
+}
+
+func PrepareEventForResponse(event models.Event) ResponseEvent {
+	charactersInEvent := make(map[string]map[string]int64, len(event.CharactersInEvent))
+
+	for characterId, emotions := range event.CharactersInEvent {
+		positions := make(map[string]int64, len(emotions))
+
+		for emotion, position := range emotions {
+			positions[utils.ToString(emotion)] = position
+		}
+
+		charactersInEvent[utils.ToString(characterId)] = positions
+	}
+
+	payloads := prepareEventPayloadsForResponse(event)
+
+	return ResponseEvent{
+		Id:                utils.ToString(event.Id),
+		Type:              event.Type,
+		Character:         utils.ToString(event.Character),
+		Sound:             utils.ToString(event.Sound),
+		CharactersInEvent: charactersInEvent,
+		Text:              event.Text,
+		Effects:           event.Effects,
+		Background:        payloads.Background,
+		Music:             payloads.Music,
+		SoundEffect:       payloads.SoundEffect,
+		Screen:            payloads.Screen,
+		Wait:              payloads.Wait,
+	}
+}
@@ -0,0 +1,134 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestGetNodeBySlugHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"chapter_id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"chapter_id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := GetNodeBySlugHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/get-node-by-slug", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestGetNodeBySlugHandler_Lookup(t *testing.T) {
+	tests := []struct {
+		name           string
+		slug           string
+		found          bool
+		expectedStatus int
+	}{
+		{name: "Узел найден", slug: "start", found: true, expectedStatus: http.StatusOK},
+		{name: "Узел не найден", slug: "missing", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rows := sqlmock.NewRows(testNodeColumns)
+
+			if tt.found {
+				rows.AddRow(10, "start", 5, 0, 0, testNodeEvents, "{}", "{}", "", 3)
+			}
+
+			mock.ExpectQuery(`FROM nodes\s+WHERE chapter_id = \$1 AND slug = \$2`).
+				WithArgs(5, tt.slug).
+				WillReturnRows(rows)
+
+			handler := GetNodeBySlugHandler(gormDB, new(zerolog.Logger))
+
+			body := []byte(`{"chapter_id": "5", "slug": "` + tt.slug + `"}`)
+			req := httptest.NewRequest(http.MethodPost, "/get-node-by-slug", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			if !tt.found {
+				return
+			}
+
+			var response struct {
+				Node ResponseNode `json:"node"`
+			}
+
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, "10", response.Node.Id)
+			assert.Equal(t, "start", response.Node.Slug)
+			assert.Equal(t, 3, response.Node.Version)
+		})
+	}
+}
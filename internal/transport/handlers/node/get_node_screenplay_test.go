@@ -0,0 +1,110 @@
+package node
+
+import (
+	"bytes"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestGetNodeScreenplayHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := GetNodeScreenplayHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "//get-node-screenplay", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+// expectScreenplayDictionary ожидает загрузку узла 10 и словаря главы 5 с узлами start и finale
+func expectScreenplayDictionary(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("FROM nodes").
+		WillReturnRows(sqlmock.NewRows(testNodeColumns).AddRow(10, "start", 5, 0, 0, testNodeEvents, "{}", "{}", "", 3))
+	mock.ExpectQuery("FROM chapters").
+		WillReturnRows(sqlmock.NewRows(testChapterColumns).AddRow(5, "Глава", 10, "[10,11]", "[]", 1, "{}", 1, 1))
+	mock.ExpectQuery("FROM nodes").
+		WillReturnRows(sqlmock.NewRows(testNodeColumns).
+			AddRow(10, "start", 5, 0, 0, testNodeEvents, "{}", "{}", "", 3).
+			AddRow(11, "finale", 5, 0, 0, "[]", "{}", "{}", "", 1))
+}
+
+func TestGetNodeScreenplayHandler_Text(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectScreenplayDictionary(mock)
+
+	handler := GetNodeScreenplayHandler(gormDB, new(zerolog.Logger))
+
+	req := httptest.NewRequest(http.MethodPost, "/get-node-screenplay", bytes.NewReader([]byte(`{"id": "10"}`)))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.JSONEq(t, `{"text": "Раз\nДва\n"}`, w.Body.String())
+}
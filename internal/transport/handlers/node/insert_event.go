@@ -0,0 +1,157 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/services/chapter"
+	"vn/pkg/metrick"
+)
+
+type InsertEventRequest struct {
+	NodeId   string       `json:"node_id"`
+	Position *int         `json:"position,omitempty"` // пусто - добавить в конец
+	Event    RequestEvent `json:"event"`
+	Version  int          `json:"version"`  // версия узла, которую видел клиент
+	AdminId  string       `json:"admin_id"` // админ, держащий блокировку узла
+}
+
+func InsertEventHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("node", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"node",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на добавление события")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in insert event")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req InsertEventRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in insert event")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in insert event")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		nodeId, err := strconv.ParseInt(req.NodeId, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in insert event")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		event, err := parseEvent(req.Event)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert event in insert event")
+			http.Error(rw, "Failed to covert event", http.StatusInternalServerError)
+			return
+		}
+
+		position := -1
+
+		if req.Position != nil {
+			position = *req.Position
+		}
+
+		adminId, err := parseOptionalId(req.AdminId)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert admin id in insert event")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		id, version, err := chapter.InsertEvent(nodeId, position, event, req.Version, adminId, db)
+
+		var lockedErr *chapter.NodeLockedError
+
+		if errors.As(err, &lockedErr) {
+			log.Error().Msg("node is locked in insert event")
+			writeNodeLocked(rw, lockedErr)
+			return
+		}
+
+		var conflictErr *chapter.NodeConflictError
+
+		if errors.As(err, &conflictErr) {
+			log.Error().Msg("version conflict in insert event")
+			writeNodeConflict(rw, conflictErr)
+			return
+		}
+
+		var validationErr *chapter.NodeValidationError
+
+		if errors.As(err, &validationErr) {
+			log.Error().Msg("event validation failed in insert event")
+			writeValidationErrors(rw, validationErr)
+			return
+		}
+
+		if err != nil {
+			log.Error().Msg("fail to insert event in insert event")
+			http.Error(rw, "fail to insert event", statusForEventError(err))
+			return
+		}
+
+		// Формируем ответ
+		response := map[string]interface{}{
+			"id":      utils.ToString(id),
+			"version": version,
+		}
+
+		// Отправляем ответ клиенту
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(response)
+	}
+}
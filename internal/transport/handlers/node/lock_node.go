@@ -0,0 +1,166 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/models"
+	"vn/internal/services/chapter"
+	"vn/pkg/metrick"
+)
+
+type LockNodeRequest struct {
+	NodeId  string `json:"node_id"`
+	AdminId string `json:"admin_id"`
+	Force   bool   `json:"force,omitempty"` // перехватить чужую блокировку, только для сверхадмина
+}
+
+func LockNodeHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("node", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"node",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на блокировку узла")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in lock node")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req LockNodeRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in lock node")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in lock node")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		nodeId, err := strconv.ParseInt(req.NodeId, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in lock node")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		adminId, err := strconv.ParseInt(req.AdminId, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in lock node")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		lock, err := chapter.LockNode(nodeId, adminId, req.Force, db)
+
+		var lockedErr *chapter.NodeLockedError
+
+		if errors.As(err, &lockedErr) {
+			log.Error().Msg("node is locked in lock node")
+			writeNodeLocked(rw, lockedErr)
+			return
+		}
+
+		if err != nil {
+			log.Error().Msg("fail to lock node in lock node")
+			http.Error(rw, "fail to lock node", statusForLockError(err))
+			return
+		}
+
+		// Формируем ответ
+		response := map[string]interface{}{
+			"lock": PrepareNodeLockForResponse(*lock),
+		}
+
+		// Отправляем ответ клиенту
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(response)
+	}
+}
+
+type ResponseNodeLock struct {
+	NodeId    string `json:"node_id"`
+	AdminId   string `json:"admin_id"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+func PrepareNodeLockForResponse(lock models.NodeLock) ResponseNodeLock {
+	return ResponseNodeLock{
+		NodeId:    utils.ToString(lock.NodeId),
+		AdminId:   utils.ToString(lock.AdminId),
+		ExpiresAt: lock.ExpiresAt.Format(time.RFC3339),
+	}
+}
+
+// writeNodeLocked отвечает 423 с текущим владельцем блокировки
+func writeNodeLocked(rw http.ResponseWriter, lockedErr *chapter.NodeLockedError) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusLocked)
+	json.NewEncoder(rw).Encode(map[string]interface{}{
+		"lock": PrepareNodeLockForResponse(lockedErr.Lock),
+	})
+}
+
+// statusForLockError возвращает 400 без админа, 403 для перехвата блокировки не сверхадмином,
+// 423 без блокировки и 404 для остальных ошибок
+func statusForLockError(err error) int {
+	if errors.Is(err, chapter.ErrInvalidAdmin) {
+		return http.StatusBadRequest
+	}
+
+	if errors.Is(err, chapter.ErrNotSuperAdmin) {
+		return http.StatusForbidden
+	}
+
+	if errors.Is(err, chapter.ErrLockNotHeld) {
+		return http.StatusLocked
+	}
+
+	return http.StatusNotFound
+}
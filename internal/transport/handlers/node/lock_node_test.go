@@ -0,0 +1,161 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestLockNodeHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"node_id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"node_id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := LockNodeHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "//lock_node", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestLockNodeHandler_Lock(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expect         func(mock sqlmock.Sqlmock)
+		expectedStatus int
+		expectedAdmin  string
+	}{
+		{
+			name: "Блокировка поставлена",
+			body: `{"node_id": "10", "admin_id": "7"}`,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`INSERT INTO "node_locks" .* ON CONFLICT`).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			expectedStatus: http.StatusOK,
+			expectedAdmin:  "7",
+		},
+		{
+			name: "Узел заблокирован другим",
+			body: `{"node_id": "10", "admin_id": "7"}`,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`INSERT INTO "node_locks" .* ON CONFLICT`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+				mock.ExpectQuery(`FROM "node_locks"`).
+					WillReturnRows(sqlmock.NewRows(testLockColumns).AddRow(10, 8, time.Now().Add(time.Minute)))
+			},
+			expectedStatus: http.StatusLocked,
+			expectedAdmin:  "8",
+		},
+		{
+			name: "Перехват не сверхадмином",
+			body: `{"node_id": "10", "admin_id": "7", "force": true}`,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM admins").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "password", "admin_status", "created_chapters_raw", "request_sent_raw", "requests_received_raw"}).
+						AddRow(7, "Админ", "a@example.com", "", 0, "[]", "[]", "[]"))
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mock.ExpectQuery("FROM nodes").
+				WillReturnRows(sqlmock.NewRows(testNodeColumns).AddRow(10, "start", 5, 0, 0, testNodeEvents, "{}", "{}", "", 3))
+			tt.expect(mock)
+
+			handler := LockNodeHandler(gormDB, new(zerolog.Logger))
+
+			req := httptest.NewRequest(http.MethodPost, "/lock-node", bytes.NewReader([]byte(tt.body)))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			if tt.expectedAdmin == "" {
+				return
+			}
+
+			// При конфликте клиент видит, кто держит узел
+			var response struct {
+				Lock ResponseNodeLock `json:"lock"`
+			}
+
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, "10", response.Lock.NodeId)
+			assert.Equal(t, tt.expectedAdmin, response.Lock.AdminId)
+		})
+	}
+}
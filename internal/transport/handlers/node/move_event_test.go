@@ -0,0 +1,189 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestMoveEventHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"node_id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"node_id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "Invalid event ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"node_id": "1", "event_id": "first"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := MoveEventHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/move-event", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+var (
+	testNodeColumns    = []string{"id", "slug", "chapter_id", "music", "background", "events_raw", "branching_raw", "end_raw", "comment", "version"}
+	testLockColumns    = []string{"node_id", "admin_id", "expires_at"}
+	testChapterColumns = []string{"id", "name", "start_node", "nodes_raw", "characters_raw", "status", "updated_at_raw", "author", "version"}
+)
+
+// Узел 10 версии 3 с двумя репликами рассказчика
+const testNodeEvents = `[{"Id":1,"Type":0,"Text":"Раз"},{"Id":2,"Type":0,"Text":"Два"}]`
+
+// expectNodeWrite ожидает чтение узла 10 и блокировки, которую держит lockAdmin.
+// При saved ожидается сохранение узла с ревизией
+func expectNodeWrite(mock sqlmock.Sqlmock, lockAdmin int64, saved bool) {
+	mock.ExpectQuery("FROM nodes").
+		WillReturnRows(sqlmock.NewRows(testNodeColumns).AddRow(10, "start", 5, 0, 0, testNodeEvents, "{}", "{}", "", 3))
+	mock.ExpectQuery(`FROM "node_locks"`).
+		WillReturnRows(sqlmock.NewRows(testLockColumns).AddRow(10, lockAdmin, time.Now().Add(time.Minute)))
+
+	if !saved {
+		return
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "nodes" SET .* WHERE id = \$\d+ AND version = \$\d+`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "revisions"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+}
+
+func TestMoveEventHandler_Versioning(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		lockAdmin      int64
+		saved          bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Новая версия",
+			body:           `{"node_id": "10", "event_id": "2", "position": 0, "version": 3, "admin_id": "7"}`,
+			lockAdmin:      7,
+			saved:          true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"version": 4}`,
+		},
+		{
+			name:           "Устаревшая версия",
+			body:           `{"node_id": "10", "event_id": "2", "position": 0, "version": 2, "admin_id": "7"}`,
+			lockAdmin:      7,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Чужая блокировка",
+			body:           `{"node_id": "10", "event_id": "2", "position": 0, "version": 3, "admin_id": "7"}`,
+			lockAdmin:      8,
+			expectedStatus: http.StatusLocked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectNodeWrite(mock, tt.lockAdmin, tt.saved)
+
+			handler := MoveEventHandler(gormDB, new(zerolog.Logger))
+
+			req := httptest.NewRequest(http.MethodPost, "/move-event", bytes.NewReader([]byte(tt.body)))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			switch tt.expectedStatus {
+			case http.StatusOK:
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			case http.StatusConflict:
+				// Клиент получает текущий узел, чтобы слить изменения
+				var response struct {
+					Node ResponseNode `json:"node"`
+				}
+
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, 3, response.Node.Version)
+				assert.Equal(t, "1", response.Node.Events[0].Id)
+			case http.StatusLocked:
+				var response struct {
+					Lock ResponseNodeLock `json:"lock"`
+				}
+
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, "8", response.Lock.AdminId)
+			}
+		})
+	}
+}
@@ -0,0 +1,383 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/models"
+	"vn/internal/services/chapter"
+	"vn/pkg/metrick"
+)
+
+type UpdateNodeRequest struct {
+	Id         string            `json:"id"`
+	Slug       string            `json:"slug,omitempty"`
+	Events     []RequestEvent    `json:"events,omitempty"`
+	Music      string            `json:"music,omitempty"`
+	Background string            `json:"background,omitempty"`
+	Branching  *RequestBranching `json:"branching,omitempty"`
+	End        *ResponseEndInfo  `json:"end,omitempty"`
+	Comment    string            `json:"comment,omitempty"`
+	Version    int               `json:"version"`  // версия, которую видел клиент, обязательна
+	AdminId    string            `json:"admin_id"` // админ, держащий блокировку узла
+}
+
+type RequestBranching struct {
+	Flag    bool            `json:"flag"`
+	Choices []RequestChoice `json:"choices"`
+}
+
+type RequestChoice struct {
+	Id        string `json:"id,omitempty"` // пустой id - новый вариант
+	Text      string `json:"text"`
+	NextNode  string `json:"next_node"`
+	Condition string `json:"condition,omitempty"`
+}
+
+type RequestEvent struct {
+	Id                string                      `json:"id,omitempty"` // пустой id - новое событие
+	Type              int64                       `json:"type"`
+	Character         string                      `json:"character,omitempty"`
+	Sound             string                      `json:"sound,omitempty"`
+	CharactersInEvent map[string]map[string]int64 `json:"characters_in_event,omitempty"`
+	Text              string                      `json:"text"`
+	Effects           []models.Effect             `json:"effects,omitempty"`
+	Background        *ResponseBackground         `json:"background,omitempty"`
+	Music             *ResponseMusic              `json:"music,omitempty"`
+	SoundEffect       *ResponseSoundEffect        `json:"sound_effect,omitempty"`
+	Screen            *models.ScreenEffectPayload `json:"screen,omitempty"`
+	Wait              *models.WaitPayload         `json:"wait,omitempty"`
+}
+
+func UpdateNodeHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("node", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"node",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на обновление узла")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in node update")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req UpdateNodeRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in node update")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in node update")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		id, err := strconv.ParseInt(req.Id, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in node update")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		events, err := parseEvents(req.Events)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert events in node update")
+			http.Error(rw, "Failed to covert events", http.StatusInternalServerError)
+			return
+		}
+
+		music, err := parseOptionalId(req.Music)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert music in node update")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		background, err := parseOptionalId(req.Background)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert background in node update")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		adminId, err := parseOptionalId(req.AdminId)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert admin id in node update")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		branching, err := parseBranching(req.Branching)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert branching in node update")
+			http.Error(rw, "Failed to covert branching", http.StatusInternalServerError)
+			return
+		}
+
+		var end *models.EndInfo
+
+		if req.End != nil {
+			end = &models.EndInfo{
+				Flag:      req.End.Flag,
+				EndResult: req.End.EndResult,
+				EndText:   req.End.EndText,
+			}
+		}
+
+		version, err := chapter.UpdateNode(id, req.Slug, events, music, background, branching, end, req.Comment, req.Version, adminId, db)
+
+		var conflictErr *chapter.NodeConflictError
+
+		if errors.As(err, &conflictErr) {
+			log.Error().Msg("version conflict in node update")
+			writeNodeConflict(rw, conflictErr)
+			return
+		}
+
+		var lockedErr *chapter.NodeLockedError
+
+		if errors.As(err, &lockedErr) {
+			log.Error().Msg("node is locked in node update")
+			writeNodeLocked(rw, lockedErr)
+			return
+		}
+
+		var validationErr *chapter.NodeValidationError
+
+		if errors.As(err, &validationErr) {
+			log.Error().Msg("node validation failed in node update")
+			writeValidationErrors(rw, validationErr)
+			return
+		}
+
+		if err != nil {
+			log.Error().Msg("fail to update node in node update")
+			http.Error(rw, "fail to update node", statusForNodeError(err))
+			return
+		}
+
+		// Новая версия нужна клиенту для следующего изменения
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(map[string]interface{}{
+			"version": version,
+		})
+	}
+}
+
+// writeNodeConflict отвечает 409 с текущим состоянием узла, чтобы клиент мог объединить правки
+func writeNodeConflict(rw http.ResponseWriter, conflictErr *chapter.NodeConflictError) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusConflict)
+	json.NewEncoder(rw).Encode(map[string]interface{}{
+		"node": PrepareNodeForResponse(conflictErr.Current),
+	})
+}
+
+type ResponseValidationError struct {
+	EventId string `json:"event_id,omitempty"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// writeValidationErrors отвечает 422 со списком ошибок, чтобы редактор мог подсветить поля
+func writeValidationErrors(rw http.ResponseWriter, validationErr *chapter.NodeValidationError) {
+	errs := make([]ResponseValidationError, 0, len(validationErr.Errors))
+
+	for _, fieldErr := range validationErr.Errors {
+		responseErr := ResponseValidationError{
+			Field:   fieldErr.Field,
+			Message: fieldErr.Message,
+		}
+
+		if fieldErr.EventId != 0 {
+			responseErr.EventId = utils.ToString(fieldErr.EventId)
+		}
+
+		errs = append(errs, responseErr)
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(rw).Encode(map[string]interface{}{
+		"errors": errs,
+	})
+}
+
+func parseOptionalId(id string) (int64, error) {
+	if id == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseInt(id, 10, 64)
+}
+
+func parseBranching(reqBranching *RequestBranching) (*models.Branching, error) {
+	if reqBranching == nil {
+		return nil, nil
+	}
+
+	choices := make([]models.Choice, 0, len(reqBranching.Choices))
+
+	for _, reqChoice := range reqBranching.Choices {
+		id, err := parseOptionalId(reqChoice.Id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		nextNode, err := strconv.ParseInt(reqChoice.NextNode, 10, 64)
+
+		if err != nil {
+			return nil, err
+		}
+
+		choices = append(choices, models.Choice{
+			Id:        id,
+			Text:      reqChoice.Text,
+			NextNode:  nextNode,
+			Condition: reqChoice.Condition,
+		})
+	}
+
+	return &models.Branching{
+		Flag:    reqBranching.Flag,
+		Choices: choices,
+	}, nil
+}
+
+func parseEvents(reqEvents []RequestEvent) (models.Events, error) {
+	if reqEvents == nil {
+		return nil, nil
+	}
+
+	events := make(models.Events, 0, len(reqEvents))
+
+	for _, reqEvent := range reqEvents {
+		event, err := parseEvent(reqEvent)
+
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+func parseEvent(reqEvent RequestEvent) (models.Event, error) {
+	id, err := parseOptionalId(reqEvent.Id)
+
+	if err != nil {
+		return models.Event{}, err
+	}
+
+	character, err := parseOptionalId(reqEvent.Character)
+
+	if err != nil {
+		return models.Event{}, err
+	}
+
+	sound, err := parseOptionalId(reqEvent.Sound)
+
+	if err != nil {
+		return models.Event{}, err
+	}
+
+	charactersInEvent := make(map[int64]map[int64]int64, len(reqEvent.CharactersInEvent))
+
+	for characterKey, emotions := range reqEvent.CharactersInEvent {
+		characterId, err := strconv.ParseInt(characterKey, 10, 64)
+
+		if err != nil {
+			return models.Event{}, err
+		}
+
+		positions := make(map[int64]int64, len(emotions))
+
+		for emotionKey, position := range emotions {
+			emotion, err := strconv.ParseInt(emotionKey, 10, 64)
+
+			if err != nil {
+				return models.Event{}, err
+			}
+
+			positions[emotion] = position
+		}
+
+		charactersInEvent[characterId] = positions
+	}
+
+	event := models.Event{
+		Id:                id,
+		Type:              reqEvent.Type,
+		Character:         character,
+		Sound:             sound,
+		CharactersInEvent: charactersInEvent,
+		Text:              reqEvent.Text,
+		Effects:           reqEvent.Effects,
+	}
+
+	err = parseEventPayloads(&event, eventPayloads{
+		Background:  reqEvent.Background,
+		Music:       reqEvent.Music,
+		SoundEffect: reqEvent.SoundEffect,
+		Screen:      reqEvent.Screen,
+		Wait:        reqEvent.Wait,
+	})
+
+	if err != nil {
+		return models.Event{}, err
+	}
+
+	return event, nil
+}
@@ -0,0 +1,145 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+	"vn/internal/services/chapter"
+	"vn/internal/services/screenplay"
+	"vn/pkg/metrick"
+)
+
+type UpdateNodeScreenplayRequest struct {
+	Id      string `json:"id"`
+	Text    string `json:"text"`
+	Version int    `json:"version"`  // версия, которую видел клиент, обязательна
+	AdminId string `json:"admin_id"` // админ, держащий блокировку узла
+}
+
+func UpdateNodeScreenplayHandler(db *gorm.DB, log *zerolog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		// Создаем wrapper для ResponseWriter чтобы отслеживать статус
+		rw := &metrick.StatusRecorder{ResponseWriter: w}
+
+		// Вызываем оригинальную функцию обработчика
+		defer func() {
+			// Записываем время выполнения запроса
+			duration := time.Since(startTime).Seconds()
+
+			// Записываем метрики
+			metrick.RequestDuration.WithLabelValues("node", r.Method).
+				Observe(duration)
+
+			metrick.RequestCount.WithLabelValues(
+				"node",
+				r.Method,
+				strconv.Itoa(rw.StatusCode),
+			).Inc()
+		}()
+
+		log.Info().Msg("получен запрос на обновление узла из текста")
+		// Добавляем CORS заголовки
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+
+		// Обрабатываем предварительный запрос (OPTIONS)
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Проверяем, что это POST-запрос
+		if r.Method != http.MethodPost {
+			log.Error().Msg("Only POST requests allowed in node screenplay update")
+			http.Error(rw, "Only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Читаем тело запроса
+		var req UpdateNodeScreenplayRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Msg("Failed to read request body in node screenplay update")
+			http.Error(rw, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		// Разбираем JSON
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Error().Msg("Invalid JSON format in node screenplay update")
+			http.Error(rw, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		id, err := strconv.ParseInt(req.Id, 10, 64)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert id in node screenplay update")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		adminId, err := parseOptionalId(req.AdminId)
+
+		if err != nil {
+			log.Error().Msg("Failed to covert admin id in node screenplay update")
+			http.Error(rw, "Failed to covert id", http.StatusInternalServerError)
+			return
+		}
+
+		version, err := chapter.UpdateNodeScreenplay(id, req.Text, req.Version, adminId, db)
+
+		// Ошибка разбора содержит номер строки, ее показывают автору
+		if errors.Is(err, screenplay.ErrInvalid) {
+			log.Error().Msg("invalid screenplay in node screenplay update")
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var conflictErr *chapter.NodeConflictError
+
+		if errors.As(err, &conflictErr) {
+			log.Error().Msg("version conflict in node screenplay update")
+			writeNodeConflict(rw, conflictErr)
+			return
+		}
+
+		var lockedErr *chapter.NodeLockedError
+
+		if errors.As(err, &lockedErr) {
+			log.Error().Msg("node is locked in node screenplay update")
+			writeNodeLocked(rw, lockedErr)
+			return
+		}
+
+		var validationErr *chapter.NodeValidationError
+
+		if errors.As(err, &validationErr) {
+			log.Error().Msg("node validation failed in node screenplay update")
+			writeValidationErrors(rw, validationErr)
+			return
+		}
+
+		if err != nil {
+			log.Error().Msg("fail to update node in node screenplay update")
+			http.Error(rw, "fail to update node", statusForNodeError(err))
+			return
+		}
+
+		// Новая версия нужна клиенту для следующего изменения
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(map[string]interface{}{
+			"version": version,
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestUpdateNodeScreenplayHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "not a number", "text": "Привет"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := UpdateNodeScreenplayHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "//update-node-screenplay", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestUpdateNodeScreenplayHandler_Versioning(t *testing.T) {
+	tests := []struct {
+		name           string
+		text           string
+		version        int
+		lockAdmin      int64
+		expectedStatus int
+	}{
+		{name: "Новая версия", text: "Раз\nДва с половиной\n\n> Дальше -> finale\n", version: 3, lockAdmin: 7, expectedStatus: http.StatusOK},
+		{name: "Устаревшая версия", text: "Раз\n", version: 2, lockAdmin: 7, expectedStatus: http.StatusConflict},
+		{name: "Чужая блокировка", text: "Раз\n", version: 3, lockAdmin: 8, expectedStatus: http.StatusLocked},
+		{name: "Неизвестный узел", text: "> Уйти -> nowhere\n", version: 3, lockAdmin: 7, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectScreenplayDictionary(mock)
+
+			// Разобранный текст сохраняется так же, как обычное изменение узла
+			if tt.expectedStatus != http.StatusBadRequest {
+				mock.ExpectQuery("FROM nodes").
+					WillReturnRows(sqlmock.NewRows(testNodeColumns).AddRow(10, "start", 5, 0, 0, testNodeEvents, "{}", "{}", "", 3))
+				mock.ExpectQuery(`FROM "node_locks"`).
+					WillReturnRows(sqlmock.NewRows(testLockColumns).AddRow(10, tt.lockAdmin, time.Now().Add(time.Minute)))
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				mock.ExpectQuery("FROM chapters").
+					WillReturnRows(sqlmock.NewRows(testChapterColumns).AddRow(5, "Глава", 10, "[10,11]", "[]", 1, "{}", 1, 1))
+				mock.ExpectQuery("FROM chapters").
+					WillReturnRows(sqlmock.NewRows(testChapterColumns).AddRow(5, "Глава", 10, "[10,11]", "[]", 1, "{}", 1, 1))
+				mock.ExpectQuery(`SELECT "id" FROM "nodes"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE "nodes" SET .* WHERE id = \$\d+ AND version = \$\d+`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(`INSERT INTO "revisions"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			}
+
+			handler := UpdateNodeScreenplayHandler(gormDB, new(zerolog.Logger))
+
+			body, err := json.Marshal(UpdateNodeScreenplayRequest{Id: "10", Text: tt.text, Version: tt.version, AdminId: "7"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/update-node-screenplay", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			switch tt.expectedStatus {
+			case http.StatusOK:
+				assert.JSONEq(t, `{"version": 4}`, w.Body.String())
+			case http.StatusConflict:
+				var response struct {
+					Node ResponseNode `json:"node"`
+				}
+
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, 3, response.Node.Version)
+			case http.StatusLocked:
+				var response struct {
+					Lock ResponseNodeLock `json:"lock"`
+				}
+
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, "8", response.Lock.AdminId)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gorm.io/driver/postgres"
+)
+
+func TestUpdateNodeHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "OPTIONS request",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Empty body",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "invalid json`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "Invalid event ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "1", "events": [{"id": "first", "type": 0}]}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "Invalid event character",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "1", "events": [{"type": 3, "character": "anna"}]}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "Invalid choice target",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "1", "branching": {"flag": true, "choices": [{"text": "Уйти", "next_node": "exit"}]}}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "Invalid music ID",
+			method:         http.MethodPost,
+			body:           []byte(`{"id": "1", "music": "not a number"}`),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	handler := UpdateNodeHandler(
+		&gorm.DB{Config: &gorm.Config{ConnPool: db}},
+		new(zerolog.Logger),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/update-node", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestParseEvents(t *testing.T) {
+	events, err := parseEvents([]RequestEvent{
+		{
+			Id:                "5",
+			Type:              3,
+			Character:         "42",
+			Sound:             "7",
+			CharactersInEvent: map[string]map[string]int64{"42": {"1": 30}},
+			Text:              "Привет",
+		},
+		{Type: 0, Text: "Новое событие"},
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, int64(5), events[0].Id)
+	assert.Equal(t, int64(0), events[1].Id)
+	assert.Equal(t, int64(42), events[0].Character)
+	assert.Equal(t, int64(7), events[0].Sound)
+	assert.Equal(t, int64(30), events[0].CharactersInEvent[42][1])
+	assert.Equal(t, "Привет", events[0].Text)
+
+	events, err = parseEvents([]RequestEvent{
+		{Type: 4, Background: &ResponseBackground{Media: "9", Transition: "fade"}},
+		{Type: 5, Music: &ResponseMusic{Action: "stop"}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9), events[0].Background.Media)
+	assert.Equal(t, "fade", events[0].Background.Transition)
+	assert.Equal(t, int64(0), events[1].Music.Media)
+
+	_, err = parseEvents([]RequestEvent{{Type: 4, Background: &ResponseBackground{Media: "forest"}}})
+
+	assert.Error(t, err)
+
+	events, err = parseEvents(nil)
+
+	assert.NoError(t, err)
+	assert.Nil(t, events)
+}
+
+func TestParseBranching(t *testing.T) {
+	branching, err := parseBranching(&RequestBranching{
+		Flag: true,
+		Choices: []RequestChoice{
+			{Text: "Остаться", NextNode: "11"},
+			{Text: "Уйти", NextNode: "12"},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, branching.Flag)
+	assert.Equal(t, []int64{11, 12}, branching.Targets())
+	assert.Equal(t, "Остаться", branching.Choices[0].Text)
+
+	branching, err = parseBranching(nil)
+
+	assert.NoError(t, err)
+	assert.Nil(t, branching)
+}
+
+func TestUpdateNodeHandler_Versioning(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		lockAdmin      int64
+		saved          bool
+		expectedStatus int
+	}{
+		{
+			name:           "Новая версия",
+			body:           `{"id": "10", "comment": "Новый комментарий", "version": 3, "admin_id": "7"}`,
+			lockAdmin:      7,
+			saved:          true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Устаревшая версия",
+			body:           `{"id": "10", "comment": "Новый комментарий", "version": 2, "admin_id": "7"}`,
+			lockAdmin:      7,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Версия не передана",
+			body:           `{"id": "10", "comment": "Новый комментарий", "admin_id": "7"}`,
+			lockAdmin:      7,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Чужая блокировка",
+			body:           `{"id": "10", "comment": "Новый комментарий", "version": 3, "admin_id": "7"}`,
+			lockAdmin:      8,
+			expectedStatus: http.StatusLocked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectNodeWrite(mock, tt.lockAdmin, tt.saved)
+
+			handler := UpdateNodeHandler(gormDB, new(zerolog.Logger))
+
+			req := httptest.NewRequest(http.MethodPost, "/update-node", bytes.NewReader([]byte(tt.body)))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			switch tt.expectedStatus {
+			case http.StatusOK:
+				assert.JSONEq(t, `{"version": 4}`, w.Body.String())
+			case http.StatusConflict:
+				// Клиент получает текущий узел, чтобы слить изменения
+				var response struct {
+					Node ResponseNode `json:"node"`
+				}
+
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, 3, response.Node.Version)
+				assert.Equal(t, "start", response.Node.Slug)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package condition
+
+import "fmt"
+
+// Check проверяет типы выражения по объявленным переменным и возвращает тип результата
+func Check(expr Expr, variables map[string]Type) (Type, error) {
+	switch e := expr.(type) {
+	case *numberExpr:
+		return TypeInt, nil
+	case *boolExpr:
+		return TypeBool, nil
+	case *variableExpr:
+		t, ok := variables[e.name]
+		if !ok {
+			return 0, &Error{Pos: e.offset, Message: fmt.Sprintf("undeclared variable %q", e.name)}
+		}
+		return t, nil
+	case *unaryExpr:
+		want := TypeInt
+		if e.op == "!" {
+			want = TypeBool
+		}
+		if err := expect(e.x, want, e.op, variables); err != nil {
+			return 0, err
+		}
+		return want, nil
+	case *binaryExpr:
+		return checkBinary(e, variables)
+	default:
+		return 0, &Error{Pos: expr.pos(), Message: "unknown expression"}
+	}
+}
+
+func checkBinary(e *binaryExpr, variables map[string]Type) (Type, error) {
+	switch e.op {
+	case "&&", "||":
+		if err := expect(e.left, TypeBool, e.op, variables); err != nil {
+			return 0, err
+		}
+		if err := expect(e.right, TypeBool, e.op, variables); err != nil {
+			return 0, err
+		}
+		return TypeBool, nil
+	case "==", "!=":
+		left, err := Check(e.left, variables)
+		if err != nil {
+			return 0, err
+		}
+		right, err := Check(e.right, variables)
+		if err != nil {
+			return 0, err
+		}
+		if left != right {
+			return 0, &Error{Pos: e.offset, Message: fmt.Sprintf("can not compare %s with %s", left, right)}
+		}
+		return TypeBool, nil
+	case "<", "<=", ">", ">=":
+		if err := expect(e.left, TypeInt, e.op, variables); err != nil {
+			return 0, err
+		}
+		if err := expect(e.right, TypeInt, e.op, variables); err != nil {
+			return 0, err
+		}
+		return TypeBool, nil
+	default:
+		if err := expect(e.left, TypeInt, e.op, variables); err != nil {
+			return 0, err
+		}
+		if err := expect(e.right, TypeInt, e.op, variables); err != nil {
+			return 0, err
+		}
+		return TypeInt, nil
+	}
+}
+
+func expect(expr Expr, want Type, op string, variables map[string]Type) error {
+	t, err := Check(expr, variables)
+
+	if err != nil {
+		return err
+	}
+
+	if t != want {
+		return &Error{Pos: expr.pos(), Message: fmt.Sprintf("operator %s expects %s, got %s", op, want, t)}
+	}
+
+	return nil
+}
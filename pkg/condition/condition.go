@@ -0,0 +1,134 @@
+package condition
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Язык условий для вариантов выбора, например:
+//
+//	affinity.anna >= 3 && !flag.met_boss
+//
+// Поддерживаются целые числа, true/false, переменные вида a.b.c,
+// арифметика (+ - * / %), сравнения (== != < <= > >=),
+// логические операции (! && ||) и скобки.
+
+type Type int
+
+const (
+	TypeInt Type = iota
+	TypeBool
+)
+
+func (t Type) String() string {
+	if t == TypeBool {
+		return "bool"
+	}
+	return "int"
+}
+
+// Expr - разобранное выражение
+type Expr interface {
+	String() string
+	pos() int
+}
+
+type numberExpr struct {
+	offset int
+	value  int64
+}
+
+type boolExpr struct {
+	offset int
+	value  bool
+}
+
+type variableExpr struct {
+	offset int
+	name   string
+}
+
+type unaryExpr struct {
+	offset int
+	op     string
+	x      Expr
+}
+
+type binaryExpr struct {
+	offset int
+	op     string
+	left   Expr
+	right  Expr
+}
+
+func (e *numberExpr) String() string   { return strconv.FormatInt(e.value, 10) }
+func (e *boolExpr) String() string     { return strconv.FormatBool(e.value) }
+func (e *variableExpr) String() string { return e.name }
+func (e *unaryExpr) String() string    { return e.op + e.x.String() }
+func (e *binaryExpr) String() string {
+	return fmt.Sprintf("(%s %s %s)", e.left.String(), e.op, e.right.String())
+}
+
+func (e *numberExpr) pos() int   { return e.offset }
+func (e *boolExpr) pos() int     { return e.offset }
+func (e *variableExpr) pos() int { return e.offset }
+func (e *unaryExpr) pos() int    { return e.offset }
+func (e *binaryExpr) pos() int   { return e.offset }
+
+// Error - ошибка в условии с позицией (в байтах от начала строки)
+type Error struct {
+	Pos     int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Message)
+}
+
+// Compile разбирает условие и проверяет типы по объявленным переменным.
+// Результат условия должен быть логическим
+func Compile(src string, variables map[string]Type) (Expr, error) {
+	expr, err := Parse(src)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := Check(expr, variables)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if t != TypeBool {
+		return nil, &Error{Pos: 0, Message: fmt.Sprintf("condition must be bool, got %s", t)}
+	}
+
+	return expr, nil
+}
+
+// Variables возвращает имена переменных, используемых в выражении
+func Variables(expr Expr) []string {
+	var names []string
+	seen := map[string]bool{}
+
+	var walk func(e Expr)
+	walk = func(e Expr) {
+		switch e := e.(type) {
+		case *variableExpr:
+			if !seen[e.name] {
+				seen[e.name] = true
+				names = append(names, e.name)
+			}
+		case *unaryExpr:
+			walk(e.x)
+		case *binaryExpr:
+			walk(e.left)
+			walk(e.right)
+		}
+	}
+
+	walk(expr)
+
+	return names
+}
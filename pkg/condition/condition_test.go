@@ -0,0 +1,106 @@
+package condition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testVariables = map[string]Type{
+	"affinity.anna": TypeInt,
+	"affinity.boss": TypeInt,
+	"flag.met_boss": TypeBool,
+}
+
+func TestCompileAndEval(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		values   map[string]int64
+		expected bool
+	}{
+		{
+			name:     "Сравнение и отрицание флага",
+			src:      "affinity.anna >= 3 && !flag.met_boss",
+			values:   map[string]int64{"affinity.anna": 3},
+			expected: true,
+		},
+		{
+			name:     "Флаг установлен",
+			src:      "affinity.anna >= 3 && !flag.met_boss",
+			values:   map[string]int64{"affinity.anna": 5, "flag.met_boss": 1},
+			expected: false,
+		},
+		{
+			name:     "Приоритет операторов",
+			src:      "affinity.anna + affinity.boss * 2 == 7 || false",
+			values:   map[string]int64{"affinity.anna": 1, "affinity.boss": 3},
+			expected: true,
+		},
+		{
+			name:     "Скобки и унарный минус",
+			src:      "-(affinity.anna - 10) > 4",
+			values:   map[string]int64{"affinity.anna": 5},
+			expected: true,
+		},
+		{
+			name:     "Сравнение флагов",
+			src:      "flag.met_boss == true",
+			values:   map[string]int64{},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Compile(tt.src, testVariables)
+			assert.NoError(t, err)
+
+			result, err := Eval(expr, tt.values)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		src         string
+		expectedPos int
+	}{
+		{name: "Пустое условие", src: "", expectedPos: 0},
+		{name: "Незакрытая скобка", src: "(affinity.anna > 1", expectedPos: 18},
+		{name: "Неизвестный символ", src: "affinity.anna # 1", expectedPos: 14},
+		{name: "Необъявленная переменная", src: "affinity.kate > 1", expectedPos: 0},
+		{name: "Флаг в арифметике", src: "flag.met_boss + 1 > 0", expectedPos: 0},
+		{name: "Результат не логический", src: "affinity.anna + 1", expectedPos: 0},
+		{name: "Лишний токен", src: "true false", expectedPos: 5},
+		{name: "Точка в конце имени", src: "affinity. > 1", expectedPos: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.src, testVariables)
+
+			var conditionErr *Error
+			assert.ErrorAs(t, err, &conditionErr)
+			assert.Equal(t, tt.expectedPos, conditionErr.Pos)
+		})
+	}
+}
+
+func TestEvalDivisionByZero(t *testing.T) {
+	expr, err := Compile("affinity.anna / affinity.boss > 0", testVariables)
+	assert.NoError(t, err)
+
+	_, err = Eval(expr, map[string]int64{"affinity.anna": 1})
+	assert.ErrorIs(t, err, ErrDivisionByZero)
+}
+
+func TestVariables(t *testing.T) {
+	expr, err := Parse("affinity.anna >= 3 && (!flag.met_boss || affinity.anna > 5)")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"affinity.anna", "flag.met_boss"}, Variables(expr))
+}
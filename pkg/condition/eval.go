@@ -0,0 +1,107 @@
+package condition
+
+import "errors"
+
+var ErrDivisionByZero = errors.New("division by zero")
+
+// Eval вычисляет условие. Значения переменных хранятся как целые числа,
+// флаги - как 0 и 1. Необъявленные переменные считаются равными 0
+func Eval(expr Expr, values map[string]int64) (bool, error) {
+	value, err := eval(expr, values)
+
+	if err != nil {
+		return false, err
+	}
+
+	return value != 0, nil
+}
+
+func eval(expr Expr, values map[string]int64) (int64, error) {
+	switch e := expr.(type) {
+	case *numberExpr:
+		return e.value, nil
+	case *boolExpr:
+		return boolToInt(e.value), nil
+	case *variableExpr:
+		return values[e.name], nil
+	case *unaryExpr:
+		x, err := eval(e.x, values)
+		if err != nil {
+			return 0, err
+		}
+		if e.op == "!" {
+			return boolToInt(x == 0), nil
+		}
+		return -x, nil
+	case *binaryExpr:
+		return evalBinary(e, values)
+	default:
+		return 0, &Error{Pos: expr.pos(), Message: "unknown expression"}
+	}
+}
+
+func evalBinary(e *binaryExpr, values map[string]int64) (int64, error) {
+	left, err := eval(e.left, values)
+
+	if err != nil {
+		return 0, err
+	}
+
+	// Логические операторы вычисляются по короткой схеме
+	switch e.op {
+	case "&&":
+		if left == 0 {
+			return 0, nil
+		}
+	case "||":
+		if left != 0 {
+			return 1, nil
+		}
+	}
+
+	right, err := eval(e.right, values)
+
+	if err != nil {
+		return 0, err
+	}
+
+	switch e.op {
+	case "&&", "||":
+		return boolToInt(right != 0), nil
+	case "==":
+		return boolToInt(left == right), nil
+	case "!=":
+		return boolToInt(left != right), nil
+	case "<":
+		return boolToInt(left < right), nil
+	case "<=":
+		return boolToInt(left <= right), nil
+	case ">":
+		return boolToInt(left > right), nil
+	case ">=":
+		return boolToInt(left >= right), nil
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/", "%":
+		if right == 0 {
+			return 0, ErrDivisionByZero
+		}
+		if e.op == "/" {
+			return left / right, nil
+		}
+		return left % right, nil
+	default:
+		return 0, &Error{Pos: e.offset, Message: "unknown operator " + e.op}
+	}
+}
+
+func boolToInt(value bool) int64 {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,250 @@
+package condition
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOperator
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	start int
+}
+
+// Операторы упорядочены так, чтобы двухсимвольные проверялись первыми
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "%"}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+
+	for i < len(src) {
+		c := src[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", start: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", start: i})
+			i++
+		case isDigit(c):
+			start := i
+			for i < len(src) && isDigit(src[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: src[start:i], start: start})
+		case isIdentStart(c):
+			start := i
+			for i < len(src) && (isIdentPart(src[i]) || src[i] == '.') {
+				i++
+			}
+			text := src[start:i]
+			if strings.HasSuffix(text, ".") || strings.Contains(text, "..") {
+				return nil, &Error{Pos: start, Message: fmt.Sprintf("invalid variable name %q", text)}
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: text, start: start})
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, token{kind: tokenOperator, text: op, start: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, &Error{Pos: i, Message: fmt.Sprintf("unexpected character %q", c)}
+			}
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, start: len(src)})
+
+	return tokens, nil
+}
+
+func isDigit(c byte) bool      { return c >= '0' && c <= '9' }
+func isIdentStart(c byte) bool { return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isIdentPart(c byte) bool  { return isIdentStart(c) || isDigit(c) }
+
+type parser struct {
+	tokens []token
+	next   int
+}
+
+// Parse разбирает выражение без проверки типов
+func Parse(src string) (Expr, error) {
+	tokens, err := tokenize(src)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+
+	if p.peek().kind == tokenEOF {
+		return nil, &Error{Pos: 0, Message: "empty condition"}
+	}
+
+	expr, err := p.parseOr()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, &Error{Pos: t.start, Message: fmt.Sprintf("unexpected %q", t.text)}
+	}
+
+	return expr, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) accept(ops ...string) (token, bool) {
+	t := p.peek()
+
+	if t.kind != tokenOperator {
+		return t, false
+	}
+
+	for _, op := range ops {
+		if t.text == op {
+			p.next++
+			return t, true
+		}
+	}
+
+	return t, false
+}
+
+// Приоритеты: || < && < сравнение < + - < * / % < унарные
+func (p *parser) parseOr() (Expr, error) {
+	return p.parseBinary(p.parseAnd, "||")
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	return p.parseBinary(p.parseComparison, "&&")
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	left, err := p.parseSum()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if t, ok := p.accept("==", "!=", "<", "<=", ">", ">="); ok {
+		right, err := p.parseSum()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &binaryExpr{offset: t.start, op: t.text, left: left, right: right}, nil
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseSum() (Expr, error) {
+	return p.parseBinary(p.parseProduct, "+", "-")
+}
+
+func (p *parser) parseProduct() (Expr, error) {
+	return p.parseBinary(p.parseUnary, "*", "/", "%")
+}
+
+func (p *parser) parseBinary(operand func() (Expr, error), ops ...string) (Expr, error) {
+	left, err := operand()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t, ok := p.accept(ops...)
+
+		if !ok {
+			return left, nil
+		}
+
+		right, err := operand()
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = &binaryExpr{offset: t.start, op: t.text, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if t, ok := p.accept("!", "-"); ok {
+		x, err := p.parseUnary()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &unaryExpr{offset: t.start, op: t.text, x: x}, nil
+	}
+
+	return p.parseAtom()
+}
+
+func (p *parser) parseAtom() (Expr, error) {
+	t := p.peek()
+
+	switch t.kind {
+	case tokenNumber:
+		p.next++
+		value, err := strconv.ParseInt(t.text, 10, 64)
+		if err != nil {
+			return nil, &Error{Pos: t.start, Message: fmt.Sprintf("invalid number %q", t.text)}
+		}
+		return &numberExpr{offset: t.start, value: value}, nil
+	case tokenIdent:
+		p.next++
+		switch t.text {
+		case "true":
+			return &boolExpr{offset: t.start, value: true}, nil
+		case "false":
+			return &boolExpr{offset: t.start, value: false}, nil
+		}
+		return &variableExpr{offset: t.start, name: t.text}, nil
+	case tokenLParen:
+		p.next++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.peek(); closing.kind != tokenRParen {
+			return nil, &Error{Pos: closing.start, Message: "expected )"}
+		}
+		p.next++
+		return expr, nil
+	case tokenEOF:
+		return nil, &Error{Pos: t.start, Message: "unexpected end of condition"}
+	default:
+		return nil, &Error{Pos: t.start, Message: fmt.Sprintf("unexpected %q", t.text)}
+	}
+}
@@ -0,0 +1,137 @@
+package jsondiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+)
+
+const (
+	OpAdded   = "added"
+	OpRemoved = "removed"
+	OpChanged = "changed"
+)
+
+// Change - отличие одного значения между двумя JSON документами.
+// Path строится из имен полей и индексов, например Branching.Choices[1].Text.
+// Числа в Old и New - json.Number
+type Change struct {
+	Path string
+	Op   string
+	Old  interface{}
+	New  interface{}
+}
+
+// Diff сравнивает два JSON документа и возвращает изменения в порядке путей.
+// Объекты сравниваются по ключам, массивы - по индексам
+func Diff(from []byte, to []byte) ([]Change, error) {
+	a, err := decode(from)
+
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора исходного документа: %w", err)
+	}
+
+	b, err := decode(to)
+
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора нового документа: %w", err)
+	}
+
+	changes := []Change{}
+	diff("", a, b, &changes)
+
+	return changes, nil
+}
+
+// decode разбирает документ, сохраняя числа как json.Number. Id из generateUniqueId
+// не помещаются в float64 без потерь, и соседние id считались бы равными
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v interface{}
+
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after document")
+	}
+
+	return v, nil
+}
+
+func diff(path string, a interface{}, b interface{}, changes *[]Change) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		if bv, ok := b.(map[string]interface{}); ok {
+			diffObjects(path, av, bv, changes)
+			return
+		}
+	case []interface{}:
+		if bv, ok := b.([]interface{}); ok {
+			diffArrays(path, av, bv, changes)
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Path: path, Op: OpChanged, Old: a, New: b})
+	}
+}
+
+func diffObjects(path string, a map[string]interface{}, b map[string]interface{}, changes *[]Change) {
+	keys := make([]string, 0, len(a)+len(b))
+
+	for key := range a {
+		keys = append(keys, key)
+	}
+
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field := key
+
+		if path != "" {
+			field = path + "." + key
+		}
+
+		av, inA := a[key]
+		bv, inB := b[key]
+
+		switch {
+		case !inA:
+			*changes = append(*changes, Change{Path: field, Op: OpAdded, New: bv})
+		case !inB:
+			*changes = append(*changes, Change{Path: field, Op: OpRemoved, Old: av})
+		default:
+			diff(field, av, bv, changes)
+		}
+	}
+}
+
+func diffArrays(path string, a []interface{}, b []interface{}, changes *[]Change) {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		item := fmt.Sprintf("%s[%d]", path, i)
+
+		switch {
+		case i >= len(a):
+			*changes = append(*changes, Change{Path: item, Op: OpAdded, New: b[i]})
+		case i >= len(b):
+			*changes = append(*changes, Change{Path: item, Op: OpRemoved, Old: a[i]})
+		default:
+			diff(item, a[i], b[i], changes)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	from := []byte(`{"Name":"Глава","Nodes":[1,2,3],"Branching":{"Choices":[{"Text":"Да"}]},"Comment":"старый"}`)
+	to := []byte(`{"Name":"Глава 1","Nodes":[1,2],"Branching":{"Choices":[{"Text":"Нет"},{"Text":"Может"}]},"Music":7}`)
+
+	changes, err := Diff(from, to)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Path: "Branching.Choices[0].Text", Op: OpChanged, Old: "Да", New: "Нет"},
+		{Path: "Branching.Choices[1]", Op: OpAdded, New: map[string]interface{}{"Text": "Может"}},
+		{Path: "Comment", Op: OpRemoved, Old: "старый"},
+		{Path: "Music", Op: OpAdded, New: json.Number("7")},
+		{Path: "Name", Op: OpChanged, Old: "Глава", New: "Глава 1"},
+		{Path: "Nodes[2]", Op: OpRemoved, Old: json.Number("3")},
+	}, changes)
+}
+
+func TestDiffEqual(t *testing.T) {
+	changes, err := Diff([]byte(`{"a":[1,{"b":null}]}`), []byte(`{"a":[1,{"b":null}]}`))
+
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestDiffInvalidJSON(t *testing.T) {
+	_, err := Diff([]byte(`{`), []byte(`{}`))
+
+	assert.Error(t, err)
+}
+
+func TestDiffLargeIds(t *testing.T) {
+	changes, err := Diff([]byte(`{"Character":117000000000000001}`), []byte(`{"Character":117000000000000002}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Path: "Character", Op: OpChanged, Old: json.Number("117000000000000001"), New: json.Number("117000000000000002")},
+	}, changes)
+
+	data, err := json.Marshal(changes[0])
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"New":117000000000000002`)
+}
+
+func TestDiffTrailingData(t *testing.T) {
+	_, err := Diff([]byte(`{} {}`), []byte(`{}`))
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,22 @@
+// Package l10n читает и пишет файлы переводов для внешних переводчиков:
+// gettext PO и XLIFF 1.2
+package l10n
+
+import "errors"
+
+var ErrInvalid = errors.New("invalid translation file")
+
+// Unit - одна переводимая строка
+type Unit struct {
+	Key    string // постоянный ключ строки (msgctxt в PO, id в XLIFF)
+	Source string // исходный текст
+	Target string // перевод, пусто - не переведено
+	Note   string // подсказка переводчику
+	Fuzzy  bool   // перевод устарел или требует проверки
+}
+
+// File - содержимое файла перевода
+type File struct {
+	Locale string // язык перевода из заголовка файла, может быть пустым
+	Units  []Unit
+}
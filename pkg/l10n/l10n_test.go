@@ -0,0 +1,79 @@
+package l10n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testFile = File{
+	Locale: "en",
+	Units: []Unit{
+		{Key: "chapter.name", Source: "Пролог", Target: "Prologue"},
+		{Key: "node.1.event.2", Source: "Первая строка\nвторая \"строка\"", Target: "First line\nsecond \"line\"", Note: "Алиса", Fuzzy: true},
+		{Key: "node.1.choice.0", Source: "Уйти <тихо> & быстро"},
+	},
+}
+
+func TestPORoundTrip(t *testing.T) {
+	file, err := ReadPO(WritePO(testFile))
+
+	assert.NoError(t, err)
+	assert.Equal(t, &testFile, file)
+}
+
+func TestXLIFFRoundTrip(t *testing.T) {
+	data, err := WriteXLIFF(testFile, "ru", "chapter_1")
+	assert.NoError(t, err)
+
+	file, err := ReadXLIFF(data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &testFile, file)
+}
+
+func TestReadPO(t *testing.T) {
+	data := []byte(`# Перевод главы
+msgid ""
+msgstr ""
+"Language: de\n"
+
+#, fuzzy
+#| msgid "старый"
+msgctxt "node.1.end"
+msgid ""
+"Конец\n"
+"истории"
+msgstr "Ende\nder Geschichte"
+
+# запись без контекста пропускается
+msgid "без ключа"
+msgstr "ohne Schlüssel"
+
+#~ msgctxt "node.2.end"
+#~ msgid "удалено"
+#~ msgstr "gelöscht"
+`)
+
+	file, err := ReadPO(data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &File{
+		Locale: "de",
+		Units:  []Unit{{Key: "node.1.end", Source: "Конец\nистории", Target: "Ende\nder Geschichte", Fuzzy: true}},
+	}, file)
+
+	_, err = ReadPO([]byte(`msgid_plural "x"`))
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	_, err = ReadPO([]byte(`msgid "не закрыта`))
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestReadXLIFFInvalid(t *testing.T) {
+	_, err := ReadXLIFF([]byte(`<xliff version="2.0"><file id="f1"></file></xliff>`))
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	_, err = ReadXLIFF([]byte(`не xml`))
+	assert.ErrorIs(t, err, ErrInvalid)
+}
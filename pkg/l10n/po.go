@@ -0,0 +1,186 @@
+package l10n
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WritePO пишет строки в формате gettext PO. Ключ строки хранится в msgctxt
+func WritePO(file File) []byte {
+	var b bytes.Buffer
+
+	b.WriteString("msgid \"\"\n")
+	b.WriteString("msgstr \"\"\n")
+	b.WriteString("\"Content-Type: text/plain; charset=UTF-8\\n\"\n")
+	b.WriteString("\"Content-Transfer-Encoding: 8bit\\n\"\n")
+
+	if file.Locale != "" {
+		fmt.Fprintf(&b, "\"Language: %s\\n\"\n", file.Locale)
+	}
+
+	for _, unit := range file.Units {
+		b.WriteString("\n")
+
+		if unit.Note != "" {
+			for _, line := range strings.Split(unit.Note, "\n") {
+				fmt.Fprintf(&b, "#. %s\n", line)
+			}
+		}
+
+		if unit.Fuzzy {
+			b.WriteString("#, fuzzy\n")
+		}
+
+		writePOString(&b, "msgctxt", unit.Key)
+		writePOString(&b, "msgid", unit.Source)
+		writePOString(&b, "msgstr", unit.Target)
+	}
+
+	return b.Bytes()
+}
+
+// writePOString пишет многострочный текст по строке на каждый перевод строки, как xgettext
+func writePOString(b *bytes.Buffer, keyword string, value string) {
+	if !strings.Contains(value, "\n") || strings.Index(value, "\n") == len(value)-1 {
+		fmt.Fprintf(b, "%s %s\n", keyword, quotePO(value))
+		return
+	}
+
+	fmt.Fprintf(b, "%s \"\"\n", keyword)
+
+	for _, line := range strings.SplitAfter(value, "\n") {
+		if line != "" {
+			fmt.Fprintf(b, "%s\n", quotePO(line))
+		}
+	}
+}
+
+var poEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+
+func quotePO(s string) string {
+	return `"` + poEscaper.Replace(s) + `"`
+}
+
+// ReadPO разбирает файл PO. Записи без msgctxt и формы множественного числа не поддерживаются
+func ReadPO(data []byte) (*File, error) {
+	file := &File{}
+
+	var unit Unit
+	var hasContext, hasId bool
+	var field *string
+	lineNumber := 0
+
+	finish := func() {
+		if hasId {
+			if !hasContext && unit.Source == "" {
+				file.Locale = poHeader(unit.Target, "Language")
+			} else if hasContext {
+				file.Units = append(file.Units, unit)
+			}
+		}
+
+		unit = Unit{}
+		hasContext, hasId = false, false
+		field = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#~"):
+			// Устаревшие записи
+			continue
+		case strings.HasPrefix(line, "#"):
+			if hasId {
+				finish()
+			}
+
+			if strings.HasPrefix(line, "#,") && strings.Contains(line, "fuzzy") {
+				unit.Fuzzy = true
+			} else if strings.HasPrefix(line, "#.") {
+				note := strings.TrimSpace(line[2:])
+
+				if unit.Note != "" {
+					unit.Note += "\n"
+				}
+
+				unit.Note += note
+			}
+		case strings.HasPrefix(line, `"`):
+			if field == nil {
+				return nil, fmt.Errorf("%w: line %d: string without keyword", ErrInvalid, lineNumber)
+			}
+
+			value, err := strconv.Unquote(line)
+
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalid, lineNumber, err)
+			}
+
+			*field += value
+		default:
+			keyword, rest, _ := strings.Cut(line, " ")
+
+			value, err := strconv.Unquote(strings.TrimSpace(rest))
+
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalid, lineNumber, err)
+			}
+
+			switch keyword {
+			case "msgctxt":
+				if hasId {
+					finish()
+				}
+
+				hasContext = true
+				unit.Key = value
+				field = &unit.Key
+			case "msgid":
+				if hasId {
+					finish()
+				}
+
+				hasId = true
+				unit.Source = value
+				field = &unit.Source
+			case "msgstr":
+				unit.Target = value
+				field = &unit.Target
+			default:
+				return nil, fmt.Errorf("%w: line %d: unsupported keyword %q", ErrInvalid, lineNumber, keyword)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	finish()
+
+	return file, nil
+}
+
+// poHeader достает значение поля из заголовка PO
+func poHeader(header string, name string) string {
+	for _, line := range strings.Split(header, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+
+		if ok && strings.EqualFold(strings.TrimSpace(key), name) {
+			return strings.TrimSpace(value)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,118 @@
+package l10n
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+const xliffNamespace = "urn:oasis:names:tc:xliff:document:1.2"
+
+// Состояние перевода, которое пишется для устаревших строк. При чтении
+// любое состояние needs-* считается непроверенным переводом
+const xliffNeedsReview = "needs-review-translation"
+
+type xliffDocument struct {
+	XMLName xml.Name    `xml:"xliff"`
+	Xmlns   string      `xml:"xmlns,attr,omitempty"`
+	Version string      `xml:"version,attr"`
+	Files   []xliffFile `xml:"file"`
+}
+
+type xliffFile struct {
+	Original       string      `xml:"original,attr"`
+	SourceLanguage string      `xml:"source-language,attr"`
+	TargetLanguage string      `xml:"target-language,attr,omitempty"`
+	Datatype       string      `xml:"datatype,attr"`
+	Units          []xliffUnit `xml:"body>trans-unit"`
+}
+
+type xliffUnit struct {
+	Id     string       `xml:"id,attr"`
+	Source string       `xml:"source"`
+	Target *xliffTarget `xml:"target,omitempty"`
+	Note   string       `xml:"note,omitempty"`
+}
+
+type xliffTarget struct {
+	State string `xml:"state,attr,omitempty"`
+	Text  string `xml:",chardata"`
+}
+
+// WriteXLIFF пишет строки в формате XLIFF 1.2
+func WriteXLIFF(file File, sourceLocale string, original string) ([]byte, error) {
+	doc := xliffDocument{
+		Xmlns:   xliffNamespace,
+		Version: "1.2",
+		Files: []xliffFile{{
+			Original:       original,
+			SourceLanguage: sourceLocale,
+			TargetLanguage: file.Locale,
+			Datatype:       "plaintext",
+			Units:          make([]xliffUnit, 0, len(file.Units)),
+		}},
+	}
+
+	for _, unit := range file.Units {
+		xu := xliffUnit{Id: unit.Key, Source: unit.Source, Note: unit.Note}
+
+		if unit.Target != "" {
+			xu.Target = &xliffTarget{Text: unit.Target, State: "translated"}
+
+			if unit.Fuzzy {
+				xu.Target.State = xliffNeedsReview
+			}
+		}
+
+		doc.Files[0].Units = append(doc.Files[0].Units, xu)
+	}
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+
+	encoder := xml.NewEncoder(&b)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+
+	b.WriteString("\n")
+
+	return b.Bytes(), nil
+}
+
+// ReadXLIFF разбирает файл XLIFF 1.2. Строки всех <file> собираются вместе
+func ReadXLIFF(data []byte) (*File, error) {
+	var doc xliffDocument
+
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	if !strings.HasPrefix(doc.Version, "1.") {
+		return nil, fmt.Errorf("%w: unsupported XLIFF version %q", ErrInvalid, doc.Version)
+	}
+
+	file := &File{}
+
+	for _, xf := range doc.Files {
+		if file.Locale == "" {
+			file.Locale = xf.TargetLanguage
+		}
+
+		for _, xu := range xf.Units {
+			unit := Unit{Key: xu.Id, Source: xu.Source, Note: xu.Note}
+
+			if xu.Target != nil {
+				unit.Target = xu.Target.Text
+				unit.Fuzzy = strings.HasPrefix(xu.Target.State, "needs-")
+			}
+
+			file.Units = append(file.Units, unit)
+		}
+	}
+
+	return file, nil
+}
@@ -0,0 +1,372 @@
+package story
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Флаги точки выбора в скомпилированном Ink
+const inkInvisibleDefault = 0x8
+
+const inkRoot = "root"
+
+// Именованное содержимое, которое не становится фрагментом: "s" - начало текста
+// варианта выбора, "global decl" - объявления переменных
+var inkSkipNamed = map[string]bool{
+	"s":           true,
+	"global decl": true,
+	"#f":          true,
+	"#n":          true,
+}
+
+type inkParser struct {
+	containers map[string][]interface{} // путь - содержимое контейнера, включая безымянные
+	passages   []string                 // пути контейнеров, которые становятся фрагментами
+	isPassage  map[string]bool
+}
+
+// ParseInk читает JSON, скомпилированный inklecate. Фрагментами становятся корень,
+// узлы (knot), подузлы (stitch), тела вариантов выбора и точки сбора (gather).
+// Логика, переменные и условия Ink не переносятся
+func ParseInk(data []byte) (*Story, error) {
+	var compiled struct {
+		InkVersion int           `json:"inkVersion"`
+		Root       []interface{} `json:"root"`
+	}
+
+	if err := json.Unmarshal(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), &compiled); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	if compiled.InkVersion == 0 || compiled.Root == nil {
+		return nil, fmt.Errorf("%w: not a compiled ink story", ErrInvalid)
+	}
+
+	p := &inkParser{containers: map[string][]interface{}{}, isPassage: map[string]bool{}}
+	p.passages = append(p.passages, "")
+	p.isPassage[""] = true
+	p.index(compiled.Root, "")
+
+	story := &Story{Start: inkRoot}
+
+	for _, path := range p.passages {
+		story.Passages = append(story.Passages, p.passage(path))
+	}
+
+	collapseEmpty(story)
+
+	return story, nil
+}
+
+// index запоминает все контейнеры по путям и отмечает именованные как фрагменты
+func (p *inkParser) index(container []interface{}, path string) {
+	p.containers[path] = container
+
+	content, named := splitInkContainer(container)
+
+	for i, item := range content {
+		if child, ok := item.([]interface{}); ok {
+			p.index(child, joinInkPath(path, strconv.Itoa(i)))
+		}
+	}
+
+	names := make([]string, 0, len(named))
+
+	for name := range named {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		child, ok := named[name].([]interface{})
+
+		if !ok {
+			continue
+		}
+
+		childPath := joinInkPath(path, name)
+
+		if !inkSkipNamed[name] {
+			p.passages = append(p.passages, childPath)
+			p.isPassage[childPath] = true
+		}
+
+		p.index(child, childPath)
+	}
+}
+
+// inkWalker обходит содержимое одного фрагмента
+type inkWalker struct {
+	parser     *inkParser
+	passage    Passage
+	line       strings.Builder
+	evalDepth  int
+	inString   bool
+	inTag      bool
+	text       strings.Builder // текст внутри str ... /str
+	choiceText string
+	stopped    bool
+}
+
+func (p *inkParser) passage(path string) Passage {
+	w := &inkWalker{parser: p, passage: Passage{Name: p.passageName(path)}}
+
+	w.walk(p.containers[path], path)
+	w.flush()
+
+	return w.passage
+}
+
+func (w *inkWalker) walk(container []interface{}, path string) {
+	content, _ := splitInkContainer(container)
+
+	for i, item := range content {
+		if w.stopped {
+			return
+		}
+
+		itemPath := joinInkPath(path, strconv.Itoa(i))
+
+		switch value := item.(type) {
+		case string:
+			w.command(value)
+		case []interface{}:
+			w.walk(value, itemPath)
+		case map[string]interface{}:
+			w.object(value, itemPath)
+		}
+	}
+}
+
+func (w *inkWalker) command(value string) {
+	switch {
+	case strings.HasPrefix(value, "^"):
+		if w.inString {
+			w.text.WriteString(value[1:])
+		} else if w.evalDepth == 0 && !w.inTag {
+			w.line.WriteString(value[1:])
+		}
+	case value == "\n":
+		w.flush()
+	case value == "ev":
+		w.evalDepth++
+	case value == "/ev":
+		w.evalDepth--
+	case value == "str":
+		w.inString = true
+		w.text.Reset()
+	case value == "/str":
+		w.inString = false
+		w.choiceText = strings.TrimSpace(w.text.String())
+	case value == "#":
+		w.inTag = true
+	case value == "/#":
+		w.inTag = false
+	case value == "done" || value == "end":
+		w.stopped = true
+	}
+}
+
+func (w *inkWalker) object(value map[string]interface{}, path string) {
+	if target, ok := value["->"].(string); ok {
+		// Переходы по переменным и условные переходы не переносятся
+		if value["var"] != nil || value["c"] != nil {
+			return
+		}
+
+		resolved := resolveInkPath(target, path)
+
+		if lastInkComponent(resolved) == "s" {
+			// Начальный текст варианта выбора уже попал в текст варианта
+			if w.inString {
+				w.text.WriteString(w.parser.plainText(resolved))
+			}
+			return
+		}
+
+		if w.evalDepth > 0 || w.inString {
+			return
+		}
+
+		if w.passage.Next == "" {
+			w.passage.Next = w.parser.passageName(w.parser.owner(resolved))
+		}
+
+		w.stopped = true
+		return
+	}
+
+	if target, ok := value["*"].(string); ok {
+		flags, _ := value["flg"].(float64)
+		choice := Choice{Text: w.choiceText, Target: w.parser.passageName(w.parser.owner(resolveInkPath(target, path)))}
+		w.choiceText = ""
+
+		if int(flags)&inkInvisibleDefault != 0 || choice.Text == "" {
+			if w.passage.Next == "" {
+				w.passage.Next = choice.Target
+			}
+			return
+		}
+
+		w.passage.Choices = append(w.passage.Choices, choice)
+	}
+}
+
+func (w *inkWalker) flush() {
+	text := strings.TrimSpace(w.line.String())
+	w.line.Reset()
+
+	if text != "" {
+		w.passage.Lines = append(w.passage.Lines, ParseLine(text))
+	}
+}
+
+// plainText собирает текст контейнера без учета логики
+func (p *inkParser) plainText(path string) string {
+	var text strings.Builder
+
+	content, _ := splitInkContainer(p.containers[path])
+
+	for _, item := range content {
+		if value, ok := item.(string); ok && strings.HasPrefix(value, "^") {
+			text.WriteString(value[1:])
+		}
+	}
+
+	return text.String()
+}
+
+// owner возвращает путь фрагмента, которому принадлежит контейнер
+func (p *inkParser) owner(path string) string {
+	for {
+		if p.isPassage[path] {
+			return path
+		}
+
+		i := strings.LastIndex(path, ".")
+
+		if i < 0 {
+			return ""
+		}
+
+		path = path[:i]
+	}
+}
+
+func (p *inkParser) passageName(path string) string {
+	if path == "" {
+		return inkRoot
+	}
+
+	return path
+}
+
+// splitInkContainer отделяет содержимое контейнера от последнего элемента - словаря
+// именованного содержимого или null, который inklecate пишет всегда
+func splitInkContainer(container []interface{}) ([]interface{}, map[string]interface{}) {
+	if len(container) == 0 {
+		return nil, nil
+	}
+
+	named, _ := container[len(container)-1].(map[string]interface{})
+
+	return container[:len(container)-1], named
+}
+
+func joinInkPath(path string, component string) string {
+	if path == "" {
+		return component
+	}
+
+	return path + "." + component
+}
+
+// resolveInkPath переводит относительный путь (".^.c-0") в абсолютный.
+// Относительный путь отсчитывается от объекта, поэтому первый ^ - его контейнер
+func resolveInkPath(target string, from string) string {
+	if !strings.HasPrefix(target, ".") {
+		return target
+	}
+
+	var components []string
+
+	if from != "" {
+		components = strings.Split(from, ".")
+	}
+
+	for _, component := range strings.Split(target[1:], ".") {
+		if component == "^" {
+			if len(components) > 0 {
+				components = components[:len(components)-1]
+			}
+			continue
+		}
+
+		components = append(components, component)
+	}
+
+	return strings.Join(components, ".")
+}
+
+func lastInkComponent(path string) string {
+	return path[strings.LastIndex(path, ".")+1:]
+}
+
+// collapseEmpty убирает фрагменты без текста и вариантов, которые только передают
+// управление дальше, и перенаправляет ссылки на них
+func collapseEmpty(story *Story) {
+	forward := map[string]string{}
+
+	for _, passage := range story.Passages {
+		if len(passage.Lines) == 0 && len(passage.Choices) == 0 && passage.Next != "" {
+			forward[passage.Name] = passage.Next
+		}
+	}
+
+	resolve := func(name string) string {
+		for steps := 0; steps <= len(forward); steps++ {
+			next, ok := forward[name]
+
+			if !ok {
+				return name
+			}
+
+			name = next
+		}
+
+		return name
+	}
+
+	// Цикл из пустых фрагментов не схлопывается: один из них остается в истории
+	for name := range forward {
+		if _, cyclic := forward[resolve(name)]; cyclic {
+			delete(forward, name)
+		}
+	}
+
+	story.Start = resolve(story.Start)
+	passages := story.Passages[:0]
+
+	for _, passage := range story.Passages {
+		if _, ok := forward[passage.Name]; ok && passage.Name != story.Start {
+			continue
+		}
+
+		if passage.Next != "" {
+			passage.Next = resolve(passage.Next)
+		}
+
+		for i := range passage.Choices {
+			passage.Choices[i].Target = resolve(passage.Choices[i].Target)
+		}
+
+		passages = append(passages, passage)
+	}
+
+	story.Passages = passages
+}
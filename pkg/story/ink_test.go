@@ -0,0 +1,67 @@
+package story
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Скомпилированная inklecate история:
+//
+//	Alice: Hello!
+//	-> meet
+//	=== meet ===
+//	You see a door.
+//	* [Open it] Door opens.
+//	  -> hall
+//	* [Leave]
+//	  -> END
+//	=== hall ===
+//	Bob: Welcome.
+//	-> END
+const inkSample = `{"inkVersion":21,"root":[["^Alice: Hello!","\n",{"->":"meet"},["done",{"#n":"g-0"}],null],"done",{"meet":[["^You see a door.","\n","ev","str","^Open it","/str","/ev",{"*":".^.c-0","flg":20},"ev","str","^Leave","/str","/ev",{"*":".^.c-1","flg":20},{"c-0":["^ Door opens.","\n",{"->":"hall"},{"#f":5}],"c-1":["\n","end",{"#f":5}]}],null],"hall":["^Bob: Welcome.","\n","end",null],"global decl":["ev","/ev","end",null]}],"listDefs":{}}`
+
+func TestParseInk(t *testing.T) {
+	story, err := ParseInk([]byte(inkSample))
+
+	assert.NoError(t, err)
+	assert.Equal(t, &Story{
+		Start: "root",
+		Passages: []Passage{
+			{Name: "root", Lines: []Line{{Speaker: "Alice", Text: "Hello!"}}, Next: "meet"},
+			{Name: "hall", Lines: []Line{{Speaker: "Bob", Text: "Welcome."}}},
+			{
+				Name:  "meet",
+				Lines: []Line{{Text: "You see a door."}},
+				Choices: []Choice{
+					{Text: "Open it", Target: "meet.0.c-0"},
+					{Text: "Leave", Target: "meet.0.c-1"},
+				},
+			},
+			{Name: "meet.0.c-0", Lines: []Line{{Text: "Door opens."}}, Next: "hall"},
+			{Name: "meet.0.c-1"},
+		},
+	}, story)
+}
+
+func TestParseInkCollapsesEmptyPassages(t *testing.T) {
+	// Корень только переходит в knot, а вариант выбора сразу ведет в другой knot
+	data := `{"inkVersion":21,"root":[[{"->":"start"},null],"done",{"start":[["^Go?","\n","ev","str","^Yes","/str","/ev",{"*":".^.c-0","flg":20},{"c-0":["\n",{"->":"finish"},null]}],null],"finish":["^The end.","\n","end",null]}]}`
+
+	story, err := ParseInk([]byte(data))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "start", story.Start)
+	assert.Equal(t, []Passage{
+		{Name: "finish", Lines: []Line{{Text: "The end."}}},
+		{Name: "start", Lines: []Line{{Text: "Go?"}}, Choices: []Choice{{Text: "Yes", Target: "finish"}}},
+	}, story.Passages)
+}
+
+func TestParseInkInvalid(t *testing.T) {
+	_, err := ParseInk([]byte(`{"root": []}`))
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	_, err = ParseInk([]byte(`:: Twee`))
+	assert.ErrorIs(t, err, ErrInvalid)
+}
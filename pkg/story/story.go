@@ -0,0 +1,95 @@
+// Package story разбирает истории из внешних редакторов (Twine, Ink) в общее
+// представление: фрагменты с репликами, вариантами выбора и переходами
+package story
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var ErrInvalid = errors.New("invalid story")
+
+// Story - история, не зависящая от формата исходного файла
+type Story struct {
+	Title    string
+	Start    string // имя начального фрагмента
+	Passages []Passage
+}
+
+// Passage - фрагмент истории, из него получается узел главы
+type Passage struct {
+	Name    string
+	Lines   []Line
+	Choices []Choice // варианты, которые выбирает игрок
+	Next    string   // автоматический переход, если вариантов нет. Пусто - концовка
+}
+
+// Line - строка текста. Строка вида "Имя: реплика" считается речью персонажа,
+// если ResolveSpeakers не отнесет ее к тексту рассказчика
+type Line struct {
+	Speaker string // пусто - текст рассказчика
+	Text    string
+}
+
+type Choice struct {
+	Text   string
+	Target string // имя фрагмента
+}
+
+// Passage возвращает фрагмент по имени
+func (s *Story) Passage(name string) (*Passage, bool) {
+	for i := range s.Passages {
+		if s.Passages[i].Name == name {
+			return &s.Passages[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// Speakers возвращает говорящих в порядке первого появления
+func (s *Story) Speakers() []string {
+	seen := map[string]bool{}
+	var speakers []string
+
+	for _, passage := range s.Passages {
+		for _, line := range passage.Lines {
+			if line.Speaker != "" && !seen[line.Speaker] {
+				seen[line.Speaker] = true
+				speakers = append(speakers, line.Speaker)
+			}
+		}
+	}
+
+	return speakers
+}
+
+var speakerLine = regexp.MustCompile(`^([\p{L}\p{N} _'-]{1,40}):\s+(\S.*)$`)
+
+// ParseLine отделяет имя говорящего от реплики
+func ParseLine(text string) Line {
+	text = strings.TrimSpace(text)
+
+	if match := speakerLine.FindStringSubmatch(text); match != nil {
+		return Line{Speaker: strings.TrimSpace(match[1]), Text: strings.TrimSpace(match[2])}
+	}
+
+	return Line{Text: text}
+}
+
+// ResolveSpeakers возвращает тексту рассказчика строки "Слово: текст", где слово
+// не имя говорящего, например "Поздно ночью: все спали."
+func (s *Story) ResolveSpeakers(narration func(speaker string) bool) {
+	for i := range s.Passages {
+		passage := &s.Passages[i]
+
+		for j, line := range passage.Lines {
+			if line.Speaker == "" || !narration(line.Speaker) {
+				continue
+			}
+
+			passage.Lines[j] = Line{Text: line.Speaker + ": " + line.Text}
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package story
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveSpeakers(t *testing.T) {
+	story := &Story{
+		Passages: []Passage{
+			{
+				Name: "Начало",
+				Lines: []Line{
+					ParseLine("Алиса: Привет!"),
+					ParseLine("Поздно ночью: все спали."),
+					ParseLine("Тишина."),
+				},
+			},
+		},
+	}
+
+	story.ResolveSpeakers(func(speaker string) bool { return speaker == "Поздно ночью" })
+
+	assert.Equal(t, []Line{
+		{Speaker: "Алиса", Text: "Привет!"},
+		{Text: "Поздно ночью: все спали."},
+		{Text: "Тишина."},
+	}, story.Passages[0].Lines)
+	assert.Equal(t, []string{"Алиса"}, story.Speakers())
+}
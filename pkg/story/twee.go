@@ -0,0 +1,220 @@
+package story
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Служебные фрагменты Twine и его форматов, которые не являются сценами
+var tweeSpecial = map[string]bool{
+	"StoryTitle":    true,
+	"StoryData":     true,
+	"StoryInit":     true,
+	"StoryCaption":  true,
+	"StoryMenu":     true,
+	"StoryBanner":   true,
+	"StorySubtitle": true,
+	"StoryAuthor":   true,
+	"PassageReady":  true,
+	"PassageDone":   true,
+	"PassageHeader": true,
+	"PassageFooter": true,
+}
+
+var tweeSpecialTags = map[string]bool{
+	"script":     true,
+	"stylesheet": true,
+	"widget":     true,
+}
+
+var (
+	tweeLink    = regexp.MustCompile(`\[\[(.+?)\]\]`)
+	tweeComment = regexp.MustCompile(`(?s)<!--.*?-->|/\*.*?\*/`)
+)
+
+type tweePassage struct {
+	name string
+	tags []string
+	body []string
+}
+
+// ParseTwee читает файл Twee 3. Ссылки [[...]] становятся вариантами выбора,
+// остальные непустые строки - репликами. Макросы форматов историй не исполняются
+func ParseTwee(data []byte) (*Story, error) {
+	var passages []*tweePassage
+
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if strings.HasPrefix(line, "::") {
+			name, tags := parseTweeHeader(line[2:])
+			passages = append(passages, &tweePassage{name: name, tags: tags})
+			continue
+		}
+
+		if len(passages) > 0 {
+			current := passages[len(passages)-1]
+			current.body = append(current.body, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	story := &Story{}
+	var start string
+
+	for _, passage := range passages {
+		text := strings.TrimSpace(strings.Join(passage.body, "\n"))
+
+		switch passage.name {
+		case "StoryTitle":
+			story.Title = text
+		case "StoryData":
+			var storyData struct {
+				Start string `json:"start"`
+			}
+
+			if err := json.Unmarshal([]byte(text), &storyData); err != nil {
+				return nil, fmt.Errorf("%w: StoryData: %v", ErrInvalid, err)
+			}
+
+			start = storyData.Start
+		}
+
+		if tweeSpecial[passage.name] || hasTweeSpecialTag(passage.tags) {
+			continue
+		}
+
+		if _, ok := story.Passage(passage.name); ok {
+			return nil, fmt.Errorf("%w: duplicate passage %q", ErrInvalid, passage.name)
+		}
+
+		story.Passages = append(story.Passages, parseTweeBody(passage.name, text))
+	}
+
+	if len(story.Passages) == 0 {
+		return nil, fmt.Errorf("%w: no passages", ErrInvalid)
+	}
+
+	switch {
+	case start != "":
+		story.Start = start
+	case hasPassage(story, "Start"):
+		story.Start = "Start"
+	default:
+		story.Start = story.Passages[0].Name
+	}
+
+	return story, nil
+}
+
+// parseTweeHeader разбирает заголовок ":: Имя [теги] {метаданные}"
+func parseTweeHeader(header string) (string, []string) {
+	var name strings.Builder
+	rest := ""
+
+	header = strings.TrimSpace(header)
+
+	for i := 0; i < len(header); i++ {
+		c := header[i]
+
+		if c == '\\' && i+1 < len(header) {
+			i++
+			name.WriteByte(header[i])
+			continue
+		}
+
+		if c == '[' || c == '{' {
+			rest = header[i:]
+			break
+		}
+
+		name.WriteByte(c)
+	}
+
+	var tags []string
+
+	if strings.HasPrefix(rest, "[") {
+		if end := strings.Index(rest, "]"); end > 0 {
+			tags = strings.Fields(rest[1:end])
+		}
+	}
+
+	return strings.TrimSpace(name.String()), tags
+}
+
+func parseTweeBody(name string, text string) Passage {
+	passage := Passage{Name: name}
+
+	text = tweeComment.ReplaceAllString(text, "")
+
+	for _, raw := range strings.Split(text, "\n") {
+		for _, match := range tweeLink.FindAllStringSubmatch(raw, -1) {
+			passage.Choices = append(passage.Choices, parseTweeLink(match[1]))
+		}
+
+		// Строка только из ссылок - это меню, а не реплика
+		if isOnlyLinks(raw) {
+			continue
+		}
+
+		// Текст ссылки внутри предложения остается в реплике
+		line := tweeLink.ReplaceAllStringFunc(raw, func(link string) string {
+			return parseTweeLink(tweeLink.FindStringSubmatch(link)[1]).Text
+		})
+
+		passage.Lines = append(passage.Lines, ParseLine(line))
+	}
+
+	return passage
+}
+
+// parseTweeLink поддерживает [[Цель]], [[Текст|Цель]], [[Текст->Цель]] и [[Цель<-Текст]]
+func parseTweeLink(link string) Choice {
+	// Сеттер SugarCube [[Текст|Цель][$x to 1]] отбрасывается
+	if i := strings.Index(link, "]["); i >= 0 {
+		link = link[:i]
+	}
+
+	var text, target string
+
+	if i := strings.LastIndex(link, "->"); i >= 0 {
+		text, target = link[:i], link[i+2:]
+	} else if i := strings.Index(link, "<-"); i >= 0 {
+		target, text = link[:i], link[i+2:]
+	} else if i := strings.LastIndex(link, "|"); i >= 0 {
+		text, target = link[:i], link[i+1:]
+	} else {
+		text, target = link, link
+	}
+
+	return Choice{Text: strings.TrimSpace(text), Target: strings.TrimSpace(target)}
+}
+
+func isOnlyLinks(line string) bool {
+	return strings.TrimSpace(tweeLink.ReplaceAllString(line, "")) == ""
+}
+
+func hasTweeSpecialTag(tags []string) bool {
+	for _, tag := range tags {
+		if tweeSpecialTags[tag] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasPassage(story *Story, name string) bool {
+	_, ok := story.Passage(name)
+	return ok
+}
@@ -0,0 +1,67 @@
+package story
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTwee(t *testing.T) {
+	data := []byte(`:: StoryTitle
+Встреча
+
+:: StoryData
+{"ifid": "D674C58C-DEFA-4F70-B7A2-27742230C0FC", "format": "Harlowe", "start": "Начало"}
+
+:: UserScript [script]
+window.x = 1;
+
+:: Начало [intro] {"position":"100,100"}
+Алиса: Привет!
+Ты стоишь у [[двери|Дверь]].
+/* заметка автора */
+[[Уйти->Конец]]
+
+:: Дверь
+Дверь открыта.
+[[Конец<-Войти]]
+
+:: Конец
+Конец истории.
+`)
+
+	story, err := ParseTwee(data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &Story{
+		Title: "Встреча",
+		Start: "Начало",
+		Passages: []Passage{
+			{
+				Name: "Начало",
+				Lines: []Line{
+					{Speaker: "Алиса", Text: "Привет!"},
+					{Text: "Ты стоишь у двери."},
+				},
+				Choices: []Choice{{Text: "двери", Target: "Дверь"}, {Text: "Уйти", Target: "Конец"}},
+			},
+			{
+				Name:    "Дверь",
+				Lines:   []Line{{Text: "Дверь открыта."}},
+				Choices: []Choice{{Text: "Войти", Target: "Конец"}},
+			},
+			{
+				Name:  "Конец",
+				Lines: []Line{{Text: "Конец истории."}},
+			},
+		},
+	}, story)
+}
+
+func TestParseTweeErrors(t *testing.T) {
+	_, err := ParseTwee([]byte("просто текст без фрагментов"))
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	_, err = ParseTwee([]byte(":: A\nодин\n:: A\nдва\n"))
+	assert.ErrorIs(t, err, ErrInvalid)
+}
@@ -34,6 +34,7 @@ CREATE TABLE IF NOT EXISTS chapters (
     status INTEGER NOT NULL CHECK (status IN (1, 2, 3)),
     updated_at JSONB NOT NULL DEFAULT '{}'::jsonb,
     author INTEGER NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (author) REFERENCES admins(id)
     );
@@ -59,10 +60,11 @@ CREATE TABLE IF NOT EXISTS media (
 -- Создание таблицы узлов
 CREATE TABLE IF NOT EXISTS nodes (
                                      id SERIAL PRIMARY KEY,
-                                     slug VARCHAR(255) UNIQUE NOT NULL,
+                                     slug VARCHAR(255) NOT NULL,
     chapter_id INTEGER NOT NULL,
     music_id INTEGER,
     background_id INTEGER,
+    version INTEGER NOT NULL DEFAULT 1,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (chapter_id) REFERENCES chapters(id),
     FOREIGN KEY (music_id) REFERENCES media(id),
@@ -95,9 +97,90 @@ CREATE TABLE IF NOT EXISTS players (
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
     );
 
+-- Создание таблицы переменных сюжета
+CREATE TABLE IF NOT EXISTS variables (
+                                         id BIGINT PRIMARY KEY,
+                                         chapter_id BIGINT NOT NULL DEFAULT 0,
+                                         name VARCHAR(255) NOT NULL,
+                                         type VARCHAR(16) NOT NULL CHECK (type IN ('int', 'flag')),
+                                         "default" BIGINT NOT NULL DEFAULT 0
+    );
+
+-- Создание таблицы значений переменных сюжета игроков
+CREATE TABLE IF NOT EXISTS player_variables (
+                                                id SERIAL PRIMARY KEY,
+                                                player_id BIGINT NOT NULL,
+                                                chapter_id BIGINT NOT NULL DEFAULT 0,
+                                                name VARCHAR(255) NOT NULL,
+                                                value BIGINT NOT NULL DEFAULT 0
+    );
+
+-- Создание таблицы удаленных глав (хранятся до окончательного удаления)
+CREATE TABLE IF NOT EXISTS deleted_chapters (
+                                                id BIGINT PRIMARY KEY,
+                                                snapshot JSONB NOT NULL,
+                                                deleted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+-- Создание таблицы опубликованных версий глав (снимки не изменяются)
+CREATE TABLE IF NOT EXISTS chapter_versions (
+                                                id SERIAL PRIMARY KEY,
+                                                chapter_id BIGINT NOT NULL,
+                                                version INTEGER NOT NULL,
+                                                content JSONB NOT NULL,
+                                                published_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+-- Создание таблицы версий глав, которые проходят игроки
+CREATE TABLE IF NOT EXISTS player_chapter_versions (
+                                                id SERIAL PRIMARY KEY,
+                                                player_id BIGINT NOT NULL,
+                                                chapter_id BIGINT NOT NULL,
+                                                version_id BIGINT NOT NULL REFERENCES chapter_versions(id)
+    );
+
+-- Создание таблицы ревизий глав и узлов
+CREATE TABLE IF NOT EXISTS revisions (
+                                         id SERIAL PRIMARY KEY,
+                                         entity_type VARCHAR(16) NOT NULL,
+                                         entity_id BIGINT NOT NULL,
+                                         chapter_id BIGINT NOT NULL,
+                                         author BIGINT NOT NULL DEFAULT 0,
+                                         data JSONB NOT NULL,
+                                         created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+-- Создание таблицы блокировок узлов на время редактирования
+CREATE TABLE IF NOT EXISTS node_locks (
+                                          node_id BIGINT PRIMARY KEY,
+                                          admin_id BIGINT NOT NULL,
+                                          expires_at TIMESTAMP NOT NULL
+    );
+
+-- Создание таблицы переводов текста глав и имен персонажей
+CREATE TABLE IF NOT EXISTS translations (
+                                            id SERIAL PRIMARY KEY,
+                                            locale VARCHAR(35) NOT NULL,
+                                            chapter_id BIGINT NOT NULL DEFAULT 0,
+                                            key TEXT NOT NULL,
+                                            source TEXT NOT NULL DEFAULT '',
+                                            text TEXT NOT NULL DEFAULT '',
+                                            updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
 -- Создание индексов для оптимизации поиска
 CREATE INDEX IF NOT EXISTS idx_chapters_author ON chapters(author);
 CREATE INDEX IF NOT EXISTS idx_nodes_chapter ON nodes(chapter_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_node_chapter_slug ON nodes(chapter_id, slug);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_variable_name ON variables(chapter_id, name);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_player_variable ON player_variables(player_id, chapter_id, name);
+CREATE INDEX IF NOT EXISTS idx_deleted_chapters_deleted_at ON deleted_chapters(deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_chapter_version ON chapter_versions(chapter_id, version);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_player_chapter_version ON player_chapter_versions(player_id, chapter_id);
+CREATE INDEX IF NOT EXISTS idx_revision_entity ON revisions(entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_revisions_chapter_id ON revisions(chapter_id);
+CREATE INDEX IF NOT EXISTS idx_node_locks_expires_at ON node_locks(expires_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_translation_key ON translations(locale, chapter_id, key);
 CREATE INDEX IF NOT EXISTS idx_requests_admin ON requests(requesting_admin);
 CREATE INDEX IF NOT EXISTS idx_requests_chapter ON requests(requested_chapter_id);
 CREATE INDEX IF NOT EXISTS idx_players_email ON players(email) USING GIST;
\ No newline at end of file